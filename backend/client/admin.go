@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The methods in this file need an admin session.
+
+// User is an account as seen by an admin.
+type User struct {
+	ID        uint      `json:"ID"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"` // user or admin
+	Language  string    `json:"language"`
+}
+
+// UserUpdate changes an account. Name and role are always set; the
+// password only when not empty.
+type UserUpdate struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"`
+}
+
+// ListUsers returns a page of all users, by ID.
+func (c *Client) ListUsers(ctx context.Context, p Page) (*List[User], error) {
+	return list[User](ctx, c, "/admin/users", p)
+}
+
+// AllUsers iterates over all users, by ID, fetching pageSize at a time.
+func (c *Client) AllUsers(ctx context.Context, pageSize int) iter.Seq2[User, error] {
+	return all[User](ctx, c, "/admin/users", pageSize)
+}
+
+// UpdateUser changes a user's name, role and optionally password.
+func (c *Client) UpdateUser(ctx context.Context, id uint, u UserUpdate) error {
+	_, err := c.do(ctx, request{method: http.MethodPut, path: "/admin/users/" + strconv.FormatUint(uint64(id), 10), body: u}, nil)
+	return err
+}
+
+// DeleteUser deletes a user.
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/admin/users/" + strconv.FormatUint(uint64(id), 10)}, nil)
+	return err
+}
+
+// ListAllEntries returns a page of every user's entries, by ID.
+func (c *Client) ListAllEntries(ctx context.Context, p Page) (*List[Entry], error) {
+	return list[Entry](ctx, c, "/admin/entries", p)
+}
+
+// EveryEntry iterates over every user's entries, by ID, fetching pageSize
+// at a time.
+func (c *Client) EveryEntry(ctx context.Context, pageSize int) iter.Seq2[Entry, error] {
+	return all[Entry](ctx, c, "/admin/entries", pageSize)
+}
+
+// UpdateAnyEntry replaces the editable fields of any user's entry.
+func (c *Client) UpdateAnyEntry(ctx context.Context, id uint, in EntryInput) (*Entry, error) {
+	return c.entry(ctx, request{method: http.MethodPut, path: "/admin" + entryPath(id), body: in})
+}
+
+// DeleteAnyEntry deletes any user's entry.
+func (c *Client) DeleteAnyEntry(ctx context.Context, id uint) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/admin" + entryPath(id)}, nil)
+	return err
+}
+
+// AdminBatchEntries applies operations to any entries; only admins may
+// use OpMove. Rolled-back atomic batches return ErrBatchRolledBack.
+func (c *Client) AdminBatchEntries(ctx context.Context, mode string, ops []BatchOp) (*BatchResponse, error) {
+	return c.batch(ctx, "/admin/entries/batch", mode, ops)
+}
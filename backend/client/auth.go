@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Session is the answer to a login or refresh.
+type Session struct {
+	Token    string `json:"token"`
+	Role     string `json:"role"` // user or admin
+	Username string `json:"username"`
+	Language string `json:"language"`
+}
+
+// Profile is the logged-in user.
+type Profile struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Language string `json:"language"`
+}
+
+// Registration is a new account.
+type Registration struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"` // at least 6 characters
+	Language string `json:"language,omitempty"`
+}
+
+// Register creates an account and returns its ID. It does not log in.
+func (c *Client) Register(ctx context.Context, r Registration) (uint, error) {
+	var out struct {
+		ID uint `json:"id"`
+	}
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/users", body: r, idempotent: true}, &out)
+	return out.ID, err
+}
+
+// Login authenticates with an email and password, or a user name for
+// logins without an @ such as the seeded admin, and uses the returned token
+// for the following calls.
+func (c *Client) Login(ctx context.Context, login, password string) (*Session, error) {
+	body := map[string]string{"email": login, "password": password}
+	if !strings.Contains(login, "@") {
+		body = map[string]string{"name": login, "password": password}
+	}
+	return c.session(ctx, request{method: http.MethodPost, path: "/session", body: body})
+}
+
+// Refresh exchanges the current token for a new one, which also picks up
+// changes to the user's role or language.
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	return c.session(ctx, request{method: http.MethodPost, path: "/session/refresh"})
+}
+
+func (c *Client) session(ctx context.Context, req request) (*Session, error) {
+	var s Session
+	if _, err := c.do(ctx, req, &s); err != nil {
+		return nil, err
+	}
+	c.SetToken(s.Token)
+	return &s, nil
+}
+
+// Logout ends the session and forgets the token.
+func (c *Client) Logout(ctx context.Context) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: "/session"}, nil)
+	if err == nil {
+		c.SetToken("")
+	}
+	return err
+}
+
+// Me returns the logged-in user.
+func (c *Client) Me(ctx context.Context) (*Profile, error) {
+	var p Profile
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/me"}, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,253 @@
+// Package client is a Go client for the Reminder-Card API (/api/v1).
+//
+//	c, err := client.New("https://reminders.example.com")
+//	...
+//	if _, err := c.Login(ctx, "ann@example.com", "secret"); err != nil { ... }
+//	for entry, err := range c.AllEntries(ctx, 100) { ... }
+//
+// Failed requests return an *Error carrying the server's error code, so
+// callers can test for them with errors.Is(err, client.EntryNotFound).
+// Requests failing with a network error or a temporary status (429, 502,
+// 503, 504) are retried with backoff. Registration, entry creation and
+// batches are sent with an Idempotency-Key, so a retry of those is never
+// applied twice. The server doesn't deduplicate other POSTs, such as
+// imports, uploads and sync, so they are only retried when no response
+// came back at all, and may then be applied twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const userAgent = "remindercard-go-client/1"
+
+// Client calls the API. It is safe for concurrent use; the session token
+// set by Login is shared by every call.
+type Client struct {
+	baseURL  *url.URL
+	http     *http.Client
+	language string
+	retry    Retry
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Retry controls how failed requests are retried. The wait before attempt
+// n+1 is Base*2^n with jitter, capped at Max, unless the server sends a
+// longer Retry-After.
+type Retry struct {
+	Attempts int // total tries, including the first; 1 disables retries
+	Base     time.Duration
+	Max      time.Duration
+}
+
+// DefaultRetry is used unless WithRetry says otherwise.
+var DefaultRetry = Retry{Attempts: 3, Base: 200 * time.Millisecond, Max: 5 * time.Second}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client, e.g. for timeouts or a
+// custom transport. The default is http.DefaultClient.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.http = h }
+}
+
+// WithToken starts the client with an existing session token.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithLanguage asks for messages in the given language (en or ru) when the
+// user has no stored preference.
+func WithLanguage(lang string) Option {
+	return func(c *Client) { c.language = lang }
+}
+
+// WithRetry replaces DefaultRetry.
+func WithRetry(r Retry) Option {
+	return func(c *Client) { c.retry = r }
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "https://reminders.example.com". A trailing /api/v1 may be included.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q is not absolute", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/api/v1")
+
+	c := &Client{baseURL: u, http: http.DefaultClient, retry: DefaultRetry}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.retry.Attempts < 1 {
+		c.retry.Attempts = 1
+	}
+	return c, nil
+}
+
+// Token is the current session token, empty when logged out.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetToken replaces the session token.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// request describes one API call. path is relative to /api/v1.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	accept int // an error status answered with a regular JSON body
+
+	// idempotent POSTs go to routes that honour Idempotency-Key; other
+	// POSTs are not retried once the server has answered
+	idempotent bool
+
+	// raw is sent as is, instead of body as JSON
+	raw         []byte
+	contentType string
+}
+
+// do sends req, retrying as configured, and decodes a successful JSON
+// response into out (if not nil). Failures are returned as *Error.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (http.Header, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && (resp.StatusCode != req.accept || isProblem(resp)) {
+		return resp.Header, parseError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.Header, fmt.Errorf("client: decoding %s %s: %w", req.method, req.path, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// send performs req with retries and returns the last response, whose body
+// the caller must close.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	body, contentType := req.raw, req.contentType
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+	u := *c.baseURL
+	u.Path += "/api/v1" + req.path
+	u.RawQuery = req.query.Encode()
+
+	// One key for every attempt, so the server applies the request once
+	var idempotencyKey string
+	if req.idempotent {
+		idempotencyKey = newKey()
+	}
+	// An answer, even a 503 from a proxy, may come after the server applied
+	// the request
+	once := req.method == http.MethodPost && !req.idempotent
+
+	for attempt := 1; ; attempt++ {
+		hreq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		hreq.Header.Set("Accept", "application/json")
+		hreq.Header.Set("User-Agent", userAgent)
+		if contentType != "" {
+			hreq.Header.Set("Content-Type", contentType)
+		}
+		if idempotencyKey != "" {
+			hreq.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		if c.language != "" {
+			hreq.Header.Set("Accept-Language", c.language)
+		}
+		if token := c.Token(); token != "" {
+			hreq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.http.Do(hreq)
+		if attempt >= c.retry.Attempts || !retryable(resp, err) || once && resp != nil {
+			return resp, err
+		}
+		wait := c.backoff(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryable reports whether a request may succeed if sent again.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		// The first attempt with this Idempotency-Key is still running
+		return resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+func (c *Client) backoff(attempt int, resp *http.Response) time.Duration {
+	wait := c.retry.Base << (attempt - 1)
+	if wait <= 0 || wait > c.retry.Max {
+		wait = c.retry.Max
+	}
+	wait = wait/2 + mrand.N(wait/2+1)
+	if resp != nil {
+		if after := retryAfter(resp.Header); after > wait {
+			wait = after
+		}
+	}
+	return wait
+}
+
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,239 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Base/client"
+	"Base/internal/apierror"
+	"Base/internal/models"
+	"Base/internal/testdb"
+	"Base/internal/testserver"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newServer runs the real router on a fresh database with one admin,
+// admin@example.com / adminpass.
+func newServer(t *testing.T) string {
+	t.Helper()
+	db := testdb.Open(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("adminpass"), bcrypt.MinCost)
+	db.Create(&models.User{Name: "admin", Email: "admin@example.com", Password: string(hash), Role: "admin"})
+	return testserver.Start(t, db)
+}
+
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	t.Helper()
+	c, err := client.New(url, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClientAgainstRouter(t *testing.T) {
+	ctx := context.Background()
+	url := newServer(t)
+	c := newClient(t, url)
+
+	if _, err := c.Me(ctx); !errors.Is(err, client.Unauthorized) {
+		t.Fatalf("Me without a session: err = %v, want unauthorized", err)
+	}
+	if _, err := c.Register(ctx, client.Registration{Name: "Ann", Email: "ann@example.com", Password: "secret1"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Register(ctx, client.Registration{Name: "Ann", Email: "not-an-email", Password: "1"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != client.ValidationFailed || len(apiErr.Fields) == 0 || apiErr.RequestID == "" {
+		t.Fatalf("invalid registration: err = %#v", err)
+	}
+	if _, err := c.Login(ctx, "ann@example.com", "wrong"); !errors.Is(err, client.InvalidCredentials) {
+		t.Fatalf("wrong password: err = %v", err)
+	}
+	if _, err := c.Login(ctx, "ann@example.com", "secret1"); err != nil {
+		t.Fatal(err)
+	}
+	me, err := c.Me(ctx)
+	if err != nil || me.Email != "ann@example.com" || me.Role != "user" {
+		t.Fatalf("Me = %+v, %v", me, err)
+	}
+
+	// Entry CRUD
+	var ids []uint
+	for _, text := range []string{"one", "two", "three", "four", "five"} {
+		e, err := c.CreateEntry(ctx, client.EntryInput{Situation: "s", Text: text, Icon: "star", Colour: "purple"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, e.ID)
+	}
+	e, err := c.GetEntry(ctx, ids[0])
+	if err != nil || e.Text != "one" {
+		t.Fatalf("GetEntry = %+v, %v", e, err)
+	}
+	in := e.Input()
+	in.Tags = "work"
+	if e, err = c.UpdateEntry(ctx, e.ID, in); err != nil || e.Tags != "work" || e.Text != "one" {
+		t.Fatalf("UpdateEntry = %+v, %v", e, err)
+	}
+	if err := c.DeleteEntry(ctx, ids[4]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetEntry(ctx, ids[4]); !errors.Is(err, client.EntryNotFound) {
+		t.Fatalf("deleted entry: err = %v", err)
+	}
+
+	// Pagination
+	page, err := c.ListEntries(ctx, client.Page{Limit: 3})
+	if err != nil || len(page.Items) != 3 || page.Next == nil || page.Next.Offset != 3 {
+		t.Fatalf("first page = %+v, %v", page, err)
+	}
+	var texts []string
+	for e, err := range c.AllEntries(ctx, 2) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts = append(texts, e.Text)
+	}
+	if len(texts) != 4 || texts[0] != "four" || texts[3] != "one" {
+		t.Fatalf("AllEntries = %v", texts)
+	}
+
+	// Batches
+	resp, err := c.BatchEntries(ctx, client.BatchAtomic, []client.BatchOp{
+		{Op: client.OpTag, ID: ids[1], AddTags: []string{"home"}},
+		{Op: client.OpDelete, ID: 9999},
+	})
+	if !errors.Is(err, client.ErrBatchRolledBack) || resp == nil || resp.Results[0].Status != "rolled_back" {
+		t.Fatalf("failing atomic batch = %+v, %v", resp, err)
+	}
+	if resp, err = c.BatchEntries(ctx, client.BatchPerItem, []client.BatchOp{{Op: client.OpTag, ID: ids[1], AddTags: []string{"home"}}}); err != nil || resp.Succeeded != 1 {
+		t.Fatalf("batch = %+v, %v", resp, err)
+	}
+
+	// Admin operations
+	if _, err := c.ListUsers(ctx, client.Page{}); !errors.Is(err, client.Forbidden) {
+		t.Fatalf("ListUsers as a user: err = %v", err)
+	}
+	admin := newClient(t, url+"/api/v1")
+	if _, err := admin.Login(ctx, "admin@example.com", "adminpass"); err != nil {
+		t.Fatal(err)
+	}
+	var users []client.User
+	for u, err := range admin.AllUsers(ctx, 1) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	if len(users) != 2 || users[1].Email != "ann@example.com" {
+		t.Fatalf("AllUsers = %+v", users)
+	}
+	if err := admin.UpdateUser(ctx, users[1].ID, client.UserUpdate{Name: "Anna", Role: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, err := range admin.EveryEntry(ctx, 500) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count != 4 {
+		t.Errorf("EveryEntry returned %d entries, want 4", count)
+	}
+	if err := admin.DeleteAnyEntry(ctx, ids[3]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refresh picks up the new name; logout forgets the token
+	s, err := c.Refresh(ctx)
+	if err != nil || s.Username != "Anna" || c.Token() != s.Token {
+		t.Fatalf("Refresh = %+v, %v", s, err)
+	}
+	if err := c.Logout(ctx); err != nil || c.Token() != "" {
+		t.Fatalf("Logout: %v, token %q", err, c.Token())
+	}
+}
+
+func TestClientRetriesWithSameIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	keys := make(chan string, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get("Idempotency-Key")
+		if calls.Add(1) < 3 {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"code":"service_unavailable","status":503}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ID":7,"text":"t"}`))
+	}))
+	defer srv.Close()
+
+	c := newClient(t, srv.URL, client.WithRetry(client.Retry{Attempts: 3, Base: time.Millisecond, Max: 5 * time.Millisecond}))
+	e, err := c.CreateEntry(context.Background(), client.EntryInput{Text: "t"})
+	if err != nil || e.ID != 7 {
+		t.Fatalf("CreateEntry = %+v, %v", e, err)
+	}
+	first := <-keys
+	if first == "" || <-keys != first || <-keys != first {
+		t.Error("retries were not sent with the same Idempotency-Key")
+	}
+
+	calls.Store(0)
+	c = newClient(t, srv.URL, client.WithRetry(client.Retry{Attempts: 1}))
+	if _, err := c.CreateEntry(context.Background(), client.EntryInput{}); !errors.Is(err, client.Unavailable) {
+		t.Fatalf("without retries: err = %v, want service_unavailable", err)
+	}
+	<-keys
+
+	// Imports aren't deduplicated by the server, so an answered one isn't retried
+	calls.Store(0)
+	c = newClient(t, srv.URL, client.WithRetry(client.Retry{Attempts: 3, Base: time.Millisecond, Max: 5 * time.Millisecond}))
+	if _, err := c.ImportEntries(context.Background(), "entries.json", strings.NewReader("[]"), false); !errors.Is(err, client.Unavailable) {
+		t.Fatalf("import: err = %v, want service_unavailable", err)
+	}
+	if n := calls.Load(); n != 1 || <-keys != "" {
+		t.Errorf("import was sent %d times, want once without an Idempotency-Key", n)
+	}
+}
+
+// The client's codes must stay in step with the server's.
+func TestCodesMatchServer(t *testing.T) {
+	pairs := map[client.Code]apierror.Code{
+		client.InvalidJSON: apierror.InvalidJSON, client.ValidationFailed: apierror.ValidationFailed,
+		client.MissingFields: apierror.MissingFields, client.InvalidReminder: apierror.InvalidReminder,
+		client.InvalidID: apierror.InvalidID, client.InvalidCursor: apierror.InvalidCursor,
+		client.InvalidBatch: apierror.InvalidBatch, client.TooManyChanges: apierror.TooManyChanges,
+		client.FileRequired: apierror.FileRequired, client.FileUnreadable: apierror.FileUnreadable,
+		client.FileTooLarge: apierror.FileTooLarge, client.FileTypeNotAllowed: apierror.FileTypeNotAllowed,
+		client.UnsupportedFormat: apierror.UnsupportedFormat, client.InvalidFile: apierror.InvalidFile,
+		client.ChecksumMismatch: apierror.ChecksumMismatch, client.BodyTooLarge: apierror.BodyTooLarge,
+		client.Unauthorized: apierror.Unauthorized, client.InvalidToken: apierror.InvalidToken,
+		client.Forbidden: apierror.Forbidden, client.InvalidCredentials: apierror.InvalidCredentials,
+		client.CredentialsMissing: apierror.CredentialsMissing, client.RouteNotFound: apierror.RouteNotFound,
+		client.EntryNotFound: apierror.EntryNotFound, client.UserNotFound: apierror.UserNotFound,
+		client.AttachmentNotFound: apierror.AttachmentNotFound, client.ThumbnailNotFound: apierror.ThumbnailNotFound,
+		client.ThumbnailPending: apierror.ThumbnailPending, client.ExportNotFound: apierror.ExportNotFound,
+		client.CalendarNotFound: apierror.CalendarNotFound, client.EmailTaken: apierror.EmailTaken,
+		client.ExportInProgress: apierror.ExportInProgress, client.ExportUnavailable: apierror.ExportUnavailable,
+		client.LinkExpired: apierror.LinkExpired, client.IdempotencyKeyReused: apierror.IdempotencyKeyReused,
+		client.IdempotencyKeyBusy: apierror.IdempotencyKeyBusy, client.IdempotencyKeyTooLong: apierror.IdempotencyKeyTooLong,
+		client.DatabaseError: apierror.DatabaseError, client.StorageError: apierror.StorageError,
+		client.InternalError: apierror.InternalError, client.Unavailable: apierror.Unavailable,
+	}
+	for got, want := range pairs {
+		if string(got) != string(want) {
+			t.Errorf("client code %q, server code %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Entry is a reminder card.
+type Entry struct {
+	ID         uint       `json:"ID"`
+	CreatedAt  time.Time  `json:"CreatedAt"`
+	UpdatedAt  time.Time  `json:"UpdatedAt"`
+	UserID     uint       `json:"user_id"`
+	Situation  string     `json:"situation"`
+	Text       string     `json:"text"`
+	Icon       string     `json:"icon"`
+	Colour     string     `json:"colour"`
+	Tags       string     `json:"tags"` // space-separated
+	RemindAt   *time.Time `json:"remind_at"`
+	Recurrence string     `json:"recurrence"` // RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO
+	ClientID   string     `json:"client_id,omitempty"`
+}
+
+// EntryInput is the editable part of an entry. An update replaces every
+// field, so start from Entry.Input to change only some of them.
+type EntryInput struct {
+	Situation  string     `json:"situation"`
+	Text       string     `json:"text"`
+	Icon       string     `json:"icon"`
+	Colour     string     `json:"colour"`
+	Tags       string     `json:"tags"`
+	RemindAt   *time.Time `json:"remind_at"`
+	Recurrence string     `json:"recurrence"`
+	ClientID   string     `json:"client_id,omitempty"`
+}
+
+// Input returns the entry's editable fields.
+func (e *Entry) Input() EntryInput {
+	return EntryInput{
+		Situation: e.Situation, Text: e.Text, Icon: e.Icon, Colour: e.Colour, Tags: e.Tags,
+		RemindAt: e.RemindAt, Recurrence: e.Recurrence, ClientID: e.ClientID,
+	}
+}
+
+// ListEntries returns a page of the user's entries, newest first.
+func (c *Client) ListEntries(ctx context.Context, p Page) (*List[Entry], error) {
+	return list[Entry](ctx, c, "/entries", p)
+}
+
+// AllEntries iterates over all of the user's entries, newest first,
+// fetching pageSize at a time.
+func (c *Client) AllEntries(ctx context.Context, pageSize int) iter.Seq2[Entry, error] {
+	return all[Entry](ctx, c, "/entries", pageSize)
+}
+
+// GetEntry returns one of the user's entries.
+func (c *Client) GetEntry(ctx context.Context, id uint) (*Entry, error) {
+	return c.entry(ctx, request{method: http.MethodGet, path: entryPath(id)})
+}
+
+// CreateEntry adds an entry. Situation, text, icon and colour are required.
+func (c *Client) CreateEntry(ctx context.Context, in EntryInput) (*Entry, error) {
+	return c.entry(ctx, request{method: http.MethodPost, path: "/entries", body: in, idempotent: true})
+}
+
+// UpdateEntry replaces the editable fields of one of the user's entries.
+func (c *Client) UpdateEntry(ctx context.Context, id uint, in EntryInput) (*Entry, error) {
+	return c.entry(ctx, request{method: http.MethodPut, path: entryPath(id), body: in})
+}
+
+// DeleteEntry deletes one of the user's entries with its attachments.
+func (c *Client) DeleteEntry(ctx context.Context, id uint) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: entryPath(id)}, nil)
+	return err
+}
+
+func (c *Client) entry(ctx context.Context, req request) (*Entry, error) {
+	var e Entry
+	if _, err := c.do(ctx, req, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func entryPath(id uint) string {
+	return "/entries/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// Batch operation kinds
+const (
+	OpUpdate = "update"
+	OpTag    = "tag"
+	OpMove   = "move" // admin only
+	OpDelete = "delete"
+)
+
+// Batch modes
+const (
+	BatchAtomic  = "atomic"   // all operations or none (the default)
+	BatchPerItem = "per_item" // keep the operations that succeed
+)
+
+// BatchOp is one operation of a batch.
+type BatchOp struct {
+	Op         string                 `json:"op"`
+	ID         uint                   `json:"id"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`      // update
+	AddTags    []string               `json:"add_tags,omitempty"`    // tag
+	RemoveTags []string               `json:"remove_tags,omitempty"` // tag
+	UserID     uint                   `json:"user_id,omitempty"`     // move: the new owner
+}
+
+// BatchResult is the outcome of one operation.
+type BatchResult struct {
+	Index  int        `json:"index"`
+	ID     uint       `json:"id"`
+	Status string     `json:"status"` // ok, failed or rolled_back
+	Error  *ItemError `json:"error,omitempty"`
+	Entry  *Entry     `json:"entry,omitempty"`
+}
+
+// BatchResponse reports every operation of a batch.
+type BatchResponse struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchEntries applies operations to the user's entries. When an atomic
+// batch is rolled back the results are returned with ErrBatchRolledBack.
+func (c *Client) BatchEntries(ctx context.Context, mode string, ops []BatchOp) (*BatchResponse, error) {
+	return c.batch(ctx, "/entries/batch", mode, ops)
+}
+
+func (c *Client) batch(ctx context.Context, path, mode string, ops []BatchOp) (*BatchResponse, error) {
+	body := map[string]interface{}{"mode": mode, "operations": ops}
+	var out BatchResponse
+	req := request{method: http.MethodPost, path: path, body: body, accept: http.StatusUnprocessableEntity, idempotent: true}
+	if _, err := c.do(ctx, req, &out); err != nil {
+		return nil, err
+	}
+	if out.Mode == BatchAtomic && out.Failed > 0 {
+		return &out, ErrBatchRolledBack
+	}
+	return &out, nil
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Code is the server's machine-readable error code. A Code is an error,
+// so errors.Is(err, client.EntryNotFound) tests a returned *Error.
+type Code string
+
+func (c Code) Error() string { return string(c) }
+
+// Request problems
+const (
+	InvalidJSON        Code = "invalid_json"
+	ValidationFailed   Code = "validation_failed"
+	MissingFields      Code = "missing_fields"
+	InvalidReminder    Code = "invalid_reminder"
+	InvalidID          Code = "invalid_id"
+	InvalidCursor      Code = "invalid_cursor"
+	InvalidBatch       Code = "invalid_batch"
+	TooManyChanges     Code = "too_many_changes"
+	FileRequired       Code = "file_required"
+	FileUnreadable     Code = "file_unreadable"
+	FileTooLarge       Code = "file_too_large"
+	FileTypeNotAllowed Code = "file_type_not_allowed"
+	UnsupportedFormat  Code = "unsupported_format"
+	InvalidFile        Code = "invalid_file"
+	ChecksumMismatch   Code = "checksum_mismatch"
+	BodyTooLarge       Code = "body_too_large"
+)
+
+// Authentication and authorisation
+const (
+	Unauthorized       Code = "unauthorized"
+	InvalidToken       Code = "invalid_token"
+	Forbidden          Code = "forbidden"
+	InvalidCredentials Code = "invalid_credentials"
+	CredentialsMissing Code = "credentials_missing"
+)
+
+// Resources and their state
+const (
+	RouteNotFound         Code = "route_not_found"
+	EntryNotFound         Code = "entry_not_found"
+	UserNotFound          Code = "user_not_found"
+	AttachmentNotFound    Code = "attachment_not_found"
+	ThumbnailNotFound     Code = "thumbnail_not_found"
+	ThumbnailPending      Code = "thumbnail_pending"
+	ExportNotFound        Code = "export_not_found"
+	CalendarNotFound      Code = "calendar_not_found"
+	EmailTaken            Code = "email_taken"
+	ExportInProgress      Code = "export_in_progress"
+	ExportUnavailable     Code = "export_unavailable"
+	LinkExpired           Code = "link_expired"
+	IdempotencyKeyReused  Code = "idempotency_key_reused"
+	IdempotencyKeyBusy    Code = "idempotency_key_in_progress"
+	IdempotencyKeyTooLong Code = "idempotency_key_too_long"
+)
+
+// Server problems
+const (
+	DatabaseError Code = "database_error"
+	StorageError  Code = "storage_error"
+	InternalError Code = "internal_error"
+	Unavailable   Code = "service_unavailable"
+)
+
+// FieldError is one invalid field of a rejected request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ItemError is why one operation of a batch or one row of an import failed
+// while the others went through.
+type ItemError struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is a failed API call, decoded from the server's RFC 7807 problem
+// response. Code is empty when the response was not a problem document,
+// for example an error page from a proxy.
+type Error struct {
+	Status     int
+	Code       Code
+	Title      string
+	Detail     string
+	RequestID  string       // quote it when reporting a problem
+	TraceID    string       // set when the server traces requests
+	Fields     []FieldError // for validation_failed
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("reminder-card: %d", e.Status)
+	if e.Code != "" {
+		msg += " " + string(e.Code)
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	} else if e.Title != "" {
+		msg += ": " + e.Title
+	}
+	for _, f := range e.Fields {
+		msg += "; " + f.Field + ": " + f.Rule
+	}
+	return msg
+}
+
+// Is matches the error's Code.
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && code != "" && code == e.Code
+}
+
+// ErrBatchRolledBack is returned with the per-operation results when an
+// atomic batch had a failing operation and nothing was applied.
+var ErrBatchRolledBack = errors.New("reminder-card: batch rolled back")
+
+// ErrImportInvalid is returned with the report when an import file had
+// invalid rows and nothing was imported.
+var ErrImportInvalid = errors.New("reminder-card: import has invalid rows")
+
+type problem struct {
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id"`
+	TraceID   string       `json:"trace_id"`
+	Errors    []FieldError `json:"errors"`
+}
+
+// parseError turns an unsuccessful response into an *Error.
+func parseError(resp *http.Response) error {
+	e := &Error{
+		Status:     resp.StatusCode,
+		Title:      http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: retryAfter(resp.Header),
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if !isProblem(resp) {
+		e.Detail = strings.TrimSpace(string(body))
+		return e
+	}
+	var p problem
+	if err := json.Unmarshal(body, &p); err != nil {
+		e.Detail = strings.TrimSpace(string(body))
+		return e
+	}
+	e.Code, e.Title, e.Detail, e.Fields, e.TraceID = p.Code, p.Title, p.Detail, p.Errors, p.TraceID
+	if p.RequestID != "" {
+		e.RequestID = p.RequestID
+	}
+	return e
+}
+
+func isProblem(resp *http.Response) bool {
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return ct == "application/problem+json"
+}
+
+// retryAfter reads a Retry-After header given in seconds or as a date.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page selects part of a list. The zero Page asks for the whole list.
+type Page struct {
+	Limit  int // at most 500
+	Offset int
+}
+
+func (p Page) query() url.Values {
+	q := url.Values{}
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	return q
+}
+
+// List is one page of results. Next is nil on the last page.
+type List[T any] struct {
+	Items []T
+	Next  *Page
+}
+
+func list[T any](ctx context.Context, c *Client, path string, p Page) (*List[T], error) {
+	var items []T
+	h, err := c.do(ctx, request{method: http.MethodGet, path: path, query: p.query()}, &items)
+	if err != nil {
+		return nil, err
+	}
+	return &List[T]{Items: items, Next: nextPage(h.Get("Link"))}, nil
+}
+
+// all iterates over every item, fetching pageSize at a time. It stops at
+// the first error, which it yields with the zero item.
+func all[T any](ctx context.Context, c *Client, path string, pageSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		p := &Page{Limit: pageSize}
+		for p != nil {
+			page, err := list[T](ctx, c, path, *p)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			p = page.Next
+		}
+	}
+}
+
+// nextPage reads the rel="next" target of a Link header.
+func nextPage(link string) *Page {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return nil
+		}
+		limit, _ := strconv.Atoi(u.Query().Get("limit"))
+		offset, _ := strconv.Atoi(u.Query().Get("offset"))
+		if limit <= 0 {
+			return nil
+		}
+		return &Page{Limit: limit, Offset: offset}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Export and import formats
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+)
+
+// ImportRow is the outcome for one record of an imported file.
+type ImportRow struct {
+	Line   int         `json:"line"`
+	Status string      `json:"status"` // ok, invalid or duplicate
+	Record EntryInput  `json:"record"`
+	Errors []ItemError `json:"errors,omitempty"`
+}
+
+// ImportReport summarises an import. Nothing is written when any row is
+// invalid or on a dry run.
+type ImportReport struct {
+	DryRun     bool        `json:"dry_run"`
+	Format     string      `json:"format"`
+	Total      int         `json:"total"`
+	Valid      int         `json:"valid"`
+	Invalid    int         `json:"invalid"`
+	Duplicates int         `json:"duplicates"`
+	Imported   int         `json:"imported"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+// ExportEntries downloads all of the user's entries in format. The caller
+// must close the returned file; filename is the server's suggested name.
+func (c *Client) ExportEntries(ctx context.Context, format string) (file io.ReadCloser, filename string, err error) {
+	resp, err := c.send(ctx, request{method: http.MethodGet, path: "/entries/export", query: url.Values{"format": {format}}})
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, "", parseError(resp)
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		filename = params["filename"]
+	}
+	return resp.Body, filename, nil
+}
+
+// ImportEntries uploads a JSON, CSV or Markdown file of entries; the
+// format is taken from the file name. With dryRun the report previews the
+// import without writing anything. If any row is invalid nothing is
+// imported and the report is returned with ErrImportInvalid.
+func (c *Client) ImportEntries(ctx context.Context, filename string, file io.Reader, dryRun bool) (*ImportReport, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, err
+	}
+	if err := w.WriteField("dry_run", strconv.FormatBool(dryRun)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var report ImportReport
+	req := request{
+		method: http.MethodPost, path: "/entries/import", accept: http.StatusUnprocessableEntity,
+		raw: buf.Bytes(), contentType: w.FormDataContentType(),
+	}
+	if _, err := c.do(ctx, req, &report); err != nil {
+		return nil, err
+	}
+	if !report.DryRun && report.Invalid > 0 {
+		return &report, ErrImportInvalid
+	}
+	return &report, nil
+}
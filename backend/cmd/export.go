@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"Base/internal/handlers"
+	"Base/internal/storage"
+
+	"github.com/spf13/cobra"
+)
+
+func exportCommand() *cobra.Command {
+	var email, out string
+	var id uint
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write a user's data archive",
+		Long: "Write the same ZIP archive that GET /api/v1/export offers to the user\n" +
+			"with the given --email or --user-id. Use --out - for stdout.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (email == "") == (id == 0) {
+				return usageError{errors.New("give exactly one of --email or --user-id")}
+			}
+			if out == "" {
+				return usageError{errors.New("--out is required")}
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			blobs, err := storage.New(cfg.Storage)
+			if err != nil {
+				return fmt.Errorf("failed to set up blob storage: %w", err)
+			}
+			handlers.SetBlobStore(blobs)
+			user, err := findUser(db, id, email)
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if out != "-" {
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := handlers.WriteArchive(cmd.Context(), w, user.ID); err != nil {
+				if out != "-" {
+					os.Remove(out)
+				}
+				return fmt.Errorf("failed to export %s: %w", user.Email, err)
+			}
+			if out != "-" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s\n", out)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "email address of the account")
+	cmd.Flags().UintVar(&id, "user-id", 0, "ID of the account")
+	cmd.Flags().StringVar(&out, "out", "", "file to write, or - for stdout")
+	return cmd
+}
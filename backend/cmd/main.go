@@ -1,124 +1,139 @@
+// Command server runs the Reminder-Card API and its maintenance tasks:
+//
+//	server serve                      run the API (the default)
+//	server migrate up|down|status     manage the database schema
+//	server seed demo                  add a demo account with sample entries
+//	server user create-admin|reset-password|list
+//	server export --email ann@example.com --out ann.zip
+//
+// Configuration comes from an optional YAML or TOML file (--config or
+// $CONFIG_FILE), the environment, an optional .env file and flags, in
+// increasing precedence. It exits with 0 on success, 1 when the task fails
+// and 2 on a usage error.
 package main
 
 import (
-	_ "Base/docs" // Make sure this path is correct
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"Base/internal/config"
 	database "Base/internal/database"
 	"Base/internal/handlers"
-	"Base/internal/models"
-	"Base/internal/routes"
-	"log"
-	"os"
-	"time"
+	"Base/internal/logging"
 
-	"github.com/gin-gonic/gin"
-	godoenv "github.com/joho/godotenv"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
-// @title           Portfolio API
-// @version         1.0
-// @description     A REST API for user management
-// @termsOfService  http://swagger.io/terms/
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitPending = 3 // migrate status --check: migrations are pending
+)
 
-// @contact.name   API Support
-// @contact.url    http://www.swagger.io/support
-// @contact.email  support@swagger.io
+// usageError is a mistake in the command line rather than a failed task.
+type usageError struct{ error }
 
-// @license.name  MIT
-// @license.url   https://opensource.org/licenses/MIT
+// exitError ends the command with a specific exit code.
+type exitError struct {
+	code int
+	msg  string
+}
 
-// @host      localhost:8080
-// @BasePath  /
+func (e exitError) Error() string { return e.msg }
 
-// @securityDefinitions.basic  BasicAuth
 func main() {
-	err := godoenv.Load()
-	if err != nil {
-		log.Printf("Note: .env file not found, using system environment variables")
-	}
-
-	log.Println("Initializing database connection...")
-	db, err := database.DBConnect()
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-
-	// Set the database connection in handlers package
-	handlers.SetDB(db)
-
-	// Auto-migrate database models to create tables
-	if err := db.AutoMigrate(&models.User{}, &models.Entry{}); err != nil {
-		log.Fatal("Failed to auto-migrate database:", err)
-	}
+	os.Exit(run(os.Args[1:]))
+}
 
-	// Seed admin user if ADMIN_PASSWORD is set and no admin exists
-	adminPassword := os.Getenv("ADMIN_PASSWORD")
-	if adminPassword != "" {
-		var adminUser models.User
-		if err := db.Where("role = ?", "admin").First(&adminUser).Error; err != nil {
-			importBcryptPassword, _ := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
-			adminUser = models.User{
-				Name:     "admin",
-				Email:    "admin@example.com",
-				Password: string(importBcryptPassword),
-				Role:     "admin",
-			}
-			db.Create(&adminUser)
-			log.Println("Admin user seeded successfully.")
-		} else if adminUser.Name != "admin" {
-			// Just in case existing admin has different name
-			adminUser.Name = "admin"
-			db.Save(&adminUser)
+func run(args []string) int {
+	root := newRootCommand()
+	root.SetArgs(args)
+	err := root.Execute()
+	var exit exitError
+	var usage usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &exit):
+		if exit.msg != "" {
+			fmt.Fprintln(os.Stderr, exit.msg)
 		}
+		return exit.code
+	case errors.As(err, &usage) || strings.HasPrefix(err.Error(), "unknown command"):
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprintln(os.Stderr, "Run with --help for usage.")
+		return exitUsage
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitFailure
 	}
+}
 
-	// Test database connection
-	sqlDB, err := db.DB()
-	if err != nil {
-		log.Fatal("Failed to get SQL DB:", err)
+// cfg is loaded before any command runs.
+var cfg *config.Config
+
+func newRootCommand() *cobra.Command {
+	var configFile, databaseURL string
+	serve := serveCommand()
+	root := &cobra.Command{
+		Use:           "server",
+		Short:         "Reminder-Card API server",
+		Long:          "Reminder-Card API server. Without a command it serves the API.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if cfg, err = config.Load(configFile); err != nil {
+				return err
+			}
+			logging.Setup(cfg.Log)
+			if databaseURL != "" {
+				return cfg.Set("database.url", databaseURL)
+			}
+			return nil
+		},
+		Args: noArgs,
+		RunE: serve.RunE,
 	}
+	root.PersistentFlags().StringVar(&configFile, "config", "", "YAML or TOML config file (default $CONFIG_FILE)")
+	root.PersistentFlags().StringVar(&databaseURL, "database-url", "", "PostgreSQL URL, overriding DATABASE_URL and DB_*")
+	root.Flags().AddFlagSet(serve.Flags())
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error { return usageError{err} })
+	root.AddCommand(serve, migrateCommand(), seedCommand(), userCommand(), exportCommand())
+	return root
+}
 
-	// Set connection pool settings
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// Test the connection
-	if err := sqlDB.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
+func noArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.NoArgs(cmd, args); err != nil {
+		return usageError{err}
 	}
+	return nil
+}
 
-	// Initialize Gin router
-	router := gin.Default()
-
-	// Initialize Gin router
-
-	router.GET("/ping", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "pong",
-		})
-	})
-
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
-
-	router.Use(gin.Recovery())
-	routes.SetupRoutes(router)
-
-	// Use standard PORT environment variable which Render defaults to
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = os.Getenv("Server_Port") // Keep backward compatibility with our own custom setting if PORT is not present
-		if port == "" {
-			port = "8080"
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(n)(cmd, args); err != nil {
+			return usageError{err}
 		}
-		log.Printf("PORT not set, defaulting to %s", port)
+		return nil
 	}
+}
 
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+// connect opens the database, waiting for it to come up, and hands it to
+// the handlers.
+func connect(ctx context.Context) (*gorm.DB, error) {
+	slog.Info("connecting to the database")
+	db, err := database.Connect(ctx, cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	handlers.SetDB(db)
+	return db, nil
 }
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestRunUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"frobnicate"},
+		{"serve", "--no-such-flag"},
+		{"migrate", "up", "extra"},
+		{"migrate", "down", "--to", "1", "--steps", "2"},
+		{"user", "reset-password"},
+		{"export", "--email", "a@example.com"},
+	} {
+		if code := run(args); code != exitUsage {
+			t.Errorf("run(%q) = %d, want %d", args, code, exitUsage)
+		}
+	}
+	if code := run([]string{"--help"}); code != exitOK {
+		t.Errorf("run(--help) = %d, want %d", code, exitOK)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"Base/internal/migrate"
+
+	"github.com/spf13/cobra"
+)
+
+func migrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply, roll back or inspect schema migrations",
+	}
+	cmd.AddCommand(migrateUpCommand(), migrateDownCommand(), migrateStatusCommand())
+	return cmd
+}
+
+func migrateUpCommand() *cobra.Command {
+	var to int
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending migrations",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			applied, err := migrate.New(db, migrate.All).Up(cmd.Context(), to)
+			for _, m := range applied {
+				fmt.Fprintf(cmd.OutOrStdout(), "Applied %d: %s\n", m.Version, m.Name)
+			}
+			if err == nil && len(applied) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Already up to date")
+			}
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&to, "to", 0, "stop after this version (default: the latest)")
+	return cmd
+}
+
+func migrateDownCommand() *cobra.Command {
+	var to, steps int
+	cmd := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back migrations, the newest one by default",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("to") && cmd.Flags().Changed("steps") {
+				return usageError{fmt.Errorf("use either --to or --steps")}
+			}
+			if to < 0 || steps < 1 {
+				return usageError{fmt.Errorf("--to must be at least 0 and --steps at least 1")}
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			m := migrate.New(db, migrate.All)
+			if !cmd.Flags().Changed("to") {
+				if to, err = stepsBack(cmd, m, steps); err != nil {
+					return err
+				}
+			}
+			rolledBack, err := m.Down(cmd.Context(), to)
+			for _, mig := range rolledBack {
+				fmt.Fprintf(cmd.OutOrStdout(), "Rolled back %d: %s\n", mig.Version, mig.Name)
+			}
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&to, "to", 0, "roll back every migration newer than this version; 0 empties the schema")
+	cmd.Flags().IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	return cmd
+}
+
+// stepsBack is the version left after rolling back the newest n applied
+// migrations.
+func stepsBack(cmd *cobra.Command, m *migrate.Migrator, n int) (int, error) {
+	status, err := m.Status(cmd.Context())
+	if err != nil {
+		return 0, err
+	}
+	var applied []int
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			applied = append(applied, s.Version)
+		}
+	}
+	if n >= len(applied) {
+		return 0, nil
+	}
+	return applied[len(applied)-1-n], nil
+}
+
+func migrateStatusCommand() *cobra.Command {
+	var check bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "List migrations and whether they are applied",
+		Long: "List migrations and whether they are applied. With --check it exits\n" +
+			"with status 3 when any migration is pending.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			status, err := migrate.New(db, migrate.All).Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+			pending := 0
+			for _, s := range status {
+				applied := "pending"
+				if s.AppliedAt != nil {
+					applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+				} else {
+					pending++
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if check && pending > 0 {
+				return exitError{code: exitPending, msg: fmt.Sprintf("%d migrations pending", pending)}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, "exit with status 3 if migrations are pending")
+	return cmd
+}
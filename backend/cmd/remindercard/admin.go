@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"Base/client"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) adminCommand() *cobra.Command {
+	users := &cobra.Command{
+		Use:   "users",
+		Short: "Manage user accounts",
+	}
+	users.AddCommand(a.listUsersCommand(), a.createUserCommand(), a.editUserCommand(), a.deleteUserCommand())
+
+	entries := &cobra.Command{
+		Use:   "entries",
+		Short: "Every user's entries",
+	}
+	entries.AddCommand(a.listAllEntriesCommand())
+
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Administration; needs an admin login",
+	}
+	cmd.AddCommand(users, entries)
+	return cmd
+}
+
+func (a *app) listUsersCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all users",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			users := []client.User{}
+			for u, err := range c.AllUsers(ctx(cmd), pageSize) {
+				if err != nil {
+					return err
+				}
+				users = append(users, u)
+			}
+			return a.print(userTable(users))
+		},
+	}
+}
+
+func (a *app) createUserCommand() *cobra.Command {
+	var r client.Registration
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user account",
+		Long:  "Create a user account. The password is prompted for, or read from stdin.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			if r.Password, err = a.password(bufio.NewReader(a.in)); err != nil {
+				return err
+			}
+			id, err := c.Register(ctx(cmd), r)
+			if err != nil {
+				return err
+			}
+			fprintf(a.out, "Created user %d\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&r.Name, "name", "", "display name")
+	cmd.Flags().StringVar(&r.Email, "email", "", "login email")
+	cmd.Flags().StringVar(&r.Language, "language", "", "preferred language: en or ru")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("email")
+	_ = cmd.RegisterFlagCompletionFunc("language", fixedCompletion("en", "ru"))
+	return cmd
+}
+
+func (a *app) editUserCommand() *cobra.Command {
+	var name, role string
+	var setPassword bool
+	cmd := &cobra.Command{
+		Use:               "edit <id>",
+		Short:             "Change a user's name, role or password",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeUserIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			user, err := findUser(cmd, c, id)
+			if err != nil {
+				return err
+			}
+
+			// The server sets name and role together, so keep the current ones
+			update := client.UserUpdate{Name: user.Name, Role: user.Role}
+			if cmd.Flags().Changed("name") {
+				update.Name = name
+			}
+			if cmd.Flags().Changed("role") {
+				update.Role = role
+			}
+			if setPassword {
+				if update.Password, err = a.password(bufio.NewReader(a.in)); err != nil {
+					return err
+				}
+			}
+			if err := c.UpdateUser(ctx(cmd), id, update); err != nil {
+				return err
+			}
+			fprintf(a.out, "Updated user %d\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "new display name")
+	cmd.Flags().StringVar(&role, "role", "", "user or admin")
+	cmd.Flags().BoolVar(&setPassword, "password", false, "set a new password, prompted for or read from stdin")
+	_ = cmd.RegisterFlagCompletionFunc("role", fixedCompletion("user", "admin"))
+	return cmd
+}
+
+// findUser looks a user up in the admin list, which is the only way the
+// API exposes other accounts.
+func findUser(cmd *cobra.Command, c *client.Client, id uint) (*client.User, error) {
+	for u, err := range c.AllUsers(ctx(cmd), pageSize) {
+		if err != nil {
+			return nil, err
+		}
+		if u.ID == id {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user %d not found", id)
+}
+
+func (a *app) deleteUserCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <id>...",
+		Aliases:           []string{"rm"},
+		Short:             "Delete users",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeUserIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				if err := c.DeleteUser(ctx(cmd), id); err != nil {
+					return fmt.Errorf("user %d: %w", id, err)
+				}
+				fprintf(a.out, "Deleted user %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func (a *app) listAllEntriesCommand() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List every user's entries, by ID",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			entries := []client.Entry{}
+			for e, err := range c.EveryEntry(ctx(cmd), pageSize) {
+				if err != nil {
+					return err
+				}
+				entries = append(entries, e)
+				if limit > 0 && len(entries) == limit {
+					break
+				}
+			}
+			return a.print(entryTable(entries))
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "show at most this many entries")
+	return cmd
+}
+
+// completeUserIDs suggests user IDs, described by email.
+func (a *app) completeUserIDs(cmd *cobra.Command, args []string, prefix string) ([]string, cobra.ShellCompDirective) {
+	if err := cmd.Root().PersistentPreRunE(cmd, args); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	c, err := a.client()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for u, err := range c.AllUsers(ctx(cmd), pageSize) {
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		if id := strconv.FormatUint(uint64(u.ID), 10); strings.HasPrefix(id, prefix) {
+			ids = append(ids, id+"\t"+u.Email)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is what login remembers between runs, stored as JSON in the OS
+// config directory (e.g. ~/.config/remindercard/config.json).
+type config struct {
+	Server   string `json:"server"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+func configPath() (string, error) {
+	if p := os.Getenv("REMINDERCARD_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "remindercard", "config.json"), nil
+}
+
+// loadConfig reads the saved config; a missing file is an empty config.
+func loadConfig() (*config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, json.Unmarshal(data, &cfg)
+}
+
+// save writes the config readable only by the user, as it holds the token.
+func (cfg *config) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
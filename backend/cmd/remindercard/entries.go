@@ -0,0 +1,407 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Base/client"
+	"Base/internal/transfer"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pageSize is how many entries are fetched per request when listing.
+const pageSize = 100
+
+func (a *app) entriesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "entries",
+		Aliases: []string{"entry", "e"},
+		Short:   "List, search and edit your entries",
+	}
+	cmd.AddCommand(
+		a.listEntriesCommand(), a.searchEntriesCommand(), a.showEntryCommand(),
+		a.addEntryCommand(), a.editEntryCommand(), a.deleteEntriesCommand(),
+		a.exportCommand(), a.importCommand(),
+	)
+	return cmd
+}
+
+func (a *app) listEntriesCommand() *cobra.Command {
+	var limit int
+	var tag string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List your entries, newest first",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.printEntries(cmd, limit, func(e client.Entry) bool {
+				return tag == "" || hasTag(e, tag)
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "show at most this many entries")
+	cmd.Flags().StringVarP(&tag, "tag", "t", "", "only entries with this tag")
+	return cmd
+}
+
+func (a *app) searchEntriesCommand() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "search <text>",
+		Short: "Find entries whose situation, text or tags contain the words",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			words := strings.Fields(strings.ToLower(strings.Join(args, " ")))
+			return a.printEntries(cmd, limit, func(e client.Entry) bool {
+				haystack := strings.ToLower(e.Situation + "\n" + e.Text + "\n" + e.Tags)
+				for _, w := range words {
+					if !strings.Contains(haystack, w) {
+						return false
+					}
+				}
+				return true
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "show at most this many matches")
+	return cmd
+}
+
+// printEntries pages through the user's entries, printing those that match
+// until limit (0 for no limit) is reached.
+func (a *app) printEntries(cmd *cobra.Command, limit int, match func(client.Entry) bool) error {
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	entries := []client.Entry{}
+	for e, err := range c.AllEntries(ctx(cmd), pageSize) {
+		if err != nil {
+			return err
+		}
+		if !match(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return a.print(entryTable(entries))
+}
+
+func hasTag(e client.Entry, tag string) bool {
+	for _, t := range strings.Fields(e.Tags) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *app) showEntryCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "show <id>",
+		Short:             "Show one entry",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeEntryIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			e, err := c.GetEntry(ctx(cmd), id)
+			if err != nil {
+				return err
+			}
+			return a.printEntry(e)
+		},
+	}
+}
+
+func (a *app) printEntry(e *client.Entry) error {
+	if a.output == outputJSON {
+		return a.printJSON(e)
+	}
+	fprintf(a.out, "ID:         %d\nSituation:  %s\nText:       %s\nIcon:       %s\nColour:     %s\nTags:       %s\nRemind at:  %s\nRecurrence: %s\nCreated:    %s\n",
+		e.ID, e.Situation, e.Text, e.Icon, e.Colour, e.Tags, formatTime(e.RemindAt), e.Recurrence, formatTime(&e.CreatedAt))
+	return nil
+}
+
+// entryFlags are the editable fields of an entry as command-line flags.
+type entryFlags struct {
+	in       client.EntryInput
+	remindAt string
+}
+
+func (f *entryFlags) register(fs *pflag.FlagSet) {
+	fs.StringVarP(&f.in.Situation, "situation", "s", "", "when the reminder applies")
+	fs.StringVar(&f.in.Text, "text", "", "what to remember")
+	fs.StringVar(&f.in.Icon, "icon", "", "icon name, e.g. star")
+	fs.StringVar(&f.in.Colour, "colour", "", "card colour, e.g. purple")
+	fs.StringVar(&f.in.Tags, "tags", "", "space-separated tags")
+	fs.StringVar(&f.remindAt, "remind-at", "", `reminder time, RFC 3339 or "2006-01-02 15:04" local time; "none" clears it`)
+	fs.StringVar(&f.in.Recurrence, "recurrence", "", "RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO")
+}
+
+// apply copies the flags that were given onto in.
+func (f *entryFlags) apply(fs *pflag.FlagSet, in *client.EntryInput) error {
+	set := func(name string, dst *string, v string) {
+		if fs.Changed(name) {
+			*dst = v
+		}
+	}
+	set("situation", &in.Situation, f.in.Situation)
+	set("text", &in.Text, f.in.Text)
+	set("icon", &in.Icon, f.in.Icon)
+	set("colour", &in.Colour, f.in.Colour)
+	set("tags", &in.Tags, f.in.Tags)
+	set("recurrence", &in.Recurrence, f.in.Recurrence)
+	if fs.Changed("remind-at") {
+		t, err := parseTime(f.remindAt)
+		if err != nil {
+			return err
+		}
+		in.RemindAt = t
+	}
+	return nil
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" || s == "none" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid time %q; use RFC 3339 or 2006-01-02 15:04", s)
+}
+
+func (a *app) addEntryCommand() *cobra.Command {
+	var f entryFlags
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Create an entry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var in client.EntryInput
+			if err := f.apply(cmd.Flags(), &in); err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			e, err := c.CreateEntry(ctx(cmd), in)
+			if err != nil {
+				return err
+			}
+			return a.printEntry(e)
+		},
+	}
+	f.register(cmd.Flags())
+	for _, name := range []string{"situation", "text", "icon", "colour"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+func (a *app) editEntryCommand() *cobra.Command {
+	var f entryFlags
+	cmd := &cobra.Command{
+		Use:               "edit <id>",
+		Short:             "Change the given fields of an entry",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeEntryIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			e, err := c.GetEntry(ctx(cmd), id)
+			if err != nil {
+				return err
+			}
+			in := e.Input()
+			if err := f.apply(cmd.Flags(), &in); err != nil {
+				return err
+			}
+			if e, err = c.UpdateEntry(ctx(cmd), id, in); err != nil {
+				return err
+			}
+			return a.printEntry(e)
+		},
+	}
+	f.register(cmd.Flags())
+	return cmd
+}
+
+func (a *app) deleteEntriesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "delete <id>...",
+		Aliases:           []string{"rm"},
+		Short:             "Delete entries",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeEntryIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				if err := c.DeleteEntry(ctx(cmd), id); err != nil {
+					return fmt.Errorf("entry %d: %w", id, err)
+				}
+				fprintf(a.out, "Deleted entry %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func (a *app) exportCommand() *cobra.Command {
+	var format, file string
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Download all your entries as JSON, CSV or Markdown",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := transfer.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			body, name, err := c.ExportEntries(ctx(cmd), string(f))
+			if err != nil {
+				return err
+			}
+			defer body.Close()
+
+			if file == "-" {
+				_, err := io.Copy(a.out, body)
+				return err
+			}
+			if file == "" {
+				file = filepath.Base(name)
+			}
+			dst, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(dst, body); err != nil {
+				dst.Close()
+				return err
+			}
+			if err := dst.Close(); err != nil {
+				return err
+			}
+			fprintf(cmd.ErrOrStderr(), "Exported to %s\n", file)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", string(transfer.JSON), "json, csv or md")
+	cmd.Flags().StringVar(&file, "file", "", `where to save the export, "-" for stdout (default: the server's file name)`)
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(string(transfer.JSON), string(transfer.CSV), string(transfer.Markdown)))
+	return cmd
+}
+
+func (a *app) importCommand() *cobra.Command {
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import entries from a JSON, CSV or Markdown file",
+		Long: "Import entries from a JSON, CSV or Markdown file, chosen by its extension.\n" +
+			"Duplicates are skipped; if any row is invalid nothing is imported.",
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return []string{"json", "csv", "md", "markdown"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := transfer.FormatFromFilename(args[0]); err != nil {
+				return err
+			}
+			src, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			report, err := c.ImportEntries(ctx(cmd), filepath.Base(args[0]), src, dryRun)
+			if err != nil && !errors.Is(err, client.ErrImportInvalid) {
+				return err
+			}
+			if perr := a.print(importTable(report)); perr != nil {
+				return perr
+			}
+			if a.output == outputTable {
+				fprintf(a.out, "\n%d rows: %d valid, %d invalid, %d duplicates, %d imported\n",
+					report.Total, report.Valid, report.Invalid, report.Duplicates, report.Imported)
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only check the file")
+	return cmd
+}
+
+// completeEntryIDs suggests the user's entry IDs, described by situation.
+func (a *app) completeEntryIDs(cmd *cobra.Command, args []string, prefix string) ([]string, cobra.ShellCompDirective) {
+	if err := cmd.Root().PersistentPreRunE(cmd, args); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	c, err := a.client()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for e, err := range c.AllEntries(ctx(cmd), pageSize) {
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		if id := strconv.FormatUint(uint64(e.ID), 10); strings.HasPrefix(id, prefix) {
+			ids = append(ids, id+"\t"+truncate(e.Situation, 40))
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return uint(id), nil
+}
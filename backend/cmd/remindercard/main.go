@@ -0,0 +1,118 @@
+// Command remindercard manages Reminder-Card entries from the terminal.
+//
+//	remindercard login --server https://reminders.example.com ann@example.com
+//	remindercard entries list
+//	remindercard entries add --situation "Monday standup" --text "Share the demo" --icon star --colour purple
+//
+// Run remindercard completion --help to set up shell completion.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"Base/client"
+
+	"github.com/spf13/cobra"
+)
+
+// app is the state shared by the commands of one run.
+type app struct {
+	cfg    *config
+	server string // --server, overriding the saved one
+	output string
+	lang   string
+	in     io.Reader
+	out    io.Writer
+}
+
+func main() {
+	if err := newRootCommand(os.Stdin, os.Stdout).Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", describe(err))
+		os.Exit(1)
+	}
+}
+
+func newRootCommand(in io.Reader, out io.Writer) *cobra.Command {
+	a := &app{in: in, out: out}
+	root := &cobra.Command{
+		Use:           "remindercard",
+		Short:         "Manage Reminder-Card entries from the terminal",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if a.output != outputTable && a.output != outputJSON {
+				return fmt.Errorf("unknown output format %q, use table or json", a.output)
+			}
+			var err error
+			a.cfg, err = loadConfig()
+			return err
+		},
+	}
+	root.SetIn(in)
+	root.SetOut(out)
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.server, "server", os.Getenv("REMINDERCARD_SERVER"), "API server URL (default: the one saved by login)")
+	flags.StringVarP(&a.output, "output", "o", outputTable, "output format: table or json")
+	flags.StringVar(&a.lang, "lang", "", "language of server messages: en or ru")
+	_ = root.RegisterFlagCompletionFunc("output", fixedCompletion(outputTable, outputJSON))
+	_ = root.RegisterFlagCompletionFunc("lang", fixedCompletion("en", "ru"))
+
+	root.AddCommand(a.loginCommand(), a.logoutCommand(), a.whoamiCommand(), a.entriesCommand(), a.adminCommand())
+	return root
+}
+
+// client returns an API client for the configured server and session.
+func (a *app) client() (*client.Client, error) {
+	server := a.server
+	if server == "" {
+		server = a.cfg.Server
+	}
+	if server == "" {
+		return nil, errors.New("no server configured; log in with --server URL first")
+	}
+	opts := []client.Option{client.WithToken(a.cfg.Token)}
+	if a.lang != "" {
+		opts = append(opts, client.WithLanguage(a.lang))
+	}
+	return client.New(server, opts...)
+}
+
+// describe turns API errors into one readable line.
+func describe(err error) string {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	if errors.Is(err, client.InvalidToken) || errors.Is(err, client.Unauthorized) {
+		return "not logged in or the session expired; run remindercard login"
+	}
+	msg := apiErr.Title
+	if apiErr.Detail != "" {
+		msg = apiErr.Detail
+	}
+	for _, f := range apiErr.Fields {
+		msg += "\n  " + f.Field + ": " + f.Message
+	}
+	if apiErr.RequestID != "" {
+		msg += fmt.Sprintf("\n  (request %s)", apiErr.RequestID)
+	}
+	return msg
+}
+
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func ctx(cmd *cobra.Command) context.Context {
+	if c := cmd.Context(); c != nil {
+		return c
+	}
+	return context.Background()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Base/client"
+	"Base/internal/models"
+	"Base/internal/testdb"
+	"Base/internal/testserver"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupServer(t *testing.T) string {
+	t.Helper()
+	t.Setenv("REMINDERCARD_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	t.Setenv("REMINDERCARD_SERVER", "")
+
+	db := testdb.Open(t)
+	for _, u := range []models.User{
+		{Name: "admin", Email: "admin@example.com", Role: "admin"},
+		{Name: "Ann", Email: "ann@example.com", Role: "user"},
+	} {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("secret1"), bcrypt.MinCost)
+		u.Password = string(hash)
+		db.Create(&u)
+	}
+	return testserver.Start(t, db)
+}
+
+// run executes the CLI with stdin and returns its output.
+func run(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCommand(strings.NewReader(stdin), &out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func mustRun(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	out, err := run(t, stdin, args...)
+	if err != nil {
+		t.Fatalf("remindercard %s: %s", strings.Join(args, " "), describe(err))
+	}
+	return out
+}
+
+func TestEntriesCommands(t *testing.T) {
+	server := setupServer(t)
+
+	if _, err := run(t, "", "entries", "list"); err == nil || !strings.Contains(err.Error(), "no server") {
+		t.Fatalf("list before login: err = %v", err)
+	}
+	if _, err := run(t, "wrong\n", "login", "--server", server, "ann@example.com"); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	if out := mustRun(t, "secret1\n", "login", "--server", server, "ann@example.com"); !strings.Contains(out, "Logged in as Ann") {
+		t.Errorf("login printed %q", out)
+	}
+	cfg, err := loadConfig()
+	if err != nil || cfg.Server != server || cfg.Token == "" {
+		t.Fatalf("saved config = %+v, %v", cfg, err)
+	}
+	path, _ := configPath()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("config file mode = %v, %v; want 0600", info.Mode(), err)
+	}
+
+	mustRun(t, "", "entries", "add", "-s", "Monday standup", "--text", "Share the demo", "--icon", "star", "--colour", "purple", "--tags", "work")
+	mustRun(t, "", "entries", "add", "-s", "Dentist", "--text", "Bring the card", "--icon", "heart", "--colour", "blue", "--remind-at", "2030-01-02 09:30")
+
+	var entries []client.Entry
+	if err := json.Unmarshal([]byte(mustRun(t, "", "entries", "list", "-o", "json")), &entries); err != nil || len(entries) != 2 {
+		t.Fatalf("list -o json = %+v, %v", entries, err)
+	}
+	if out := mustRun(t, "", "entries", "list", "--tag", "work"); !strings.Contains(out, "Monday standup") || strings.Contains(out, "Dentist") {
+		t.Errorf("list --tag work:\n%s", out)
+	}
+	if out := mustRun(t, "", "entries", "search", "bring", "CARD"); !strings.Contains(out, "Dentist") || strings.Contains(out, "standup") {
+		t.Errorf("search:\n%s", out)
+	}
+
+	if out := mustRun(t, "", "entries", "edit", "1", "--tags", "work weekly"); !strings.Contains(out, "work weekly") || !strings.Contains(out, "Share the demo") {
+		t.Errorf("edit kept the other fields?\n%s", out)
+	}
+	if _, err := run(t, "", "entries", "show", "999"); !strings.Contains(describe(err), "not found") {
+		t.Errorf("show 999: %v", describe(err))
+	}
+
+	export := mustRun(t, "", "entries", "export", "--format", "csv", "--file", "-")
+	if !strings.Contains(export, "Monday standup") {
+		t.Fatalf("export:\n%s", export)
+	}
+	mustRun(t, "", "entries", "delete", "1")
+	file := filepath.Join(t.TempDir(), "entries.csv")
+	if err := os.WriteFile(file, []byte(export), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out := mustRun(t, "", "entries", "import", file); !strings.Contains(out, "1 imported") {
+		t.Errorf("import:\n%s", out)
+	}
+	if out := mustRun(t, "", "entries", "show", "3"); !strings.Contains(out, "Monday standup") {
+		t.Errorf("imported entry:\n%s", out)
+	}
+
+	if _, err := run(t, "", "admin", "users", "list"); err == nil {
+		t.Error("admin users list worked for a regular user")
+	}
+	mustRun(t, "", "logout")
+	if cfg, _ := loadConfig(); cfg.Token != "" || cfg.Server != server {
+		t.Errorf("config after logout = %+v", cfg)
+	}
+}
+
+func TestAdminCommands(t *testing.T) {
+	server := setupServer(t)
+	mustRun(t, "secret1\n", "login", "--server", server, "admin@example.com")
+
+	mustRun(t, "newpass1\n", "admin", "users", "create", "--name", "Bob", "--email", "bob@example.com")
+	out := mustRun(t, "", "admin", "users", "list")
+	if !strings.Contains(out, "bob@example.com") || !strings.Contains(out, "ROLE") {
+		t.Fatalf("users list:\n%s", out)
+	}
+	mustRun(t, "", "admin", "users", "edit", "3", "--role", "admin")
+
+	var users []client.User
+	if err := json.Unmarshal([]byte(mustRun(t, "", "admin", "users", "list", "-o", "json")), &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 || users[2].Name != "Bob" || users[2].Role != "admin" {
+		t.Errorf("after edit: %+v", users)
+	}
+	mustRun(t, "", "admin", "users", "delete", "3")
+	if out := mustRun(t, "", "admin", "users", "list"); strings.Contains(out, "bob@") {
+		t.Errorf("deleted user still listed:\n%s", out)
+	}
+}
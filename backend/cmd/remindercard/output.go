@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"Base/client"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// table is a list printed as aligned columns, or as the JSON of its items
+// with -o json.
+type table struct {
+	header []string
+	rows   [][]string
+	items  interface{}
+}
+
+func (a *app) print(t table) error {
+	if a.output == outputJSON {
+		return a.printJSON(t.items)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func (a *app) printJSON(v interface{}) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func entryTable(entries []client.Entry) table {
+	t := table{header: []string{"ID", "SITUATION", "TEXT", "TAGS", "REMIND AT", "CREATED"}, items: entries}
+	for _, e := range entries {
+		t.rows = append(t.rows, []string{
+			fmt.Sprint(e.ID), truncate(e.Situation, 30), truncate(e.Text, 40), e.Tags, formatTime(e.RemindAt), e.CreatedAt.Local().Format("2006-01-02"),
+		})
+	}
+	return t
+}
+
+func userTable(users []client.User) table {
+	t := table{header: []string{"ID", "NAME", "EMAIL", "ROLE", "LANGUAGE"}, items: users}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{fmt.Sprint(u.ID), u.Name, u.Email, u.Role, u.Language})
+	}
+	return t
+}
+
+func importTable(r *client.ImportReport) table {
+	t := table{header: []string{"LINE", "STATUS", "SITUATION", "ERRORS"}, items: r}
+	for _, row := range r.Rows {
+		errs := make([]string, len(row.Errors))
+		for i, e := range row.Errors {
+			errs[i] = e.Message
+		}
+		t.rows = append(t.rows, []string{fmt.Sprint(row.Line), row.Status, truncate(row.Record.Situation, 30), strings.Join(errs, "; ")})
+	}
+	return t
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// truncate shortens s to n runes on one line.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func fprintf(w io.Writer, format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(w, format, args...)
+}
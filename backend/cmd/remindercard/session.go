@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func (a *app) loginCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "login [email]",
+		Short: "Log in and save the session",
+		Long: "Log in and save the server and session token in the config directory.\n" +
+			"The password is prompted for, or read from the first line of stdin\n" +
+			"when it is not a terminal.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.server != "" {
+				a.cfg.Server = a.server
+			}
+			a.cfg.Token = ""
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+
+			lines := bufio.NewReader(a.in)
+			login := ""
+			if len(args) > 0 {
+				login = args[0]
+			} else if login, err = a.prompt(lines, "Email: "); err != nil {
+				return err
+			}
+			password, err := a.password(lines)
+			if err != nil {
+				return err
+			}
+
+			s, err := c.Login(ctx(cmd), login, password)
+			if err != nil {
+				return err
+			}
+			a.cfg.Token, a.cfg.Username, a.cfg.Role = s.Token, s.Username, s.Role
+			if err := a.cfg.save(); err != nil {
+				return err
+			}
+			if a.output == outputJSON {
+				return a.printJSON(map[string]string{"username": s.Username, "role": s.Role, "language": s.Language})
+			}
+			fprintf(a.out, "Logged in as %s (%s)\n", s.Username, s.Role)
+			return nil
+		},
+	}
+}
+
+func (a *app) prompt(lines *bufio.Reader, label string) (string, error) {
+	if isTerminal(a.in) {
+		fprintf(a.out, "%s", label)
+	}
+	line, err := lines.ReadString('\n')
+	if line = strings.TrimSpace(line); line == "" && err != nil {
+		return "", fmt.Errorf("reading %s%w", strings.ToLower(label), err)
+	}
+	return line, nil
+}
+
+// password reads the password without echo from a terminal, or as a line
+// of piped input.
+func (a *app) password(lines *bufio.Reader) (string, error) {
+	if !isTerminal(a.in) {
+		return a.prompt(lines, "Password: ")
+	}
+	fprintf(a.out, "Password: ")
+	b, err := term.ReadPassword(int(a.in.(*os.File).Fd()))
+	fprintf(a.out, "\n")
+	return string(b), err
+}
+
+func isTerminal(in interface{}) bool {
+	f, ok := in.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+func (a *app) logoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "End the session and forget the token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.cfg.Token == "" {
+				return errors.New("not logged in")
+			}
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			// The token is forgotten even if the server no longer knows it
+			logoutErr := c.Logout(ctx(cmd))
+			a.cfg.Token, a.cfg.Username, a.cfg.Role = "", "", ""
+			if err := a.cfg.save(); err != nil {
+				return err
+			}
+			if logoutErr == nil {
+				fprintf(a.out, "Logged out\n")
+			}
+			return nil
+		},
+	}
+}
+
+func (a *app) whoamiCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the logged-in user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := a.client()
+			if err != nil {
+				return err
+			}
+			me, err := c.Me(ctx(cmd))
+			if err != nil {
+				return err
+			}
+			if a.output == outputJSON {
+				return a.printJSON(me)
+			}
+			fprintf(a.out, "%s <%s>, %s\n", me.Username, me.Email, me.Role)
+			return nil
+		},
+	}
+}
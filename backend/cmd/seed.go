@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"Base/internal/catalog"
+	"Base/internal/models"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// demoEntries are the cards a new demo account starts with.
+var demoEntries = []struct{ situation, text, tags string }{
+	{"Before a meeting", "Write down the one decision the meeting must reach.", "work"},
+	{"When stuck on a problem", "Explain it out loud to someone, or to a rubber duck.", "work ideas"},
+	{"Morning", "Drink a glass of water before the first coffee.", "health"},
+	{"Before sleep", "Note three things that went well today.", "habits"},
+	{"When angry", "Wait ten minutes before replying.", "habits"},
+	{"Reading a book", "Summarise each chapter in one sentence.", "learning"},
+}
+
+func seedCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load sample data",
+	}
+	cmd.AddCommand(seedDemoCommand())
+	return cmd
+}
+
+func seedDemoCommand() *cobra.Command {
+	var email, password string
+	cmd := &cobra.Command{
+		Use:   "demo",
+		Short: "Create a demo account with sample entries",
+		Long: "Create a demo account with sample entries. Running it again leaves an\n" +
+			"existing account and its entries alone.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(password) < minPassword {
+				return usageError{fmt.Errorf("--password must be at least %d characters", minPassword)}
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var user models.User
+			err = db.Where("email = ?", email).First(&user).Error
+			if err == nil {
+				fmt.Fprintf(cmd.OutOrStdout(), "Demo account %s already exists (id %d)\n", user.Email, user.ID)
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				user = models.User{Name: "demo", Email: email, Password: string(hash), Role: "user"}
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				entries := make([]models.Entry, len(demoEntries))
+				for i, e := range demoEntries {
+					entries[i] = models.Entry{
+						Situation: e.situation,
+						Text:      e.text,
+						Tags:      e.tags,
+						Icon:      catalog.Icons[i%len(catalog.Icons)],
+						Colour:    catalog.Colours[i%len(catalog.Colours)],
+						UserID:    user.ID,
+					}
+				}
+				return tx.Create(&entries).Error
+			})
+			if err != nil {
+				return fmt.Errorf("failed to seed demo account: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created demo account %s with %d entries\n", email, len(demoEntries))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "demo@example.com", "email address of the demo account")
+	cmd.Flags().StringVar(&password, "password", "demo1234", "password of the demo account")
+	return cmd
+}
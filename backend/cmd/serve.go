@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"Base/internal/bus"
+	"Base/internal/config"
+	"Base/internal/events"
+	"Base/internal/handlers"
+	"Base/internal/health"
+	"Base/internal/jobs"
+	"Base/internal/metrics"
+	"Base/internal/middleware"
+	"Base/internal/migrate"
+	"Base/internal/models"
+	"Base/internal/routes"
+	"Base/internal/storage"
+	"Base/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	exportSweep  = 15 * time.Minute // how often expired data exports are deleted
+	loginFlush   = time.Minute      // how often failed logins are written to the audit log
+	checkTimeout = 2 * time.Second  // for each readiness check
+)
+
+func serveCommand() *cobra.Command {
+	var port int
+	var autoMigrate bool
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the API server",
+		Long: "Run the API server. Pending migrations are applied first unless\n" +
+			"--migrate=false, in which case the server refuses to start on an\n" +
+			"outdated schema. With ADMIN_PASSWORD set, an admin account is created\n" +
+			"if none exists.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("port") {
+				cfg.Server.Port = port
+			}
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+			return serve(cmd.Context(), cfg, autoMigrate)
+		},
+	}
+	cmd.Flags().IntVar(&port, "port", 0, "port to listen on (default $PORT or 8080)")
+	cmd.Flags().BoolVar(&autoMigrate, "migrate", true, "apply pending migrations before serving")
+	return cmd
+}
+
+func serve(ctx context.Context, cfg *config.Config, autoMigrate bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The first SIGINT or SIGTERM starts a graceful shutdown; a second one
+	// kills the process
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Deferred first so it runs last and flushes the spans of the shutdown
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	db, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	if err := db.Use(metrics.Plugin{}); err != nil {
+		return err
+	}
+	if err := db.Use(tracing.Plugin{}); err != nil {
+		return err
+	}
+	if err := metrics.WatchPool(sqlDB, "reminder"); err != nil {
+		return err
+	}
+
+	// Blob storage for entry attachments
+	blobs, err := storage.New(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to set up blob storage: %w", err)
+	}
+	handlers.SetBlobStore(blobs)
+
+	// Live entry updates for connected dashboards, shared between replicas
+	// through the event bus
+	eventBus, err := bus.New(cfg.Events, db, cfg.Database.DSN())
+	if err != nil {
+		return fmt.Errorf("failed to set up event bus: %w", err)
+	}
+	defer eventBus.Close()
+	hub := events.NewHub(events.NewBroker(256), eventBus)
+	handlers.SetEvents(hub)
+
+	// Background workers for slow jobs such as data exports
+	queue := jobs.NewQueue(2, 64)
+	queue.Every("purge-exports", exportSweep, handlers.PurgeExpiredExports)
+	queue.Every("flush-failed-logins", loginFlush, handlers.FlushFailedLogins)
+	handlers.SetJobs(queue)
+
+	if err := ensureSchema(ctx, db, autoMigrate); err != nil {
+		queue.Shutdown(context.Background())
+		return err
+	}
+	if err := seedAdmin(db, cfg.Auth.AdminPassword); err != nil {
+		queue.Shutdown(context.Background())
+		return err
+	}
+
+	// Initialize Gin router
+	router := gin.New()
+	// Probes and scrapes would drown out real traffic in the traces
+	router.Use(tracing.Middleware("/livez", "/readyz", "/health", "/metrics"), metrics.Middleware())
+
+	// Probes: /livez for restarts, /readyz for routing traffic
+	probes := health.New(checkTimeout)
+	probes.Add("database", health.Database(sqlDB))
+	probes.Add("migrations", health.Migrations(migrate.New(db, migrate.All)))
+	probes.Add("jobs", health.Workers(queue.Workers))
+	probes.Add("blobs", health.Blobs(blobs))
+
+	routes.SetupProbes(router, probes)
+
+	router.Use(middleware.Recovery())
+	routes.SetupRoutes(router, cfg)
+
+	srv := newHTTPServer(":"+strconv.Itoa(cfg.Server.Port), router, cfg.Server)
+	// Event streams never finish on their own, so end them when shutdown starts
+	srv.RegisterOnShutdown(hub.Close)
+	servers := []*http.Server{srv}
+
+	// Metrics go on their own listener if there is one, else behind a token
+	switch {
+	case cfg.Metrics.Addr != "":
+		servers = append(servers, newHTTPServer(cfg.Metrics.Addr, metricsMux(cfg.Metrics.Token), cfg.Server))
+	case cfg.Metrics.Token != "":
+		router.GET("/metrics", gin.WrapH(metrics.Handler(cfg.Metrics.Token)))
+	default:
+		slog.Info("metrics disabled; set METRICS_ADDR or METRICS_TOKEN to serve /metrics")
+	}
+
+	serveErr := make(chan error, len(servers))
+	for _, s := range servers {
+		go func() {
+			slog.Info("listening", "addr", s.Addr)
+			serveErr <- s.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		hub.Close()
+		queue.Shutdown(context.Background())
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+	return shutdown(servers, queue, probes, cfg.Server)
+}
+
+func newHTTPServer(addr string, h http.Handler, cfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+func metricsMux(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(token))
+	return mux
+}
+
+// shutdown drains the server in order: /health reports draining for the
+// drain delay, then the servers stop accepting connections and wait for
+// in-flight requests, then queued jobs finish. The event bus and database
+// are closed by serve's deferred calls afterwards.
+func shutdown(servers []*http.Server, queue *jobs.Queue, probes *health.Probes, cfg config.Server) error {
+	slog.Info("shutting down", "drain_delay", cfg.DrainDelay.String())
+	probes.Drain()
+	time.Sleep(cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http server %s: %w", srv.Addr, err))
+		}
+	}
+	if err := queue.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("background jobs: %w", err))
+	}
+	if err := handlers.FlushFailedLogins(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed logins: %w", err))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("unclean shutdown: %w", err)
+	}
+	slog.Info("shutdown complete")
+	return nil
+}
+
+// ensureSchema applies pending migrations, or with autoMigrate off checks
+// that there are none.
+func ensureSchema(ctx context.Context, db *gorm.DB, autoMigrate bool) error {
+	m := migrate.New(db, migrate.All)
+	if !autoMigrate {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read the schema version: %w", err)
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migrations are pending; run migrate up first", len(pending))
+		}
+		return nil
+	}
+	applied, err := m.Up(ctx, 0)
+	for _, mig := range applied {
+		slog.Info("applied migration", "version", mig.Version, "name", mig.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+// seedAdmin creates the admin account if adminPassword is set and no admin
+// exists.
+func seedAdmin(db *gorm.DB, adminPassword string) error {
+	if adminPassword == "" {
+		return nil
+	}
+	var adminUser models.User
+	if err := db.Where("role = ?", "admin").First(&adminUser).Error; err != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		adminUser = models.User{
+			Name:     "admin",
+			Email:    "admin@example.com",
+			Password: string(hash),
+			Role:     "admin",
+		}
+		if err := db.Create(&adminUser).Error; err != nil {
+			return fmt.Errorf("failed to seed admin user: %w", err)
+		}
+		slog.Info("admin user seeded")
+	} else if adminUser.Name != "admin" {
+		// Just in case existing admin has different name
+		adminUser.Name = "admin"
+		db.Save(&adminUser)
+	}
+	return nil
+}
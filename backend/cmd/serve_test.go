@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Base/internal/config"
+	"Base/internal/health"
+	"Base/internal/jobs"
+)
+
+func TestShutdownFinishesInFlightWork(t *testing.T) {
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+
+	var jobRan atomic.Bool
+	queue := jobs.NewQueue(1, 1)
+	queue.Enqueue("slow", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		jobRan.Store(true)
+		return nil
+	})
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	probes := health.New(time.Second)
+	cfg := config.Defaults().Server
+	cfg.DrainDelay = 0
+	if err := shutdown([]*http.Server{srv}, queue, probes, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q, want done", got)
+	}
+	if !jobRan.Load() || !probes.Draining() {
+		t.Errorf("job ran = %v, draining = %v; want both", jobRan.Load(), probes.Draining())
+	}
+}
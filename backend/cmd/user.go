@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"Base/internal/audit"
+	"Base/internal/models"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+)
+
+// minPassword matches the binding on registration.
+const minPassword = 6
+
+func userCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage accounts",
+	}
+	cmd.AddCommand(createAdminCommand(), resetPasswordCommand(), userListCommand())
+	return cmd
+}
+
+func createAdminCommand() *cobra.Command {
+	var name, email string
+	var fromStdin bool
+	cmd := &cobra.Command{
+		Use:   "create-admin",
+		Short: "Create an admin account, or promote an existing one",
+		Long: "Create an admin account. If the email is already registered the account\n" +
+			"is promoted to admin and its password replaced. The password is asked\n" +
+			"for on the terminal, or read from stdin with --password-stdin.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if email == "" {
+				return usageError{errors.New("--email is required")}
+			}
+			password, err := readPassword(cmd, fromStdin)
+			if err != nil {
+				return err
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var user models.User
+			err = db.Unscoped().Where("email = ?", email).First(&user).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				user = models.User{Name: name, Email: email, Password: string(hash), Role: "admin"}
+				if user.Name == "" {
+					user.Name = strings.SplitN(email, "@", 2)[0]
+				}
+				err := db.Transaction(func(tx *gorm.DB) error {
+					if err := tx.Create(&user).Error; err != nil {
+						return err
+					}
+					return auditAccount(tx, audit.AdminCreated, user.ID, nil, user)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to create admin: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Created admin %s (id %d)\n", user.Email, user.ID)
+			case err != nil:
+				return err
+			default:
+				updates := map[string]any{"role": "admin", "password": string(hash), "deleted_at": nil}
+				promoted := user
+				promoted.Role, promoted.Password, promoted.DeletedAt = "admin", string(hash), gorm.DeletedAt{}
+				if name != "" {
+					updates["name"] = name
+					promoted.Name = name
+				}
+				err := db.Transaction(func(tx *gorm.DB) error {
+					if err := tx.Unscoped().Model(&user).Updates(updates).Error; err != nil {
+						return err
+					}
+					return auditAccount(tx, audit.AdminCreated, user.ID, user, promoted)
+				})
+				if err != nil {
+					return fmt.Errorf("failed to promote %s: %w", email, err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Promoted %s (id %d) to admin\n", user.Email, user.ID)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "display name (default: the part of the email before @)")
+	cmd.Flags().StringVar(&email, "email", "", "email address of the admin")
+	cmd.Flags().BoolVar(&fromStdin, "password-stdin", false, "read the password from stdin")
+	return cmd
+}
+
+func resetPasswordCommand() *cobra.Command {
+	var email string
+	var id uint
+	var fromStdin, generate bool
+	cmd := &cobra.Command{
+		Use:   "reset-password",
+		Short: "Set a new password for an account",
+		Long: "Set a new password for the account with the given --email or --id. The\n" +
+			"password is asked for on the terminal, read from stdin with\n" +
+			"--password-stdin, or made up and printed with --generate.",
+		Args: noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (email == "") == (id == 0) {
+				return usageError{errors.New("give exactly one of --email or --id")}
+			}
+			if fromStdin && generate {
+				return usageError{errors.New("use either --password-stdin or --generate")}
+			}
+			var password string
+			var err error
+			if generate {
+				password = rand.Text()
+			} else if password, err = readPassword(cmd, fromStdin); err != nil {
+				return err
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			user, err := findUser(db, id, email)
+			if err != nil {
+				return err
+			}
+			reset := user
+			reset.Password = string(hash)
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&user).Update("password", string(hash)).Error; err != nil {
+					return err
+				}
+				return auditAccount(tx, audit.PasswordReset, user.ID, user, reset)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to reset password: %w", err)
+			}
+			if generate {
+				fmt.Fprintf(cmd.OutOrStdout(), "New password for %s: %s\n", user.Email, password)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "Password reset for %s\n", user.Email)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "email address of the account")
+	cmd.Flags().UintVar(&id, "id", 0, "ID of the account")
+	cmd.Flags().BoolVar(&fromStdin, "password-stdin", false, "read the password from stdin")
+	cmd.Flags().BoolVar(&generate, "generate", false, "generate a random password and print it")
+	return cmd
+}
+
+func userListCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List accounts",
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return usageError{fmt.Errorf("unknown output format %q; use table or json", output)}
+			}
+			db, err := connect(cmd.Context())
+			if err != nil {
+				return err
+			}
+			var users []models.User
+			if err := db.Order("id asc").Find(&users).Error; err != nil {
+				return err
+			}
+			if output == "json" {
+				type row struct {
+					ID    uint   `json:"id"`
+					Name  string `json:"name"`
+					Email string `json:"email"`
+					Role  string `json:"role"`
+				}
+				rows := make([]row, len(users))
+				for i, u := range users {
+					rows[i] = row{u.ID, u.Name, u.Email, u.Role}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(rows)
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tEMAIL\tROLE\tCREATED")
+			for _, u := range users {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Name, u.Email, u.Role, u.CreatedAt.Local().Format("2006-01-02"))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "output format: table or json")
+	return cmd
+}
+
+// auditAccount records an account change made from the command line.
+func auditAccount(tx *gorm.DB, action string, userID uint, before, after any) error {
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	return audit.Append(tx, &models.AuditEvent{
+		Source:     audit.SourceCLI,
+		Action:     action,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Changes:    changes,
+	})
+}
+
+// findUser looks an account up by ID or email, whichever is set.
+func findUser(db *gorm.DB, id uint, email string) (models.User, error) {
+	var user models.User
+	q := db.Where("email = ?", email)
+	if id != 0 {
+		q = db.Where("id = ?", id)
+	}
+	if err := q.First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, exitError{code: exitFailure, msg: "Error: no such user"}
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// readPassword asks twice on a terminal; otherwise, or with fromStdin, it
+// reads the first line of stdin.
+func readPassword(cmd *cobra.Command, fromStdin bool) (string, error) {
+	in := cmd.InOrStdin()
+	f, isFile := in.(*os.File)
+	var password string
+	if !fromStdin && isFile && term.IsTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+		first, err := term.ReadPassword(fd)
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprint(cmd.ErrOrStderr(), "Repeat password: ")
+		second, err := term.ReadPassword(fd)
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", err
+		}
+		if string(first) != string(second) {
+			return "", errors.New("passwords do not match")
+		}
+		password = string(first)
+	} else {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < minPassword {
+		return "", fmt.Errorf("password must be at least %d characters", minPassword)
+	}
+	return password, nil
+}
@@ -0,0 +1,62 @@
+// Package anki reads and writes Anki deck packages (.apkg and legacy
+// .colpkg). A package is a ZIP holding an SQLite collection plus a media
+// manifest; only the parts needed for plain two-sided text cards are handled.
+package anki
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite" // registers the "sqlite" database/sql driver
+)
+
+// ErrUnsupported is returned for packages written by Anki 2.1.50+ with the
+// new compressed collection format only.
+var ErrUnsupported = errors.New(`unsupported Anki package: re-export with "Support older Anki versions" enabled`)
+
+// Deck is the content of a package.
+type Deck struct {
+	Name  string
+	Notes []Note
+}
+
+// Note is a two-sided card. Front and Back are plain text.
+type Note struct {
+	GUID    string
+	Front   string
+	Back    string
+	Tags    []string
+	Created time.Time
+	Reviews []Review
+}
+
+// Review is one entry of a card's review log.
+type Review struct {
+	At       time.Time
+	Ease     int // button pressed: 1 (again) to 4 (easy)
+	Interval int // days; learning steps round down to 0
+}
+
+const fieldSeparator = "\x1f"
+
+// toHTML turns plain text into an Anki field value.
+func toHTML(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "\n", "<br>")
+}
+
+var (
+	lineBreakTags = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+	anyTag        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// fromHTML turns an Anki field value into plain text.
+func fromHTML(s string) string {
+	s = lineBreakTags.ReplaceAllString(s, "\n")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	return strings.TrimSpace(s)
+}
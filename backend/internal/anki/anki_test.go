@@ -0,0 +1,99 @@
+package anki
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	created := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := &Deck{
+		Name: "Memories",
+		Notes: []Note{
+			{
+				GUID:    GUID("1"),
+				Front:   "First <day> at work",
+				Back:    "Nervous & excited\nbut fine",
+				Tags:    []string{"work", "firsts"},
+				Created: created,
+				Reviews: []Review{
+					{At: created.Add(24 * time.Hour), Ease: 3, Interval: 1},
+					{At: created.Add(48 * time.Hour), Ease: 4, Interval: 4},
+				},
+			},
+			{Front: "Same time", Back: "Ids must not collide", Created: created},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	if out.Name != "Memories" {
+		t.Errorf("deck name = %q", out.Name)
+	}
+	if len(out.Notes) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(out.Notes))
+	}
+	got := out.Notes[0]
+	if got.GUID != in.Notes[0].GUID || got.Front != in.Notes[0].Front || got.Back != in.Notes[0].Back {
+		t.Errorf("note mismatch: %+v", got)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "work" || got.Tags[1] != "firsts" {
+		t.Errorf("tags = %v", got.Tags)
+	}
+	if len(got.Reviews) != 2 || got.Reviews[1].Ease != 4 || got.Reviews[1].Interval != 4 {
+		t.Errorf("reviews = %+v", got.Reviews)
+	}
+	if !got.Created.Equal(created) {
+		t.Errorf("created = %v, want %v", got.Created, created)
+	}
+	if len(out.Notes[1].Reviews) != 0 {
+		t.Errorf("second note should have no reviews, got %+v", out.Notes[1].Reviews)
+	}
+}
+
+func TestFromHTML(t *testing.T) {
+	got := fromHTML(`<div>Hello&nbsp;<b>there</b></div><div>line two<br/>three</div>`)
+	want := "Hello there\nline two\nthree"
+	if got != want {
+		t.Errorf("fromHTML = %q, want %q", got, want)
+	}
+}
+
+func TestDeckNameIsTheBiggestDeck(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	for _, q := range []string{
+		`CREATE TABLE col (decks TEXT)`,
+		`CREATE TABLE cards (id INTEGER PRIMARY KEY, did INTEGER)`,
+		`INSERT INTO col VALUES ('{"1":{"name":"Default"},"30":{"name":"Small"},"20":{"name":"Big"},"10":{"name":"Empty"}}')`,
+		`INSERT INTO cards (did) VALUES (1), (1), (1), (20), (20), (30)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if got := deckName(db); got != "Big" {
+			t.Fatalf("deckName = %q, want the deck with most cards", got)
+		}
+	}
+
+	db.Exec(`INSERT INTO cards (did) VALUES (30)`)
+	if got := deckName(db); got != "Big" {
+		t.Errorf("deckName = %q, want the lower ID on a tie", got)
+	}
+}
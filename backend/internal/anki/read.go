@@ -0,0 +1,177 @@
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCollectionSize guards against zip bombs when unpacking the collection.
+const maxCollectionSize = 512 << 20
+
+// Read parses an .apkg or legacy .colpkg package. The first field of each
+// note becomes Front and the remaining non-empty fields are joined into Back.
+func Read(r io.ReaderAt, size int64) (*Deck, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an Anki package: %w", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	// Newer Anki writes both a real .anki21 and a stub .anki2 telling old
+	// clients to upgrade, so prefer the .anki21 one.
+	var coll *zip.File
+	for _, name := range []string{"collection.anki21", "collection.anki2"} {
+		if f, ok := files[name]; ok {
+			coll = f
+			break
+		}
+	}
+	if coll == nil {
+		if _, ok := files["collection.anki21b"]; ok {
+			return nil, ErrUnsupported
+		}
+		return nil, fmt.Errorf("not an Anki package: no collection found")
+	}
+
+	tmp, err := os.CreateTemp("", "anki-*.sqlite")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	rc, err := coll.Open()
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	n, err := io.Copy(tmp, io.LimitReader(rc, maxCollectionSize+1))
+	rc.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n > maxCollectionSize {
+		return nil, fmt.Errorf("collection is larger than %d MiB", maxCollectionSize>>20)
+	}
+
+	return readCollection(tmp.Name())
+}
+
+func readCollection(path string) (*Deck, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	deck := &Deck{Name: deckName(db)}
+
+	rows, err := db.Query(`SELECT id, guid, tags, flds FROM notes ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("read notes: %w", err)
+	}
+	index := map[int64]int{}
+	for rows.Next() {
+		var (
+			id               int64
+			guid, tags, flds string
+		)
+		if err := rows.Scan(&id, &guid, &tags, &flds); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		fields := strings.Split(flds, fieldSeparator)
+		note := Note{
+			GUID:    guid,
+			Front:   fromHTML(fields[0]),
+			Tags:    strings.Fields(tags),
+			Created: time.UnixMilli(id),
+		}
+		var back []string
+		for _, f := range fields[1:] {
+			if text := fromHTML(f); text != "" {
+				back = append(back, text)
+			}
+		}
+		note.Back = strings.Join(back, "\n\n")
+		index[id] = len(deck.Notes)
+		deck.Notes = append(deck.Notes, note)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	revs, err := db.Query(`SELECT r.id, c.nid, r.ease, r.ivl FROM revlog r JOIN cards c ON c.id = r.cid ORDER BY r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("read review log: %w", err)
+	}
+	defer revs.Close()
+	for revs.Next() {
+		var id, nid int64
+		var ease, ivl int
+		if err := revs.Scan(&id, &nid, &ease, &ivl); err != nil {
+			return nil, err
+		}
+		i, ok := index[nid]
+		if !ok {
+			continue
+		}
+		if ivl < 0 { // negative intervals are learning steps in seconds
+			ivl = 0
+		}
+		deck.Notes[i].Reviews = append(deck.Notes[i].Reviews, Review{At: time.UnixMilli(id), Ease: ease, Interval: ivl})
+	}
+	return deck, revs.Err()
+}
+
+// deckName returns the name of the non-default deck most cards are in, the
+// lowest deck ID on a tie, or "" if there is none.
+func deckName(db *sql.DB) string {
+	var raw string
+	if err := db.QueryRow(`SELECT decks FROM col LIMIT 1`).Scan(&raw); err != nil {
+		return ""
+	}
+	var decks map[string]struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(raw), &decks); err != nil {
+		return ""
+	}
+
+	cards := map[int64]int{}
+	if rows, err := db.Query(`SELECT did, COUNT(*) FROM cards GROUP BY did`); err == nil {
+		for rows.Next() {
+			var did int64
+			var n int
+			if rows.Scan(&did, &n) == nil {
+				cards[did] = n
+			}
+		}
+		rows.Close()
+	}
+
+	name, best, bestID := "", -1, int64(0)
+	for key, d := range decks {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil || id == 1 {
+			continue
+		}
+		if n := cards[id]; n > best || n == best && id < bestID {
+			name, best, bestID = d.Name, n, id
+		}
+	}
+	return name
+}
@@ -0,0 +1,258 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const schema = `
+CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null,
+	ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null,
+	models text not null, decks text not null, dconf text not null, tags text not null);
+CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null,
+	usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null,
+	flags integer not null, data text not null);
+CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null,
+	mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null,
+	ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null,
+	odue integer not null, odid integer not null, flags integer not null, data text not null);
+CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null,
+	ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+// Write packages the deck as an .apkg. Every note gets a "Reminder-Card"
+// note type with Situation/Text fields and a single card; reviews are
+// written to the review log so Anki shows the history.
+func Write(w io.Writer, deck *Deck) error {
+	dir, err := os.MkdirTemp("", "apkg-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collection.anki2")
+	if err := writeCollection(path, deck); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path) // #nosec G304 -- path is inside our own temp dir
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if _, err := io.Copy(f, src); err != nil {
+		return err
+	}
+	media, err := zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(media, "{}"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeCollection(path string, deck *Deck) (err error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := db.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	now := time.Now()
+	modelID := now.UnixMilli()
+	deckID := modelID + 1
+	name := deck.Name
+	if name == "" {
+		name = "Reminder-Card"
+	}
+
+	conf, models, decks, dconf, err := collectionConfig(now, modelID, deckID, name, len(deck.Notes))
+	if err != nil {
+		return err
+	}
+	crt := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).Unix()
+	if _, err := db.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		crt, now.UnixMilli(), now.UnixMilli(), conf, models, decks, dconf); err != nil {
+		return fmt.Errorf("insert collection: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var lastID, lastRevID int64
+	nextID := func(t time.Time, last *int64) int64 {
+		id := t.UnixMilli()
+		if id <= *last {
+			id = *last + 1
+		}
+		*last = id
+		return id
+	}
+
+	for i, n := range deck.Notes {
+		created := n.Created
+		if created.IsZero() {
+			created = now
+		}
+		noteID := nextID(created, &lastID)
+		cardID := noteID // notes and cards live in separate tables, so sharing the id is fine
+		front := toHTML(n.Front)
+		flds := front + fieldSeparator + toHTML(n.Back)
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " " + strings.Join(n.Tags, " ") + " "
+		}
+		guid := n.GUID
+		if guid == "" {
+			guid = fmt.Sprintf("rc%d", noteID)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			noteID, guid, modelID, now.Unix(), tags, flds, n.Front, checksum(n.Front)); err != nil {
+			return fmt.Errorf("insert note: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			cardID, noteID, deckID, now.Unix(), i+1); err != nil {
+			return fmt.Errorf("insert card: %w", err)
+		}
+
+		lastIvl := 0
+		for _, r := range n.Reviews {
+			revType := 1 // review
+			if lastIvl == 0 {
+				revType = 0 // learning
+			}
+			if _, err := tx.Exec(`INSERT INTO revlog VALUES (?, ?, -1, ?, ?, ?, 2500, 0, ?)`,
+				nextID(r.At, &lastRevID), cardID, r.Ease, r.Interval, lastIvl, revType); err != nil {
+				return fmt.Errorf("insert review: %w", err)
+			}
+			lastIvl = r.Interval
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checksum is Anki's duplicate-detection hash: the first 8 hex digits of the
+// SHA-1 of the stripped sort field.
+func checksum(field string) int64 {
+	sum := sha1.Sum([]byte(field)) // #nosec G401 -- required by the Anki format
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// GUID derives a stable note GUID from a key, so exporting the same entry
+// twice updates the existing Anki note instead of duplicating it.
+func GUID(key string) string {
+	sum := sha1.Sum([]byte("reminder-card:" + key)) // #nosec G401 -- not used for security
+	return hex.EncodeToString(sum[:8])
+}
+
+func collectionConfig(now time.Time, modelID, deckID int64, deckName string, notes int) (conf, models, decks, dconf string, err error) {
+	mid := fmt.Sprint(modelID)
+	did := fmt.Sprint(deckID)
+
+	field := func(name string, ord int) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name, "ord": ord, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		}
+	}
+	deckObj := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "mod": now.Unix(), "usn": -1, "desc": "", "dyn": 0, "conf": 1,
+			"collapsed": false, "browserCollapsed": false, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+
+	parts := []interface{}{
+		map[string]interface{}{
+			"nextPos": notes + 1, "estTimes": true, "activeDecks": []int64{deckID}, "sortType": "noteFld",
+			"timeLim": 0, "sortBackwards": false, "addToCur": true, "curDeck": deckID, "newBury": true,
+			"newSpread": 0, "dueCounts": true, "curModel": mid, "collapseTime": 1200,
+		},
+		map[string]interface{}{
+			mid: map[string]interface{}{
+				"id": modelID, "name": "Reminder-Card", "type": 0, "mod": now.Unix(), "usn": -1,
+				"sortf": 0, "did": deckID, "tags": []string{}, "vers": []int{},
+				"flds": []interface{}{field("Situation", 0), field("Text", 1)},
+				"tmpls": []interface{}{map[string]interface{}{
+					"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+					"qfmt": "{{Situation}}",
+					"afmt": "{{FrontSide}}\n\n<hr id=answer>\n\n{{Text}}",
+				}},
+				"css":       ".card {\n font-family: arial;\n font-size: 20px;\n text-align: center;\n color: black;\n background-color: white;\n}\n",
+				"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+				"latexPost": "\\end{document}",
+				"req":       []interface{}{[]interface{}{0, "any", []int{0}}},
+			},
+		},
+		map[string]interface{}{
+			"1": deckObj(1, "Default"),
+			did: deckObj(deckID, deckName),
+		},
+		map[string]interface{}{
+			"1": map[string]interface{}{
+				"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true,
+				"timer": 0, "replayq": true, "dyn": false,
+				"new": map[string]interface{}{
+					"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+					"separate": true, "order": 1, "perDay": 20, "bury": false,
+				},
+				"rev": map[string]interface{}{
+					"perDay": 200, "ease4": 1.3, "fuzz": 0.05, "minSpace": 1, "ivlFct": 1,
+					"maxIvl": 36500, "bury": false, "hardFactor": 1.2,
+				},
+				"lapse": map[string]interface{}{
+					"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+				},
+			},
+		},
+	}
+
+	out := make([]string, len(parts))
+	for i, p := range parts {
+		b, err := json.Marshal(p)
+		if err != nil {
+			return "", "", "", "", err
+		}
+		out[i] = string(b)
+	}
+	return out[0], out[1], out[2], out[3], nil
+}
@@ -0,0 +1,258 @@
+// Package apierror defines the API's error responses: RFC 7807 problem
+// details carrying a stable machine-readable code, optional field-level
+// validation errors and the request ID. Handlers record errors with Abort
+// and middleware.Errors renders them.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"Base/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// FieldError describes one invalid field of a request body. Message is
+// filled in, in the client's language, when the problem is rendered.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`            // the failed binding rule, e.g. required or email
+	Param   string `json:"param,omitempty"` // the rule's parameter, e.g. 6 for min=6
+	Message string `json:"message"`
+}
+
+// Error is an API error. The zero Status means the code's usual status.
+// The detail is either a catalog message (DetailKey, translated when
+// rendered) or fixed text such as a parser error.
+type Error struct {
+	Code       Code
+	Status     int
+	Detail     string
+	DetailKey  string
+	DetailArgs []string
+	Fields     []FieldError
+	Extra      map[string]interface{} // additional problem members
+	Cause      error                  // logged, never sent to the client
+}
+
+// New returns an error with the given code and its usual status.
+func New(code Code) *Error {
+	return &Error{Code: code, Status: code.Status()}
+}
+
+// Detailf sets an untranslated explanation of this occurrence, for text
+// that only exists in English such as parser errors.
+func (e *Error) Detailf(format string, args ...interface{}) *Error {
+	e.Detail = fmt.Sprintf(format, args...)
+	return e
+}
+
+// Localized sets the explanation to an i18n message with placeholder
+// name/value pairs, e.g. Localized("detail.file_too_large", "max", "1024").
+func (e *Error) Localized(key string, args ...string) *Error {
+	e.DetailKey, e.DetailArgs = key, args
+	return e
+}
+
+func (e *Error) detail(lang i18n.Lang) string {
+	if e.DetailKey != "" {
+		return i18n.T(lang, e.DetailKey, e.DetailArgs...)
+	}
+	return e.Detail
+}
+
+// With adds an extension member to the problem document.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extra == nil {
+		e.Extra = make(map[string]interface{})
+	}
+	e.Extra[key] = value
+	return e
+}
+
+// Wrap records the underlying error for the server log.
+func (e *Error) Wrap(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := string(e.Code)
+	if d := e.detail(i18n.Default); d != "" {
+		msg += ": " + d
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Is matches errors with the same code, so errors.Is(err, EntryNotFound) works.
+func (e *Error) Is(target error) bool {
+	code, ok := target.(Code)
+	return ok && code == e.Code
+}
+
+// From converts any error into an *Error. Unknown errors become
+// internal errors with err as their cause.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var code Code
+	if errors.As(err, &code) {
+		return New(code)
+	}
+	return New(InternalError).Wrap(err)
+}
+
+// Abort stops the request with err, which middleware.Errors renders.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(From(err))
+	c.Abort()
+}
+
+// FromBinding turns a ShouldBind error into invalid_json or, for failed
+// binding rules, validation_failed with one FieldError per field.
+func FromBinding(err error) *Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		e := New(ValidationFailed)
+		for _, fe := range verrs {
+			e.Fields = append(e.Fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		return e
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		e := New(ValidationFailed)
+		e.Fields = []FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String()}}
+		return e
+	}
+
+	if errors.Is(err, io.EOF) {
+		return New(InvalidJSON).Localized("detail.empty_body").Wrap(err)
+	}
+	return New(InvalidJSON).Wrap(err)
+}
+
+// fieldMessage is the message for a failed binding rule in lang.
+func fieldMessage(lang i18n.Lang, f FieldError) string {
+	key := "validation." + f.Rule
+	if !i18n.Has(i18n.Default, key) {
+		key = "validation.default"
+	}
+	return i18n.T(lang, key, "field", f.Field, "rule", f.Rule, "param", f.Param)
+}
+
+func init() {
+	// Report fields by their JSON names rather than Go struct field names.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	TraceID   string       `json:"trace_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	Extra map[string]interface{} `json:"-"`
+}
+
+// TypeURI identifies a problem type; it is not meant to be dereferenced.
+func TypeURI(code Code) string {
+	return "urn:reminder-card:problem:" + string(code)
+}
+
+// Problem builds the response document for e in lang.
+func (e *Error) Problem(lang i18n.Lang, instance, requestID string) Problem {
+	var fields []FieldError
+	for _, f := range e.Fields {
+		f.Message = fieldMessage(lang, f)
+		fields = append(fields, f)
+	}
+	return Problem{
+		Type:      TypeURI(e.Code),
+		Title:     e.Code.Title(lang),
+		Status:    e.Status,
+		Detail:    e.detail(lang),
+		Instance:  instance,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    fields,
+		Extra:     e.Extra,
+	}
+}
+
+// MarshalJSON inlines the extension members next to the standard ones.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	base, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extra) == 0 {
+		return base, err
+	}
+	members := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(base, &members); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extra {
+		if _, taken := members[k]; taken {
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		members[k] = raw
+	}
+	return json.Marshal(members)
+}
+
+// ItemError is why one item of a batch, sync or import request failed
+// while the rest of the request went through: the error's code and its
+// detail (or title) in the client's language.
+type ItemError struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Item builds the per-item report of err in lang.
+func Item(lang i18n.Lang, err error) *ItemError {
+	e := From(err)
+	msg := e.detail(lang)
+	if msg == "" {
+		msg = e.Code.Title(lang)
+	}
+	return &ItemError{Code: e.Code, Message: msg}
+}
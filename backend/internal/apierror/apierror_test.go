@@ -0,0 +1,92 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Base/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFromBindingReportsFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"nope","password":"123"}`))
+
+	var input struct {
+		Name     string `json:"name" binding:"required"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+	e := FromBinding(c.ShouldBindJSON(&input))
+	if e.Code != ValidationFailed || e.Status != http.StatusBadRequest {
+		t.Fatalf("got %s (%d)", e.Code, e.Status)
+	}
+
+	got := map[string]string{}
+	for _, f := range e.Fields {
+		got[f.Field] = f.Rule + f.Param
+	}
+	want := map[string]string{"name": "required", "email": "email", "password": "min6"}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("field %s: got rule %q, want %q (all: %+v)", field, got[field], rule, e.Fields)
+		}
+	}
+
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+	if e := FromBinding(c.ShouldBindJSON(&input)); e.Code != InvalidJSON {
+		t.Errorf("malformed JSON gave %s", e.Code)
+	}
+}
+
+func TestProblemDocument(t *testing.T) {
+	e := New(ExportInProgress).With("job", map[string]int{"id": 3})
+	b, err := json.Marshal(e.Problem(i18n.EN, "/user/exports", "req-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	_ = json.Unmarshal(b, &doc)
+	if doc["type"] != "urn:reminder-card:problem:export_in_progress" || doc["status"] != float64(409) ||
+		doc["code"] != "export_in_progress" || doc["request_id"] != "req-1" || doc["instance"] != "/user/exports" {
+		t.Errorf("unexpected problem %s", b)
+	}
+	if job, ok := doc["job"].(map[string]interface{}); !ok || job["id"] != float64(3) {
+		t.Errorf("extension member missing: %s", b)
+	}
+
+	if !errors.Is(New(EntryNotFound), EntryNotFound) || errors.Is(New(EntryNotFound), UserNotFound) {
+		t.Error("errors.Is should match on the code")
+	}
+}
+
+func TestProblemIsLocalized(t *testing.T) {
+	e := New(ValidationFailed)
+	e.Fields = []FieldError{{Field: "password", Rule: "min", Param: "6"}}
+	p := e.Problem(i18n.RU, "/user/create", "")
+	if p.Title != "Ошибка проверки данных" || p.Errors[0].Message != "Поле password: минимальная длина — 6" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+
+	p = New(FileTooLarge).Localized("detail.file_too_large", "max", "10").Problem(i18n.EN, "", "")
+	if p.Detail != "File exceeds 10 bytes" {
+		t.Errorf("detail = %q", p.Detail)
+	}
+}
+
+func TestEveryCodeHasATitle(t *testing.T) {
+	for code := range statuses {
+		for _, lang := range i18n.Supported() {
+			if !i18n.Has(lang, "error."+string(code)) {
+				t.Errorf("%s: no title for %s", lang, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package apierror
+
+import (
+	"net/http"
+
+	"Base/internal/i18n"
+)
+
+// Code is a stable, machine-readable error identifier. Clients should
+// branch on (and translate) codes; titles and details are for humans and
+// may change. A Code is itself an error, so handlers can abort with one
+// directly.
+type Code string
+
+// Request problems
+const (
+	InvalidJSON        Code = "invalid_json"
+	ValidationFailed   Code = "validation_failed"
+	MissingFields      Code = "missing_fields"
+	InvalidReminder    Code = "invalid_reminder"
+	InvalidID          Code = "invalid_id"
+	InvalidCursor      Code = "invalid_cursor"
+	InvalidBatch       Code = "invalid_batch"
+	TooManyChanges     Code = "too_many_changes"
+	FileRequired       Code = "file_required"
+	FileUnreadable     Code = "file_unreadable"
+	FileTooLarge       Code = "file_too_large"
+	FileTypeNotAllowed Code = "file_type_not_allowed"
+	UnsupportedFormat  Code = "unsupported_format"
+	InvalidFile        Code = "invalid_file"
+	ChecksumMismatch   Code = "checksum_mismatch"
+	BodyTooLarge       Code = "body_too_large"
+)
+
+// Authentication and authorisation
+const (
+	Unauthorized       Code = "unauthorized"
+	InvalidToken       Code = "invalid_token"
+	Forbidden          Code = "forbidden"
+	InvalidCredentials Code = "invalid_credentials"
+	CredentialsMissing Code = "credentials_missing"
+)
+
+// Resources and their state
+const (
+	RouteNotFound         Code = "route_not_found"
+	EntryNotFound         Code = "entry_not_found"
+	UserNotFound          Code = "user_not_found"
+	AttachmentNotFound    Code = "attachment_not_found"
+	ThumbnailNotFound     Code = "thumbnail_not_found"
+	ThumbnailPending      Code = "thumbnail_pending"
+	ExportNotFound        Code = "export_not_found"
+	CalendarNotFound      Code = "calendar_not_found"
+	EmailTaken            Code = "email_taken"
+	ExportInProgress      Code = "export_in_progress"
+	ExportUnavailable     Code = "export_unavailable"
+	LinkExpired           Code = "link_expired"
+	IdempotencyKeyReused  Code = "idempotency_key_reused"
+	IdempotencyKeyBusy    Code = "idempotency_key_in_progress"
+	IdempotencyKeyTooLong Code = "idempotency_key_too_long"
+)
+
+// Server problems
+const (
+	DatabaseError Code = "database_error"
+	StorageError  Code = "storage_error"
+	InternalError Code = "internal_error"
+	Unavailable   Code = "service_unavailable"
+)
+
+// statuses maps each code to the HTTP status normally returned with it.
+// Titles live in the i18n catalogs under "error.<code>".
+var statuses = map[Code]int{
+	InvalidJSON:        http.StatusBadRequest,
+	ValidationFailed:   http.StatusBadRequest,
+	MissingFields:      http.StatusBadRequest,
+	InvalidReminder:    http.StatusBadRequest,
+	InvalidID:          http.StatusBadRequest,
+	InvalidCursor:      http.StatusBadRequest,
+	InvalidBatch:       http.StatusBadRequest,
+	TooManyChanges:     http.StatusBadRequest,
+	FileRequired:       http.StatusBadRequest,
+	FileUnreadable:     http.StatusBadRequest,
+	FileTooLarge:       http.StatusRequestEntityTooLarge,
+	FileTypeNotAllowed: http.StatusUnsupportedMediaType,
+	UnsupportedFormat:  http.StatusBadRequest,
+	InvalidFile:        http.StatusBadRequest,
+	ChecksumMismatch:   http.StatusBadRequest,
+	BodyTooLarge:       http.StatusRequestEntityTooLarge,
+
+	Unauthorized:       http.StatusUnauthorized,
+	InvalidToken:       http.StatusUnauthorized,
+	Forbidden:          http.StatusForbidden,
+	InvalidCredentials: http.StatusUnauthorized,
+	CredentialsMissing: http.StatusBadRequest,
+
+	RouteNotFound:         http.StatusNotFound,
+	EntryNotFound:         http.StatusNotFound,
+	UserNotFound:          http.StatusNotFound,
+	AttachmentNotFound:    http.StatusNotFound,
+	ThumbnailNotFound:     http.StatusNotFound,
+	ThumbnailPending:      http.StatusAccepted,
+	ExportNotFound:        http.StatusNotFound,
+	CalendarNotFound:      http.StatusNotFound,
+	EmailTaken:            http.StatusConflict,
+	ExportInProgress:      http.StatusConflict,
+	ExportUnavailable:     http.StatusGone,
+	LinkExpired:           http.StatusGone,
+	IdempotencyKeyReused:  http.StatusUnprocessableEntity,
+	IdempotencyKeyBusy:    http.StatusConflict,
+	IdempotencyKeyTooLong: http.StatusBadRequest,
+
+	DatabaseError: http.StatusInternalServerError,
+	StorageError:  http.StatusInternalServerError,
+	InternalError: http.StatusInternalServerError,
+	Unavailable:   http.StatusServiceUnavailable,
+}
+
+func (c Code) Error() string { return c.Title(i18n.Default) }
+
+// Status is the HTTP status normally returned with the code.
+func (c Code) Status() int {
+	if s, ok := statuses[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// Title is the short summary of the code in lang.
+func (c Code) Title(lang i18n.Lang) string {
+	return i18n.T(lang, "error."+string(c))
+}
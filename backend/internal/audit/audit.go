@@ -0,0 +1,223 @@
+// Package audit keeps the append-only log of admin and security actions.
+// Records are hash-chained: each one's hash covers its content and the
+// hash of the record before it, so Verify finds any record that was edited,
+// removed or reordered after it was written.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Base/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the log.
+const (
+	LoginSucceeded = "auth.login"
+	LoginFailed    = "auth.login_failed"
+	UserUpdated    = "user.update"
+	UserDeleted    = "user.delete"
+	AdminCreated   = "user.create_admin"
+	PasswordReset  = "user.reset_password"
+	EntryUpdated   = "entry.update"
+	EntryDeleted   = "entry.delete"
+	EntryTagged    = "entry.tag"
+	EntryMoved     = "entry.move"
+)
+
+// Target types.
+const (
+	TargetUser  = "user"
+	TargetEntry = "entry"
+)
+
+// Sources of an action.
+const (
+	SourceAPI = "api"
+	SourceCLI = "cli"
+)
+
+// Genesis is the previous hash of the first record.
+var Genesis = strings.Repeat("0", 64)
+
+// lockKey is the Postgres advisory lock that serialises appends, so two
+// records never claim the same predecessor.
+const lockKey = 727275
+
+// Append assigns e its ID, time and hashes and writes it after the current
+// last record. Run it in the transaction that makes the audited change so
+// the two are committed together.
+func Append(tx *gorm.DB, e *models.AuditEvent) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+		}
+		var last models.AuditEvent
+		if err := tx.Order("id desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		e.ID = last.ID + 1
+		e.PrevHash = last.Hash
+		if e.ID == 1 {
+			e.PrevHash = Genesis
+		}
+		// Postgres keeps microseconds; hash what will be read back
+		e.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		e.Hash = Hash(e)
+		return tx.Create(e).Error
+	})
+}
+
+// Hash is the chain hash of e: SHA-256 over its fields and PrevHash.
+func Hash(e *models.AuditEvent) string {
+	b, _ := json.Marshal(struct {
+		ID         uint   `json:"id"`
+		CreatedAt  string `json:"created_at"`
+		ActorID    uint   `json:"actor_id"`
+		Source     string `json:"source"`
+		Action     string `json:"action"`
+		TargetType string `json:"target_type"`
+		TargetID   uint   `json:"target_id"`
+		Changes    string `json:"changes"`
+		IP         string `json:"ip"`
+		RequestID  string `json:"request_id"`
+		PrevHash   string `json:"prev_hash"`
+	}{
+		e.ID, e.CreatedAt.UTC().Format(time.RFC3339Nano), e.ActorID, e.Source, e.Action,
+		e.TargetType, e.TargetID, e.Changes, e.IP, e.RequestID, e.PrevHash,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Report is the outcome of Verify. Head is the hash of the last record;
+// keep a copy elsewhere to also detect records cut off the end of the log.
+type Report struct {
+	OK       bool   `json:"ok"`
+	Count    int    `json:"count"`
+	Head     string `json:"head"`
+	BrokenAt *uint  `json:"broken_at,omitempty"` // first record that doesn't fit the chain
+	Reason   string `json:"reason,omitempty"`
+}
+
+var errBroken = errors.New("chain broken")
+
+// Verify walks the whole log in order and checks every link.
+func Verify(ctx context.Context, db *gorm.DB) (Report, error) {
+	r := Report{OK: true, Head: Genesis}
+	var prev models.AuditEvent
+	var batch []models.AuditEvent
+	err := db.WithContext(ctx).Order("id asc").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			e := &batch[i]
+			switch {
+			case e.ID != prev.ID+1:
+				r.Reason = fmt.Sprintf("expected record %d", prev.ID+1)
+			case e.PrevHash != r.Head:
+				r.Reason = "prev_hash does not match the previous record"
+			case e.Hash != Hash(e):
+				r.Reason = "hash does not match the record"
+			default:
+				r.Count++
+				r.Head = e.Hash
+				prev = *e
+				continue
+			}
+			r.OK = false
+			r.BrokenAt = &e.ID
+			return errBroken
+		}
+		return nil
+	}).Error
+	if err != nil && !errors.Is(err, errBroken) {
+		return r, err
+	}
+	return r, nil
+}
+
+// Diff returns the JSON of the fields that differ between the JSON forms
+// of before and after, as {"field": {"before": ..., "after": ...}}. Either
+// may be nil for a creation or deletion. Password values are masked, entry
+// text and email addresses are replaced by a SHA-256 digest so the log,
+// which can't be purged, holds no user content, and timestamps maintained
+// by GORM are left out.
+func Diff(before, after any) (string, error) {
+	b, err := fields(before)
+	if err != nil {
+		return "", err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return "", err
+	}
+	type change struct {
+		Before any `json:"before"`
+		After  any `json:"after"`
+	}
+	changes := map[string]change{}
+	for _, m := range []map[string]json.RawMessage{b, a} {
+		for k := range m {
+			if ignored[k] || string(b[k]) == string(a[k]) {
+				continue
+			}
+			c := change{Before: b[k], After: a[k]}
+			switch {
+			case masked[k]:
+				c = change{Before: mask(b[k]), After: mask(a[k])}
+			case digested[k]:
+				c = change{Before: digest(b[k]), After: digest(a[k])}
+			}
+			changes[k] = c
+		}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	out, err := json.Marshal(changes)
+	return string(out), err
+}
+
+var (
+	ignored  = map[string]bool{"CreatedAt": true, "UpdatedAt": true}
+	masked   = map[string]bool{"password": true}
+	digested = map[string]bool{"situation": true, "text": true, "tags": true, "email": true}
+)
+
+func mask(v json.RawMessage) any {
+	if v == nil {
+		return nil
+	}
+	return "[REDACTED]"
+}
+
+// digest shows that a value changed without keeping it.
+func digest(v json.RawMessage) any {
+	if v == nil {
+		return nil
+	}
+	sum := sha256.Sum256(v)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func fields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(raw, &m)
+	return m, err
+}
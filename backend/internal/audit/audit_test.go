@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"Base/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, action := range []string{LoginSucceeded, UserUpdated, EntryDeleted} {
+		if err := Append(db, &models.AuditEvent{ActorID: 1, Source: SourceAPI, Action: action, TargetType: TargetUser, TargetID: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestAppendChainsRecords(t *testing.T) {
+	db := openDB(t)
+
+	var events []models.AuditEvent
+	db.Order("id").Find(&events)
+	if len(events) != 3 || events[0].PrevHash != Genesis {
+		t.Fatalf("events = %+v", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].ID != events[i-1].ID+1 || events[i].PrevHash != events[i-1].Hash {
+			t.Errorf("record %d does not follow record %d", events[i].ID, events[i-1].ID)
+		}
+	}
+
+	r, err := Verify(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK || r.Count != 3 || r.Head != events[2].Hash {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestVerifyFindsTampering(t *testing.T) {
+	for name, tamper := range map[string]func(db *gorm.DB){
+		"edited":  func(db *gorm.DB) { db.Model(&models.AuditEvent{}).Where("id = 2").Update("actor_id", 7) },
+		"removed": func(db *gorm.DB) { db.Delete(&models.AuditEvent{}, 2) },
+		"rehashed": func(db *gorm.DB) {
+			var e models.AuditEvent
+			db.First(&e, 2)
+			e.Action = UserDeleted
+			db.Model(&e).Updates(map[string]any{"action": e.Action, "hash": Hash(&e)})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := openDB(t)
+			tamper(db)
+			r, err := Verify(context.Background(), db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.OK || r.BrokenAt == nil || r.Reason == "" {
+				t.Fatalf("report = %+v, want a broken chain", r)
+			}
+			// The rehashed record is consistent; the next one no longer is
+			if want := map[string]uint{"edited": 2, "removed": 3, "rehashed": 3}[name]; *r.BrokenAt != want {
+				t.Errorf("broken at %d, want %d", *r.BrokenAt, want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := models.User{Name: "ann", Email: "ann@example.com", Password: "old-hash", Role: "user"}
+	after := before
+	after.Role, after.Password = "admin", "new-hash"
+
+	got, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "hash") || !strings.Contains(got, `"role":{"before":"user","after":"admin"}`) ||
+		!strings.Contains(got, `"password":{"before":"[REDACTED]","after":"[REDACTED]"}`) || strings.Contains(got, "email") {
+		t.Errorf("Diff = %s", got)
+	}
+
+	if got, _ := Diff(before, before); got != "" {
+		t.Errorf("Diff of equal values = %q, want empty", got)
+	}
+	if got, _ := Diff(nil, after); !strings.Contains(got, `"role":{"before":null,"after":"admin"}`) {
+		t.Errorf("Diff of a creation = %s", got)
+	}
+
+	moved := after
+	moved.Email = "ann@example.org"
+	got, _ = Diff(after, moved)
+	if strings.Contains(got, "example") || !strings.Contains(got, `"email":{"before":"sha256:`) {
+		t.Errorf("Diff of an email change = %s, want digests", got)
+	}
+	entry := models.Entry{Situation: "Job interview", Text: "Breathe."}
+	if got, _ := Diff(nil, entry); strings.Contains(got, "Breathe") || strings.Contains(got, "interview") {
+		t.Errorf("Diff of an entry = %s, want its text digested", got)
+	}
+}
+
+func TestFailedLoginsAreBatched(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var f FailedLogins
+	for i := 0; i < 5; i++ {
+		f.Add(2, "10.0.0.1", "req")
+	}
+	f.Add(0, "10.0.0.2", "req")
+	for i := 0; i < maxFailedLoginKeys+10; i++ {
+		f.Add(3, fmt.Sprintf("192.0.2.%d", i), "req")
+	}
+
+	if err := f.Flush(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	var failed []models.AuditEvent
+	db.Where("action = ?", LoginFailed).Find(&failed)
+	if len(failed) != maxFailedLoginKeys+1 {
+		t.Fatalf("flush wrote %d records, want one per user and IP up to %d plus the overflow", len(failed), maxFailedLoginKeys)
+	}
+	var first models.AuditEvent
+	db.Where("action = ? AND target_id = ? AND ip = ?", LoginFailed, 2, "10.0.0.1").First(&first)
+	if !strings.Contains(first.Changes, `"attempts":{"before":null,"after":5}`) {
+		t.Errorf("changes = %s, want 5 attempts", first.Changes)
+	}
+
+	if err := f.Flush(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	db.Model(&models.AuditEvent{}).Where("action = ?", LoginFailed).Count(&n)
+	if n != int64(len(failed)) {
+		t.Errorf("an empty flush wrote %d records", n-int64(len(failed)))
+	}
+	if r, err := Verify(ctx, db); err != nil || !r.OK {
+		t.Errorf("Verify = %+v, %v", r, err)
+	}
+}
+
+func TestFailedLoginsSurviveAFailedFlush(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var f FailedLogins
+	f.Add(2, "10.0.0.1", "req-1")
+	f.Add(2, "10.0.0.1", "req-2")
+
+	broken, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err := f.Flush(ctx, broken); err == nil {
+		t.Fatal("flush into a database without the audit table succeeded")
+	}
+	f.Add(2, "10.0.0.1", "req-3")
+
+	if err := f.Flush(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	var e models.AuditEvent
+	if err := db.Where("action = ?", LoginFailed).First(&e).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(e.Changes, `"attempts":{"before":null,"after":3}`) || e.RequestID != "req-3" {
+		t.Errorf("record = %s %s, want 3 attempts ending with req-3", e.Changes, e.RequestID)
+	}
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"Base/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxFailedLoginKeys bounds how many user/IP pairs are counted between
+// flushes; attempts beyond it are counted under user 0 with no IP.
+const maxFailedLoginKeys = 1000
+
+// FailedLogins counts failed logins per target user and client IP until
+// Flush writes one record for each. Appending every attempt would let
+// unauthenticated traffic grow the log and take its lock once per request.
+type FailedLogins struct {
+	mu      sync.Mutex
+	pending map[failedLoginKey]*failedLogins
+}
+
+type failedLoginKey struct {
+	userID uint
+	ip     string
+}
+
+type failedLogins struct {
+	Attempts  int       `json:"attempts"`
+	FirstAt   time.Time `json:"first_at"`
+	LastAt    time.Time `json:"last_at"`
+	requestID string
+}
+
+// Add counts a failed login for userID, 0 if no account matched.
+func (f *FailedLogins) Add(userID uint, ip, requestID string) {
+	now := time.Now().UTC()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil {
+		f.pending = make(map[failedLoginKey]*failedLogins)
+	}
+	key := failedLoginKey{userID, ip}
+	if _, ok := f.pending[key]; !ok && len(f.pending) >= maxFailedLoginKeys {
+		key = failedLoginKey{}
+	}
+	n := f.pending[key]
+	if n == nil {
+		n = &failedLogins{FirstAt: now}
+		f.pending[key] = n
+	}
+	n.Attempts++
+	n.LastAt = now
+	n.requestID = requestID
+}
+
+// Flush appends a LoginFailed record per user and IP with the number of
+// attempts since the last flush, and the time of the first and last one.
+// The request ID is the last attempt's. If the records can't be written
+// the counts are kept for the next flush.
+func (f *FailedLogins) Flush(ctx context.Context, db *gorm.DB) error {
+	f.mu.Lock()
+	pending := f.pending
+	f.pending = nil
+	f.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for key, n := range pending {
+			changes, err := Diff(nil, n)
+			if err != nil {
+				return err
+			}
+			e := &models.AuditEvent{
+				Source:     SourceAPI,
+				Action:     LoginFailed,
+				TargetType: TargetUser,
+				TargetID:   key.userID,
+				Changes:    changes,
+				IP:         key.ip,
+				RequestID:  n.requestID,
+			}
+			if err := Append(tx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		f.restore(pending)
+	}
+	return err
+}
+
+// restore merges counts that failed to flush with those added since.
+func (f *FailedLogins) restore(batch map[failedLoginKey]*failedLogins) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil {
+		f.pending = make(map[failedLoginKey]*failedLogins)
+	}
+	for key, old := range batch {
+		if _, ok := f.pending[key]; !ok && len(f.pending) >= maxFailedLoginKeys {
+			key = failedLoginKey{}
+		}
+		n := f.pending[key]
+		if n == nil {
+			f.pending[key] = old
+			continue
+		}
+		// n holds the later attempts, so its last time and request ID stay
+		n.Attempts += old.Attempts
+		if old.FirstAt.Before(n.FirstAt) {
+			n.FirstAt = old.FirstAt
+		}
+		if old.LastAt.After(n.LastAt) {
+			n.LastAt, n.requestID = old.LastAt, old.requestID
+		}
+	}
+}
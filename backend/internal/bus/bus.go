@@ -0,0 +1,59 @@
+// Package bus carries small messages between server instances, so a change
+// on one replica reaches subscribers on all of them. Today the SSE broker is
+// the only subscriber; the server has no shared caches or schedulers yet,
+// and their topics belong here once it does.
+package bus
+
+import (
+	"context"
+	"fmt"
+
+	"Base/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// Topics
+const (
+	TopicEntries = "entries" // entry change events for live clients
+)
+
+// MaxPayload is the largest payload every implementation accepts
+// (PostgreSQL limits NOTIFY payloads to just under 8000 bytes).
+const MaxPayload = 7900
+
+// Handler receives a message published on a topic. It must not block.
+type Handler func(payload []byte)
+
+// Bus is a publish/subscribe channel shared by every server instance.
+// Publishers receive their own messages too, so local and remote delivery
+// go through the same path.
+type Bus interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(topic string, h Handler) (unsubscribe func())
+	Close() error
+}
+
+// New builds the bus selected by cfg.Bus: "memory" (single instance) or
+// "postgres" (LISTEN/NOTIFY on dsn, for multiple replicas).
+func New(cfg config.Events, db *gorm.DB, dsn string) (Bus, error) {
+	switch cfg.Bus {
+	case "", "memory":
+		return NewMemory(), nil
+	case "postgres":
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgres(dsn, sqlDB), nil
+	default:
+		return nil, fmt.Errorf("unknown event bus %q", cfg.Bus)
+	}
+}
+
+func checkPayload(topic string, payload []byte) error {
+	if len(payload) > MaxPayload {
+		return fmt.Errorf("bus: %d-byte payload on %q exceeds %d bytes", len(payload), topic, MaxPayload)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package bus
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMemoryBus(t *testing.T) {
+	b := NewMemory()
+	var got []string
+	unsubscribe := b.Subscribe(TopicEntries, func(p []byte) { got = append(got, string(p)) })
+	b.Subscribe("other", func(p []byte) { t.Errorf("wrong topic received %s", p) })
+
+	ctx := context.Background()
+	if err := b.Publish(ctx, TopicEntries, []byte("one")); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe()
+	if err := b.Publish(ctx, TopicEntries, []byte("two")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0] != "one" {
+		t.Errorf("got %v", got)
+	}
+	if err := b.Publish(ctx, TopicEntries, []byte(strings.Repeat("x", MaxPayload+1))); err == nil {
+		t.Error("oversized payloads should be rejected")
+	}
+}
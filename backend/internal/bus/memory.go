@@ -0,0 +1,53 @@
+package bus
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory delivers messages within the current process only.
+type Memory struct {
+	mu       sync.RWMutex
+	handlers map[string]map[int]Handler
+	nextID   int
+}
+
+func NewMemory() *Memory {
+	return &Memory{handlers: map[string]map[int]Handler{}}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	if err := checkPayload(topic, payload); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	hs := make([]Handler, 0, len(m.handlers[topic]))
+	for _, h := range m.handlers[topic] {
+		hs = append(hs, h)
+	}
+	m.mu.RUnlock()
+
+	for _, h := range hs {
+		h(payload)
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(topic string, h Handler) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.handlers[topic] == nil {
+		m.handlers[topic] = map[int]Handler{}
+	}
+	id := m.nextID
+	m.nextID++
+	m.handlers[topic][id] = h
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.handlers[topic], id)
+	}
+}
+
+func (m *Memory) Close() error { return nil }
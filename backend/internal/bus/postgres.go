@@ -0,0 +1,174 @@
+package bus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Postgres fans messages out with LISTEN/NOTIFY. Publishing goes through the
+// regular connection pool; a dedicated connection listens and reconnects
+// with backoff if it drops. Messages sent while it is reconnecting are lost,
+// which live clients recover from by resuming or refetching.
+type Postgres struct {
+	dsn string
+	db  *sql.DB
+
+	mu       sync.RWMutex
+	handlers map[string]map[int]Handler
+	nextID   int
+	changed  chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPostgres(dsn string, db *sql.DB) *Postgres {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Postgres{
+		dsn:      dsn,
+		db:       db,
+		handlers: map[string]map[int]Handler{},
+		changed:  make(chan struct{}, 1),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go p.run(ctx)
+	return p
+}
+
+func (p *Postgres) Publish(ctx context.Context, topic string, payload []byte) error {
+	if err := checkPayload(topic, payload); err != nil {
+		return err
+	}
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", topic, string(payload))
+	return err
+}
+
+func (p *Postgres) Subscribe(topic string, h Handler) func() {
+	p.mu.Lock()
+	if p.handlers[topic] == nil {
+		p.handlers[topic] = map[int]Handler{}
+	}
+	id := p.nextID
+	p.nextID++
+	p.handlers[topic][id] = h
+	p.mu.Unlock()
+
+	p.signal()
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.handlers[topic], id)
+	}
+}
+
+// Close stops the listener.
+func (p *Postgres) Close() error {
+	p.cancel()
+	<-p.done
+	return nil
+}
+
+func (p *Postgres) signal() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Postgres) topics() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	topics := make([]string, 0, len(p.handlers))
+	for t := range p.handlers {
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+func (p *Postgres) dispatch(topic string, payload []byte) {
+	p.mu.RLock()
+	hs := make([]Handler, 0, len(p.handlers[topic]))
+	for _, h := range p.handlers[topic] {
+		hs = append(hs, h)
+	}
+	p.mu.RUnlock()
+	for _, h := range hs {
+		h(payload)
+	}
+}
+
+func (p *Postgres) run(ctx context.Context) {
+	defer close(p.done)
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := p.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		slog.Warn("event bus listener stopped; reconnecting", "error", err, "backoff", backoff.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (p *Postgres) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	listening := map[string]bool{}
+	for {
+		for _, topic := range p.topics() {
+			if listening[topic] {
+				continue
+			}
+			if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{topic}.Sanitize()); err != nil {
+				return err
+			}
+			listening[topic] = true
+		}
+
+		// Wait for a notification, but wake up when a new topic is subscribed.
+		waitCtx, cancel := context.WithCancel(ctx)
+		var interrupted atomic.Bool
+		go func() {
+			select {
+			case <-p.changed:
+				interrupted.Store(true)
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+		n, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		if err != nil {
+			if interrupted.Load() && ctx.Err() == nil && !conn.IsClosed() {
+				continue
+			}
+			if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		p.dispatch(n.Channel, []byte(n.Payload))
+	}
+}
@@ -0,0 +1,82 @@
+package catalog
+
+import "strings"
+
+// Icons and Colours mirror ICON_MAP and COLOR_MAP in frontend/lib/constants.ts.
+// Anything stored on an entry must be one of these keys or the card won't render.
+var (
+	Icons   = []string{"briefcase", "idea", "heart", "book", "coffee", "music", "sun", "moon", "star", "zap"}
+	Colours = []string{"purple", "orange", "blue", "green", "pink", "yellow"}
+)
+
+const (
+	DefaultIcon   = "star"
+	DefaultColour = "purple"
+)
+
+// Common names people use for the same thing, e.g. the lucide icon name.
+var iconAliases = map[string]string{
+	"lightbulb": "idea",
+	"bulb":      "idea",
+	"work":      "briefcase",
+	"job":       "briefcase",
+	"love":      "heart",
+	"note":      "book",
+	"notes":     "book",
+	"tea":       "coffee",
+	"song":      "music",
+	"day":       "sun",
+	"night":     "moon",
+	"favorite":  "star",
+	"favourite": "star",
+	"bolt":      "zap",
+	"lightning": "zap",
+}
+
+var colourAliases = map[string]string{
+	"violet": "purple",
+	"red":    "orange",
+	"cyan":   "blue",
+	"teal":   "green",
+	"rose":   "pink",
+	"gold":   "yellow",
+	"amber":  "yellow",
+}
+
+// NormalizeIcon maps a free-form icon name onto the catalog.
+// An empty value yields the default icon.
+func NormalizeIcon(v string) (string, bool) {
+	return normalize(v, Icons, iconAliases, DefaultIcon)
+}
+
+// NormalizeColour maps a free-form colour name onto the catalog.
+// An empty value yields the default colour.
+func NormalizeColour(v string) (string, bool) {
+	return normalize(v, Colours, colourAliases, DefaultColour)
+}
+
+func IsIcon(v string) bool   { return contains(Icons, v) }
+func IsColour(v string) bool { return contains(Colours, v) }
+
+func normalize(v string, allowed []string, aliases map[string]string, def string) (string, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return def, true
+	}
+	if contains(allowed, v) {
+		return v, true
+	}
+	if alias, ok := aliases[v]; ok {
+		return alias, true
+	}
+	return "", false
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -1,98 +1,620 @@
+// Package config loads the server settings. Each setting comes from, in
+// increasing precedence: its default, the config file, the environment
+// (including a .env file) and command-line flags.
 package config
 
-
-
 import (
-
-    "os"
-
-    "strconv"
-
-
-
-    "github.com/joho/godotenv"
-
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
 )
 
+// FileEnv names the config file when no --config flag is given.
+const FileEnv = "CONFIG_FILE"
 
+// minSecretLen is the JWT secret length below which a warning is logged.
+const minSecretLen = 32
 
 type Config struct {
-
-    DBHost     string
-
-    DBPort     int
-
-    DBUser     string
-
-    DBPassword string
-
-    DBName     string
-
-    DBSSLMode  string
-
-    ServerPort int
-
+	Server      Server
+	Database    Database
+	Auth        Auth
+	API         API
+	Storage     Storage
+	Events      Events
+	Attachments Attachments
+	Exports     Exports
+	Metrics     Metrics
+	Tracing     Tracing
+	Log         Log
 }
 
+type Server struct {
+	Port      int
+	PublicURL string // where clients reach the API, e.g. https://api.example.com; used in links handed out
+
+	ReadTimeout       time.Duration // whole request, body included
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration // event streams are exempt
+	IdleTimeout       time.Duration // keep-alive connections
+	MaxHeaderBytes    int
+
+	// On SIGTERM /health reports "draining" for DrainDelay so load balancers
+	// stop sending traffic, then in-flight requests and jobs get up to
+	// ShutdownTimeout to finish.
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+}
 
+type Database struct {
+	URL      string // DATABASE_URL; takes the place of the other connection fields
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	ConnectTimeout time.Duration // how long to keep retrying at startup; 0 tries once
+	SlowQuery      time.Duration // queries slower than this are logged; 0 disables
+}
 
-func Load() *Config {
-
-    // Load .env file if it exists
-
-    _ = godotenv.Load()
+type Auth struct {
+	JWTSecret     string
+	AdminPassword string // lets the admin log in by name; seeds an admin if none exists
+}
 
+type API struct {
+	IdempotencyTTL    time.Duration // how long a stored response is replayed
+	LegacySunset      time.Time     // when the unversioned routes go away
+	OpenAPIValidation string        // off, requests, responses or all; empty picks by gin mode
+}
 
+// Storage is where attachment bytes are kept: a local directory or an
+// S3-compatible bucket.
+type Storage struct {
+	Backend string // local or s3
+	Dir     string // the local backend's directory; required with it
+	S3      S3
+}
 
-    return &Config{
+// S3 addresses objects path-style: {Endpoint}/{Bucket}/{key}.
+type S3 struct {
+	Endpoint  string
+	Region    string // empty means us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
 
-        DBHost:     os.Getenv("DB_HOST"),
+// Events picks how live entry events reach the other replicas.
+type Events struct {
+	Bus string // memory for a single instance, or postgres (LISTEN/NOTIFY)
+}
 
-        DBPort:     getInt("DB_PORT", 5432),
+type Attachments struct {
+	MaxBytes      int64    // per file
+	AllowedTypes  []string // MIME types; ones ending in "/" are prefixes, e.g. audio/
+	StripMetadata bool     // remove EXIF/GPS from photos unless the upload asks to keep it
+}
 
-        DBUser:     os.Getenv("DB_USER"),
+type Exports struct {
+	Dir     string        // where finished archives wait to be downloaded; required
+	LinkTTL time.Duration // how long a download link stays valid
+}
 
-        DBPassword: os.Getenv("DB_PASSWORD"),
+// Metrics is where /metrics is served. With Addr it gets its own listener,
+// e.g. 127.0.0.1:9090, that the public port doesn't expose; otherwise it is
+// on the API port and needs Token. With neither it is off.
+type Metrics struct {
+	Addr  string
+	Token string
+}
 
-        DBName:     os.Getenv("DB_NAME"),
+type Log struct {
+	Level  slog.Level
+	Format string // json, or text for reading in a terminal
+}
 
-        DBSSLMode:  getString("DB_SSLMODE", "disable"),
+// Tracing configures OpenTelemetry. The variables are the standard OTEL_*
+// ones.
+type Tracing struct {
+	Exporter    string  // none, otlp, or stdout for local debugging
+	Endpoint    string  // OTLP/HTTP collector URL, e.g. http://localhost:4318
+	SampleRatio float64 // share of new traces recorded; callers' decisions are kept
+	ServiceName string
+}
 
-        ServerPort: getInt("SERVER_PORT", 8080),
+// LegacyDeprecatedAt is when the unversioned /user and /admin routes were
+// deprecated in favour of /api/v1.
+var LegacyDeprecatedAt = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+// Defaults is the configuration before any file, variable or flag.
+func Defaults() *Config {
+	return &Config{
+		Server: Server{
+			Port:              8080,
+			ReadTimeout:       time.Minute,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: Database{
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    100,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+			ConnectTimeout:  time.Minute,
+			SlowQuery:       200 * time.Millisecond,
+		},
+		API: API{
+			IdempotencyTTL: 24 * time.Hour,
+			LegacySunset:   LegacyDeprecatedAt.AddDate(0, 6, 0),
+		},
+		Storage: Storage{
+			Backend: "local",
+		},
+		Events: Events{
+			Bus: "memory",
+		},
+		Attachments: Attachments{
+			MaxBytes:     20 << 20,
+			AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/heic", "image/heif", "audio/", "video/webm"},
+		},
+		Exports: Exports{
+			LinkTTL: 24 * time.Hour,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+			ServiceName: "reminder-api",
+		},
+		Log: Log{
+			Level:  slog.LevelInfo,
+			Format: "json",
+		},
+	}
+}
 
-    }
+// setting is one configurable value: its key in the config file and for
+// Set, and the variables it is read from, highest precedence first.
+type setting struct {
+	key string
+	env []string
+	set func(c *Config, v string) error
+}
 
+var settings = []setting{
+	// PORT is what Render sets. Server_Port is the old spelling
+	{"server.port", []string{"PORT", "SERVER_PORT", "Server_Port"}, func(c *Config, v string) error { return parsePort(&c.Server.Port, v) }},
+	{"server.public_url", []string{"PUBLIC_URL"}, func(c *Config, v string) error { return parseBaseURL(&c.Server.PublicURL, v) }},
+	{"server.read_timeout", []string{"HTTP_READ_TIMEOUT"}, func(c *Config, v string) error { return parseDuration(&c.Server.ReadTimeout, v) }},
+	{"server.read_header_timeout", []string{"HTTP_READ_HEADER_TIMEOUT"}, func(c *Config, v string) error { return parseDuration(&c.Server.ReadHeaderTimeout, v) }},
+	{"server.write_timeout", []string{"HTTP_WRITE_TIMEOUT"}, func(c *Config, v string) error { return parseDuration(&c.Server.WriteTimeout, v) }},
+	{"server.idle_timeout", []string{"HTTP_IDLE_TIMEOUT"}, func(c *Config, v string) error { return parseDuration(&c.Server.IdleTimeout, v) }},
+	{"server.max_header_bytes", []string{"HTTP_MAX_HEADER_BYTES"}, func(c *Config, v string) error { return parseCount(&c.Server.MaxHeaderBytes, v) }},
+	{"server.drain_delay", []string{"SHUTDOWN_DRAIN_DELAY"}, func(c *Config, v string) error { return parseDelay(&c.Server.DrainDelay, v) }},
+	{"server.shutdown_timeout", []string{"SHUTDOWN_TIMEOUT"}, func(c *Config, v string) error { return parseDuration(&c.Server.ShutdownTimeout, v) }},
+
+	{"database.url", []string{"DATABASE_URL"}, func(c *Config, v string) error { c.Database.URL = v; return nil }},
+	{"database.host", []string{"DB_HOST"}, func(c *Config, v string) error { c.Database.Host = v; return nil }},
+	{"database.port", []string{"DB_PORT"}, func(c *Config, v string) error { return parsePort(&c.Database.Port, v) }},
+	{"database.user", []string{"DB_USER"}, func(c *Config, v string) error { c.Database.User = v; return nil }},
+	{"database.password", []string{"DB_PASSWORD"}, func(c *Config, v string) error { c.Database.Password = v; return nil }},
+	{"database.name", []string{"DB_NAME"}, func(c *Config, v string) error { c.Database.Name = v; return nil }},
+	{"database.sslmode", []string{"DB_SSLMODE"}, func(c *Config, v string) error { c.Database.SSLMode = v; return nil }},
+	{"database.max_open_conns", []string{"DB_MAX_OPEN_CONNS"}, func(c *Config, v string) error { return parseCount(&c.Database.MaxOpenConns, v) }},
+	{"database.max_idle_conns", []string{"DB_MAX_IDLE_CONNS"}, func(c *Config, v string) error { return parseCount(&c.Database.MaxIdleConns, v) }},
+	{"database.conn_max_lifetime", []string{"DB_CONN_MAX_LIFETIME"}, func(c *Config, v string) error { return parseDuration(&c.Database.ConnMaxLifetime, v) }},
+	{"database.connect_timeout", []string{"DB_CONNECT_TIMEOUT"}, func(c *Config, v string) error { return parseDelay(&c.Database.ConnectTimeout, v) }},
+	{"database.slow_query", []string{"DB_SLOW_QUERY"}, func(c *Config, v string) error { return parseDelay(&c.Database.SlowQuery, v) }},
+
+	{"auth.jwt_secret", []string{"JWT_SECRET"}, func(c *Config, v string) error { c.Auth.JWTSecret = v; return nil }},
+	{"auth.admin_password", []string{"ADMIN_PASSWORD"}, func(c *Config, v string) error { c.Auth.AdminPassword = v; return nil }},
+
+	{"log.level", []string{"LOG_LEVEL"}, func(c *Config, v string) error { return c.Log.Level.UnmarshalText([]byte(v)) }},
+	{"log.format", []string{"LOG_FORMAT"}, func(c *Config, v string) error {
+		switch v = strings.ToLower(v); v {
+		case "json", "text":
+			c.Log.Format = v
+			return nil
+		}
+		return fmt.Errorf("%q is not one of json or text", v)
+	}},
+
+	{"metrics.addr", []string{"METRICS_ADDR"}, func(c *Config, v string) error { c.Metrics.Addr = v; return nil }},
+	{"metrics.token", []string{"METRICS_TOKEN"}, func(c *Config, v string) error { c.Metrics.Token = v; return nil }},
+
+	{"tracing.exporter", []string{"OTEL_TRACES_EXPORTER"}, func(c *Config, v string) error {
+		switch v = strings.ToLower(v); v {
+		case "console": // the spec's name for it
+			v = "stdout"
+			fallthrough
+		case "none", "otlp", "stdout":
+			c.Tracing.Exporter = v
+			return nil
+		}
+		return fmt.Errorf("%q is not one of none, otlp or stdout", v)
+	}},
+	{"tracing.endpoint", []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"}, func(c *Config, v string) error { c.Tracing.Endpoint = v; return nil }},
+	{"tracing.sample_ratio", []string{"OTEL_TRACES_SAMPLER_ARG"}, func(c *Config, v string) error {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return fmt.Errorf("%q is not a ratio between 0 and 1", v)
+		}
+		c.Tracing.SampleRatio = r
+		return nil
+	}},
+	{"tracing.service_name", []string{"OTEL_SERVICE_NAME"}, func(c *Config, v string) error { c.Tracing.ServiceName = v; return nil }},
+
+	{"api.idempotency_ttl", []string{"IDEMPOTENCY_TTL"}, func(c *Config, v string) error { return parseDuration(&c.API.IdempotencyTTL, v) }},
+	{"api.legacy_sunset", []string{"LEGACY_API_SUNSET"}, func(c *Config, v string) error { return parseTime(&c.API.LegacySunset, v) }},
+	{"api.openapi_validation", []string{"OPENAPI_VALIDATION"}, func(c *Config, v string) error {
+		switch v = strings.ToLower(v); v {
+		case "", "off", "requests", "responses", "all":
+			c.API.OpenAPIValidation = v
+			return nil
+		}
+		return fmt.Errorf("%q is not one of off, requests, responses or all", v)
+	}},
+
+	{"storage.backend", []string{"BLOB_BACKEND"}, func(c *Config, v string) error {
+		switch v = strings.ToLower(v); v {
+		case "local", "s3":
+			c.Storage.Backend = v
+			return nil
+		}
+		return fmt.Errorf("%q is not one of local or s3", v)
+	}},
+	{"storage.dir", []string{"BLOB_DIR"}, func(c *Config, v string) error { c.Storage.Dir = v; return nil }},
+	{"storage.s3.endpoint", []string{"S3_ENDPOINT"}, func(c *Config, v string) error { c.Storage.S3.Endpoint = v; return nil }},
+	{"storage.s3.region", []string{"S3_REGION"}, func(c *Config, v string) error { c.Storage.S3.Region = v; return nil }},
+	{"storage.s3.bucket", []string{"S3_BUCKET"}, func(c *Config, v string) error { c.Storage.S3.Bucket = v; return nil }},
+	{"storage.s3.access_key", []string{"S3_ACCESS_KEY"}, func(c *Config, v string) error { c.Storage.S3.AccessKey = v; return nil }},
+	{"storage.s3.secret_key", []string{"S3_SECRET_KEY"}, func(c *Config, v string) error { c.Storage.S3.SecretKey = v; return nil }},
+
+	{"events.bus", []string{"EVENT_BUS"}, func(c *Config, v string) error {
+		switch v = strings.ToLower(v); v {
+		case "memory", "postgres":
+			c.Events.Bus = v
+			return nil
+		}
+		return fmt.Errorf("%q is not one of memory or postgres", v)
+	}},
+
+	{"attachments.max_bytes", []string{"ATTACHMENT_MAX_BYTES"}, func(c *Config, v string) error { return parseSize(&c.Attachments.MaxBytes, v) }},
+	{"attachments.allowed_types", []string{"ATTACHMENT_ALLOWED_TYPES"}, func(c *Config, v string) error { return parseList(&c.Attachments.AllowedTypes, v) }},
+	{"attachments.strip_metadata", []string{"ATTACHMENT_STRIP_METADATA"}, func(c *Config, v string) error { return parseBool(&c.Attachments.StripMetadata, v) }},
+
+	{"exports.dir", []string{"EXPORT_DIR"}, func(c *Config, v string) error { c.Exports.Dir = v; return nil }},
+	{"exports.link_ttl", []string{"EXPORT_LINK_TTL"}, func(c *Config, v string) error { return parseDuration(&c.Exports.LinkTTL, v) }},
 }
 
+// Load reads the .env file, then the config file at path (or $CONFIG_FILE;
+// none if both are empty), then the environment. It does not validate the
+// result; see Validate.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(); err == nil {
+		slog.Info("loaded environment from .env")
+	}
+	c := Defaults()
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
+// Set overrides one setting by its file key, e.g. "server.port". Flags use it.
+func (c *Config) Set(key, value string) error {
+	for _, s := range settings {
+		if s.key == key {
+			if err := s.set(c, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %q", key)
+}
 
-func getString(key, defaultValue string) string {
+// loadFile applies a YAML or TOML file, picked by extension. Its sections
+// and keys match the keys in settings:
+//
+//	server:
+//	  port: 8080
+//	database:
+//	  url: postgres://reminder@localhost/reminder_db
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	tree := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return fmt.Errorf("config file %s: unknown format %q; use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", tree, values); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := c.Set(k, values[k]); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	return nil
+}
 
-    if value := os.Getenv(key); value != "" {
+// flatten turns nested sections into dotted keys with string values.
+func flatten(prefix string, tree map[string]any, out map[string]string) error {
+	for k, v := range tree {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		case nil:
+		case time.Time:
+			out[key] = v.Format(time.RFC3339)
+		case []any:
+			// Lists of plain values become the comma-separated form the
+			// environment uses
+			items := make([]string, len(v))
+			for i, item := range v {
+				switch item.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: only lists of plain values are supported", key)
+				}
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
 
-        return value
+// loadEnv applies the variables that are set and non-empty.
+func (c *Config) loadEnv(lookup func(string) (string, bool)) error {
+	for _, s := range settings {
+		name, value := "", ""
+		for _, env := range s.env {
+			v, ok := lookup(env)
+			if !ok || v == "" {
+				continue
+			}
+			if name == "" {
+				name, value = env, v
+			} else if v != value {
+				slog.Warn("config variable ignored", "variable", env, "overridden_by", name)
+			}
+		}
+		if name == "" {
+			continue
+		}
+		if name == "Server_Port" {
+			slog.Warn("config variable Server_Port is deprecated; use PORT")
+		}
+		if err := s.set(c, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
 
-    }
+// Validate checks everything the API server needs. Maintenance commands
+// that only touch the database can check Database alone.
+func (c *Config) Validate() error {
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		return fmt.Errorf("server port %d is out of range", c.Server.Port)
+	}
+	if err := c.Database.Validate(); err != nil {
+		return err
+	}
+	if c.Server.PublicURL == "" {
+		slog.Warn("PUBLIC_URL is not set; links such as calendar feeds point at localhost", "base_url", c.Server.BaseURL())
+	}
+	if err := c.Auth.Validate(); err != nil {
+		return err
+	}
+	// Attachments and exports outlive a restart, so they have no default
+	// under the temp directory, which the OS is free to clean.
+	if c.Storage.Backend == "local" && c.Storage.Dir == "" {
+		return errors.New("BLOB_DIR is required with the local blob backend; use a persistent directory")
+	}
+	if c.Exports.Dir == "" {
+		return errors.New("EXPORT_DIR is required; use a persistent directory")
+	}
+	return nil
+}
 
-    return defaultValue
+// BaseURL is PublicURL, or the local address of the server without it.
+func (s Server) BaseURL() string {
+	if s.PublicURL != "" {
+		return s.PublicURL
+	}
+	return fmt.Sprintf("http://localhost:%d", s.Port)
+}
 
+// Validate checks that the database can be reached with these settings.
+func (d Database) Validate() error {
+	if d.URL != "" {
+		if !strings.HasPrefix(d.URL, "postgres://") && !strings.HasPrefix(d.URL, "postgresql://") {
+			return errors.New("DATABASE_URL must start with postgres:// or postgresql://")
+		}
+		return nil
+	}
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"DB_HOST", d.Host}, {"DB_USER", d.User}, {"DB_PASSWORD", d.Password}, {"DB_NAME", d.Name},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("database configuration is incomplete: set DATABASE_URL or %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
+// DSN is the connection string for the postgres driver.
+func (d Database) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode,
+	)
+}
 
+// Validate refuses to sign tokens with an empty key, and warns about a
+// short one.
+func (a Auth) Validate() error {
+	if a.JWTSecret == "" {
+		return errors.New("JWT_SECRET is required")
+	}
+	if len(a.JWTSecret) < minSecretLen {
+		slog.Warn("JWT_SECRET is short; use a longer random value", "min_bytes", minSecretLen)
+	}
+	return nil
+}
 
-func getInt(key string, defaultValue int) int {
+func parsePort(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%q is not a port number", v)
+	}
+	*dst = n
+	return nil
+}
 
-    if value := os.Getenv(key); value != "" {
+// parseBaseURL accepts an absolute http(s) URL and drops a trailing slash.
+func parseBaseURL(dst *string, v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q is not an http or https URL such as https://api.example.com", v)
+	}
+	*dst = strings.TrimSuffix(v, "/")
+	return nil
+}
 
-        if intVal, err := strconv.Atoi(value); err == nil {
+func parseCount(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q is not a non-negative number", v)
+	}
+	*dst = n
+	return nil
+}
 
-            return intVal
+// parseSize accepts a positive number of bytes.
+func parseSize(dst *int64, v string) error {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 1 {
+		return fmt.Errorf("%q is not a positive number of bytes", v)
+	}
+	*dst = n
+	return nil
+}
 
-        }
+// parseList splits a comma-separated list, dropping empty items.
+func parseList(dst *[]string, v string) error {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%q is not a comma-separated list", v)
+	}
+	*dst = items
+	return nil
+}
 
-    }
+func parseBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", v)
+	}
+	*dst = b
+	return nil
+}
 
-    return defaultValue
+func parseDuration(dst *time.Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("%q is not a positive duration such as 24h", v)
+	}
+	*dst = d
+	return nil
+}
 
+// parseDelay is parseDuration that also accepts 0.
+func parseDelay(dst *time.Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return fmt.Errorf("%q is not a duration such as 5s", v)
+	}
+	*dst = d
+	return nil
 }
 
+// parseTime accepts a date or an RFC 3339 time.
+func parseTime(dst *time.Time, v string) error {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			*dst = t
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a date (2006-01-02) or RFC 3339 time", v)
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "server.yaml")
+	os.WriteFile(yamlFile, []byte(`
+server:
+  port: 9000
+database:
+  host: db.internal
+  max_open_conns: 20
+api:
+  idempotency_ttl: 2h
+  legacy_sunset: 2027-01-31
+storage:
+  backend: s3
+  s3:
+    bucket: attachments
+attachments:
+  allowed_types: [image/png, audio/]
+`), 0o600)
+
+	c := Defaults()
+	if err := c.loadFile(yamlFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.loadEnv(env(map[string]string{"PORT": "9100", "SERVER_PORT": "9200", "DB_USER": "app", "PUBLIC_URL": "https://api.example.com/", "S3_BUCKET": "from-env", "EXPORT_LINK_TTL": "1h"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("database.host", "flag.internal"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Server.BaseURL() != "https://api.example.com" {
+		t.Errorf("base URL = %q", c.Server.BaseURL())
+	}
+	if c.Server.Port != 9100 {
+		t.Errorf("port = %d, want PORT to win over SERVER_PORT and the file", c.Server.Port)
+	}
+	if c.Database.Host != "flag.internal" || c.Database.User != "app" || c.Database.MaxOpenConns != 20 {
+		t.Errorf("database = %+v", c.Database)
+	}
+	if c.Database.Port != 5432 || c.Database.MaxIdleConns != 10 {
+		t.Errorf("defaults lost: %+v", c.Database)
+	}
+	if c.API.IdempotencyTTL != 2*time.Hour || !c.API.LegacySunset.Equal(time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("api = %+v", c.API)
+	}
+	if c.Storage.Backend != "s3" || c.Storage.S3.Bucket != "from-env" || c.Exports.LinkTTL != time.Hour {
+		t.Errorf("storage = %+v, exports = %+v", c.Storage, c.Exports)
+	}
+	if got := strings.Join(c.Attachments.AllowedTypes, ","); got != "image/png,audio/" || c.Attachments.MaxBytes != 20<<20 {
+		t.Errorf("attachments = %+v", c.Attachments)
+	}
+
+	tomlFile := filepath.Join(dir, "server.toml")
+	os.WriteFile(tomlFile, []byte("[auth]\njwt_secret = \"from-toml\"\n\n[database]\nurl = \"postgres://app@db/reminder\"\n"), 0o600)
+	c = Defaults()
+	if err := c.loadFile(tomlFile); err != nil {
+		t.Fatal(err)
+	}
+	if c.Auth.JWTSecret != "from-toml" || c.Database.DSN() != "postgres://app@db/reminder" {
+		t.Errorf("toml = %+v", c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		os.WriteFile(p, []byte(body), 0o600)
+		return p
+	}
+	for path, want := range map[string]string{
+		write("typo.yaml", "server:\n  prot: 80\n"):          `unknown setting "server.prot"`,
+		write("bad.yaml", "api:\n  idempotency_ttl: soon\n"): "api.idempotency_ttl",
+		write("server.json", "{}"):                           "unknown format",
+	} {
+		if err := Defaults().loadFile(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("loadFile(%s) = %v, want %q", filepath.Base(path), err, want)
+		}
+	}
+	if err := Defaults().loadEnv(env(map[string]string{"OPENAPI_VALIDATION": "sometimes"})); err == nil || !strings.Contains(err.Error(), "OPENAPI_VALIDATION") {
+		t.Errorf("bad OPENAPI_VALIDATION: %v", err)
+	}
+	if err := Defaults().loadEnv(env(map[string]string{"EVENT_BUS": "redis"})); err == nil || !strings.Contains(err.Error(), "EVENT_BUS") {
+		t.Errorf("bad EVENT_BUS: %v", err)
+	}
+	if err := Defaults().loadEnv(env(map[string]string{"ATTACHMENT_MAX_BYTES": "20MB"})); err == nil || !strings.Contains(err.Error(), "ATTACHMENT_MAX_BYTES") {
+		t.Errorf("bad ATTACHMENT_MAX_BYTES: %v", err)
+	}
+	if err := Defaults().loadEnv(env(map[string]string{"PUBLIC_URL": "api.example.com"})); err == nil || !strings.Contains(err.Error(), "PUBLIC_URL") {
+		t.Errorf("PUBLIC_URL without a scheme: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Defaults()
+	c.Database.URL = "postgres://app@db/reminder"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+		t.Errorf("empty JWT secret: %v", err)
+	}
+	c.Auth.JWTSecret = strings.Repeat("s", minSecretLen)
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "BLOB_DIR") {
+		t.Errorf("local blobs without BLOB_DIR: %v", err)
+	}
+	c.Storage.Dir = "/var/lib/reminder/blobs"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "EXPORT_DIR") {
+		t.Errorf("no EXPORT_DIR: %v", err)
+	}
+	c.Exports.Dir = "/var/lib/reminder/exports"
+	if err := c.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+
+	d := Defaults().Database
+	d.Host, d.Name = "db", "reminder"
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "DB_USER, DB_PASSWORD") {
+		t.Errorf("incomplete database: %v", err)
+	}
+	d.User, d.Password = "app", "pw"
+	if got, want := d.DSN(), "host=db user=app password=pw dbname=reminder port=5432 sslmode=disable"; got != want {
+		t.Errorf("DSN = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"Base/internal/config"
+
+	"gorm.io/gorm"
+)
+
+const (
+	firstRetry = 500 * time.Millisecond
+	maxRetry   = 10 * time.Second
+)
+
+// Connect is DBConnect retried with exponential backoff for up to
+// cfg.ConnectTimeout, so the server can start alongside a database that is
+// still booting. An incomplete configuration fails straight away.
+func Connect(ctx context.Context, cfg config.Database) (*gorm.DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return retry(ctx, cfg.ConnectTimeout, func() (*gorm.DB, error) { return DBConnect(cfg) })
+}
+
+func retry(ctx context.Context, maxWait time.Duration, open func() (*gorm.DB, error)) (*gorm.DB, error) {
+	deadline := time.Now().Add(maxWait)
+	wait := firstRetry
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		// Full jitter keeps replicas from retrying in lockstep
+		sleep := wait/2 + rand.N(wait/2+1)
+		if time.Now().Add(sleep).After(deadline) {
+			return nil, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("database not ready; retrying", "attempt", attempt, "error", err, "retry_in", sleep.Round(time.Millisecond).String())
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-time.After(sleep):
+		}
+		wait = min(wait*2, maxRetry)
+	}
+}
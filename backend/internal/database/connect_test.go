@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestRetryBacksOffUntilTheDatabaseAnswers(t *testing.T) {
+	calls := 0
+	var at []time.Time
+	db, err := retry(context.Background(), time.Minute, func() (*gorm.DB, error) {
+		calls++
+		at = append(at, time.Now())
+		if calls < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &gorm.DB{}, nil
+	})
+	if err != nil || db == nil || calls != 3 {
+		t.Fatalf("retry = %v, %v after %d calls", db, err, calls)
+	}
+	if gap := at[2].Sub(at[1]); gap < firstRetry {
+		t.Errorf("second retry after %s, want at least %s", gap, firstRetry)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	refused := errors.New("connection refused")
+	open := func() (*gorm.DB, error) { return nil, refused }
+
+	if _, err := retry(context.Background(), 0, open); !errors.Is(err, refused) || !strings.Contains(err.Error(), "1 attempts") {
+		t.Errorf("no wait allowed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := retry(ctx, time.Minute, open); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %v", err)
+	}
+}
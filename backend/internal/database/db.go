@@ -2,40 +2,23 @@ package db
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"time"
+	"log/slog"
+
+	"Base/internal/config"
+	"Base/internal/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 
-func DBConnect() (*gorm.DB, error) {
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	port := os.Getenv("DB_PORT")
-	sslmode := os.Getenv("DB_SSLMODE")
-
-	if host == "" || user == "" || password == "" || dbname == "" {
-		return nil, fmt.Errorf("database configuration is incomplete")
-	}
-
-	if port == "" {
-		port = "5432"
-	}
-	if sslmode == "" {
-		sslmode = "disable"
+// DBConnect opens the database described by cfg and checks that it answers.
+func DBConnect(cfg config.Database) (*gorm.DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		host, user, password, dbname, port, sslmode,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{Logger: logging.NewGORM(cfg.SlowQuery)})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect database: %v", err)
 	}
@@ -47,15 +30,15 @@ func DBConnect() (*gorm.DB, error) {
 	}
 
 	// Set connection pool settings (important!)
-	sqlDB.SetMaxIdleConns(10)           // Maximum idle connections
-	sqlDB.SetMaxOpenConns(100)          // Maximum open connections
-	sqlDB.SetConnMaxLifetime(time.Hour) // Maximum connection lifetime
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	// Test the connection
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	log.Println("Database connection established")
+	slog.Info("database connection established")
 	return db, nil
 }
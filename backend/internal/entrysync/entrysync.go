@@ -0,0 +1,287 @@
+// Package entrysync implements the offline sync protocol for entries. A
+// client sends the changes it made while offline together with the cursor
+// from its previous sync, and gets back everything that changed on the
+// server since then, including tombstones for deleted entries.
+//
+// Concurrent edits are resolved last-writer-wins per entry: when the server
+// copy changed after the version the client edited, every field both sides
+// set to different values is reported as a conflict and the side with the
+// later modification time wins.
+package entrysync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/models"
+)
+
+// Outcome statuses
+const (
+	Applied   = "applied"
+	Conflict  = "conflict"
+	Rejected  = "rejected"
+	Unchanged = "unchanged"
+)
+
+// Conflict winners
+const (
+	ClientWins = "client"
+	ServerWins = "server"
+)
+
+// Change is one offline modification made by the client.
+type Change struct {
+	// ClientID identifies the entry on the device; it is required for
+	// entries created offline (ID 0) so that retries don't duplicate them.
+	ClientID string `json:"client_id,omitempty"`
+	ID       uint   `json:"id,omitempty"`
+
+	// BaseUpdatedAt is the server UpdatedAt of the copy the client edited.
+	BaseUpdatedAt *time.Time `json:"base_updated_at,omitempty"`
+	// UpdatedAt is when the change was made on the device.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Deleted bool                       `json:"deleted,omitempty"`
+	Fields  map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+// FieldConflict is a field that was changed differently on both sides.
+type FieldConflict struct {
+	Field  string          `json:"field"`
+	Client json.RawMessage `json:"client"`
+	Server json.RawMessage `json:"server"`
+}
+
+// Outcome reports what happened to one Change.
+type Outcome struct {
+	ClientID   string              `json:"client_id,omitempty"`
+	ID         uint                `json:"id,omitempty"`
+	Status     string              `json:"status"`
+	Resolution string              `json:"resolution,omitempty"` // winner, for conflicts
+	Conflicts  []FieldConflict     `json:"conflicts,omitempty"`
+	Error      *apierror.ItemError `json:"error,omitempty"`
+	UpdatedAt  *time.Time          `json:"updated_at,omitempty"`
+}
+
+type field struct {
+	get func(*models.Entry) interface{}
+	set func(*models.Entry, json.RawMessage) error
+}
+
+func stringField(ptr func(*models.Entry) *string) field {
+	return field{
+		get: func(e *models.Entry) interface{} { return *ptr(e) },
+		set: func(e *models.Entry, raw json.RawMessage) error {
+			var s *string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			*ptr(e) = ""
+			if s != nil {
+				*ptr(e) = *s
+			}
+			return nil
+		},
+	}
+}
+
+// fields are the entry fields a client may change, by JSON name.
+var fields = map[string]field{
+	"situation":  stringField(func(e *models.Entry) *string { return &e.Situation }),
+	"text":       stringField(func(e *models.Entry) *string { return &e.Text }),
+	"icon":       stringField(func(e *models.Entry) *string { return &e.Icon }),
+	"colour":     stringField(func(e *models.Entry) *string { return &e.Colour }),
+	"recurrence": stringField(func(e *models.Entry) *string { return &e.Recurrence }),
+	"tags": {
+		get: func(e *models.Entry) interface{} { return e.Tags },
+		set: func(e *models.Entry, raw json.RawMessage) error {
+			// Accept both the stored string form and a list of tags.
+			var list []string
+			if err := json.Unmarshal(raw, &list); err == nil {
+				e.Tags = models.JoinTags(list)
+				return nil
+			}
+			var s *string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			e.Tags = ""
+			if s != nil {
+				e.Tags = models.JoinTags(strings.Fields(*s))
+			}
+			return nil
+		},
+	},
+	"remind_at": {
+		get: func(e *models.Entry) interface{} {
+			if e.RemindAt == nil {
+				return nil
+			}
+			return e.RemindAt.UTC().Truncate(time.Microsecond)
+		},
+		set: func(e *models.Entry, raw json.RawMessage) error {
+			var t *time.Time
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return err
+			}
+			e.RemindAt = t
+			return nil
+		},
+	},
+}
+
+// Apply sets the given fields on e, rejecting unknown fields and values of
+// the wrong type with a validation_failed error naming the field.
+func Apply(e *models.Entry, patch map[string]json.RawMessage) error {
+	for _, name := range sortedKeys(patch) {
+		f, ok := fields[name]
+		if !ok {
+			return apierror.New(apierror.ValidationFailed).Localized("detail.unknown_field", "field", name)
+		}
+		if err := f.set(e, patch[name]); err != nil {
+			return apierror.New(apierror.ValidationFailed).Localized("detail.invalid_field", "field", name).Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Result is the outcome of merging one Change into the server copy.
+type Result struct {
+	Outcome Outcome
+	Entry   models.Entry // the merged entry to save, if Save is set
+	Save    bool
+	Delete  bool
+}
+
+// ErrNoEntry is returned by Merge for an update of an entry that does not exist.
+var ErrNoEntry = errors.New("entry not found")
+
+// Merge resolves a client change against the current server copy of the
+// entry, which may be soft-deleted. now caps client timestamps so that a
+// device with a fast clock cannot win every conflict.
+func Merge(server models.Entry, c Change, now time.Time) (Result, error) {
+	res := Result{Outcome: Outcome{ClientID: c.ClientID, ID: server.ID, Status: Unchanged}}
+
+	clientTime := c.UpdatedAt
+	if clientTime.IsZero() || clientTime.After(now) {
+		clientTime = now
+	}
+	// Postgres keeps microseconds, so compare at that precision.
+	serverTime := server.UpdatedAt.Truncate(time.Microsecond)
+	stale := c.BaseUpdatedAt == nil || serverTime.After(c.BaseUpdatedAt.Truncate(time.Microsecond))
+
+	if server.DeletedAt.Valid {
+		// Deletion is final: edits to a tombstone are conflicts the server wins.
+		if !c.Deleted {
+			res.Outcome.Status = Conflict
+			res.Outcome.Resolution = ServerWins
+			res.Outcome.Conflicts = []FieldConflict{{Field: "deleted", Client: raw(false), Server: raw(true)}}
+		}
+		return res, nil
+	}
+
+	if c.Deleted {
+		if stale && server.UpdatedAt.After(clientTime) {
+			res.Outcome.Status = Conflict
+			res.Outcome.Resolution = ServerWins
+			res.Outcome.Conflicts = []FieldConflict{{Field: "deleted", Client: raw(true), Server: raw(false)}}
+			return res, nil
+		}
+		res.Outcome.Status = Applied
+		res.Delete = true
+		return res, nil
+	}
+
+	proposed := server
+	if err := Apply(&proposed, c.Fields); err != nil {
+		return res, err
+	}
+
+	var changed, conflicting []string
+	for _, name := range sortedKeys(c.Fields) {
+		f := fields[name]
+		if equal(f.get(&proposed), f.get(&server)) {
+			continue
+		}
+		changed = append(changed, name)
+		if stale {
+			conflicting = append(conflicting, name)
+		}
+	}
+	if len(changed) == 0 {
+		return res, nil
+	}
+
+	res.Entry = proposed
+	res.Save = true
+	res.Outcome.Status = Applied
+	if len(conflicting) == 0 {
+		return res, nil
+	}
+
+	res.Outcome.Status = Conflict
+	res.Outcome.Resolution = ClientWins
+	for _, name := range conflicting {
+		f := fields[name]
+		res.Outcome.Conflicts = append(res.Outcome.Conflicts, FieldConflict{
+			Field:  name,
+			Client: raw(f.get(&proposed)),
+			Server: raw(f.get(&server)),
+		})
+	}
+	if !clientTime.After(server.UpdatedAt) {
+		// The server copy is newer: keep it and let the client catch up.
+		res.Outcome.Resolution = ServerWins
+		res.Entry = server
+		res.Save = false
+	}
+	return res, nil
+}
+
+func equal(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return a == b
+}
+
+func raw(v interface{}) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Cursors are opaque to clients; they encode a server timestamp.
+
+// EncodeCursor returns the cursor for changes after t.
+func EncodeCursor(t time.Time) string {
+	return strconv.FormatInt(t.UnixMicro(), 36)
+}
+
+// DecodeCursor parses a cursor. The empty cursor means "from the beginning".
+func DecodeCursor(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	n, err := strconv.ParseInt(s, 36, 64)
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid cursor")
+	}
+	return time.UnixMicro(n), nil
+}
@@ -0,0 +1,124 @@
+package entrysync
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"Base/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func serverEntry(updated time.Time) models.Entry {
+	return models.Entry{
+		Model:     gorm.Model{ID: 7, UpdatedAt: updated},
+		Situation: "Standup",
+		Text:      "Keep it short",
+		Icon:      "star",
+		Colour:    "purple",
+		Tags:      "work",
+	}
+}
+
+func fieldsOf(t *testing.T, v map[string]interface{}) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(v))
+	for k, x := range v {
+		b, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[k] = b
+	}
+	return out
+}
+
+func TestMergeFastForward(t *testing.T) {
+	base := t0
+	res, err := Merge(serverEntry(t0), Change{
+		ID:            7,
+		BaseUpdatedAt: &base,
+		UpdatedAt:     t0.Add(time.Minute),
+		Fields:        fieldsOf(t, map[string]interface{}{"text": "Two minutes each", "tags": []string{"work", "daily"}}),
+	}, t0.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Outcome.Status != Applied || !res.Save || len(res.Outcome.Conflicts) != 0 {
+		t.Fatalf("unexpected result %+v", res.Outcome)
+	}
+	if res.Entry.Text != "Two minutes each" || res.Entry.Tags != "work daily" || res.Entry.Situation != "Standup" {
+		t.Errorf("merged entry = %+v", res.Entry)
+	}
+}
+
+func TestMergeConflictLastWriterWins(t *testing.T) {
+	base := t0
+	server := serverEntry(t0.Add(10 * time.Minute)) // edited elsewhere after the client's copy
+	change := Change{
+		ID:            7,
+		BaseUpdatedAt: &base,
+		Fields:        fieldsOf(t, map[string]interface{}{"text": "From the phone", "icon": "star"}),
+	}
+
+	change.UpdatedAt = t0.Add(20 * time.Minute)
+	res, _ := Merge(server, change, t0.Add(time.Hour))
+	if res.Outcome.Status != Conflict || res.Outcome.Resolution != ClientWins || !res.Save {
+		t.Fatalf("newer client edit should win: %+v", res.Outcome)
+	}
+	if len(res.Outcome.Conflicts) != 1 || res.Outcome.Conflicts[0].Field != "text" {
+		t.Errorf("only fields that differ are conflicts, got %+v", res.Outcome.Conflicts)
+	}
+	if string(res.Outcome.Conflicts[0].Server) != `"Keep it short"` {
+		t.Errorf("server value = %s", res.Outcome.Conflicts[0].Server)
+	}
+
+	change.UpdatedAt = t0.Add(5 * time.Minute)
+	res, _ = Merge(server, change, t0.Add(time.Hour))
+	if res.Outcome.Resolution != ServerWins || res.Save {
+		t.Errorf("older client edit should lose: %+v", res.Outcome)
+	}
+
+	// A clock in the future is capped to the server's time.
+	change.UpdatedAt = t0.Add(48 * time.Hour)
+	res, _ = Merge(server, change, t0.Add(5*time.Minute))
+	if res.Outcome.Resolution != ServerWins {
+		t.Errorf("future client timestamps must not win: %+v", res.Outcome)
+	}
+}
+
+func TestMergeDeletes(t *testing.T) {
+	base := t0
+	res, _ := Merge(serverEntry(t0), Change{ID: 7, BaseUpdatedAt: &base, UpdatedAt: t0.Add(time.Minute), Deleted: true}, t0.Add(time.Hour))
+	if !res.Delete || res.Outcome.Status != Applied {
+		t.Errorf("delete of an unchanged entry should apply: %+v", res.Outcome)
+	}
+
+	tomb := serverEntry(t0)
+	tomb.DeletedAt = gorm.DeletedAt{Time: t0.Add(time.Minute), Valid: true}
+	res, _ = Merge(tomb, Change{ID: 7, BaseUpdatedAt: &base, UpdatedAt: t0.Add(2 * time.Minute),
+		Fields: fieldsOf(t, map[string]interface{}{"text": "edit"})}, t0.Add(time.Hour))
+	if res.Save || res.Outcome.Resolution != ServerWins || res.Outcome.Conflicts[0].Field != "deleted" {
+		t.Errorf("edits to a deleted entry should lose: %+v", res.Outcome)
+	}
+}
+
+func TestMergeRejectsUnknownField(t *testing.T) {
+	_, err := Merge(serverEntry(t0), Change{ID: 7, Fields: fieldsOf(t, map[string]interface{}{"user_id": 2})}, t0)
+	if err == nil {
+		t.Error("expected an error for a field clients may not set")
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	got, err := DecodeCursor(EncodeCursor(at))
+	if err != nil || !got.Equal(at) {
+		t.Errorf("DecodeCursor = %v, %v", got, err)
+	}
+	if _, err := DecodeCursor("not a cursor!"); err == nil {
+		t.Error("expected an error for a malformed cursor")
+	}
+}
@@ -0,0 +1,170 @@
+// Package events fans entry changes out to the live connections of the
+// user they belong to.
+package events
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event types pushed to clients.
+const (
+	EntryCreated   = "entry.created"
+	EntryUpdated   = "entry.updated"
+	EntryDeleted   = "entry.deleted"
+	EntriesChanged = "entries.changed" // bulk change; clients should refetch
+)
+
+// Event is a change notification for one user.
+type Event struct {
+	ID      uint64          `json:"id"`
+	Type    string          `json:"type"`
+	UserID  uint            `json:"user_id"`
+	EntryID uint            `json:"entry_id,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	At      time.Time       `json:"at"`
+}
+
+// IDString is the event's SSE id.
+func (e Event) IDString() string {
+	return strconv.FormatUint(e.ID, 10)
+}
+
+// Subscription receives the events of a single user.
+type Subscription struct {
+	C      chan Event
+	userID uint
+}
+
+// Broker keeps the subscribers and a short per-user history so a client
+// that reconnects with Last-Event-ID can catch up on what it missed.
+type Broker struct {
+	mu          sync.Mutex
+	subs        map[uint]map[*Subscription]struct{}
+	history     map[uint][]Event
+	historySize int
+	lastID      uint64
+	closed      bool
+}
+
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		subs:        map[uint]map[*Subscription]struct{}{},
+		history:     map[uint][]Event{},
+		historySize: historySize,
+	}
+}
+
+// NextID returns a new event ID. IDs are time-based so that events created
+// by different server processes still sort roughly in order.
+func (b *Broker) NextID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextIDLocked()
+}
+
+func (b *Broker) nextIDLocked() uint64 {
+	id := uint64(time.Now().UnixNano()) // #nosec G115 -- clock is after 1970
+	if id <= b.lastID {
+		id = b.lastID + 1
+	}
+	b.lastID = id
+	return id
+}
+
+// Publish records the event and delivers it to the user's subscribers. A
+// subscriber that can't keep up is dropped; it will reconnect and resume.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.ID == 0 {
+		e.ID = b.nextIDLocked()
+	} else if e.ID > b.lastID {
+		b.lastID = e.ID
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	h := append(b.history[e.UserID], e)
+	if len(h) > b.historySize {
+		h = h[len(h)-b.historySize:]
+	}
+	b.history[e.UserID] = h
+
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.C <- e:
+		default:
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. If lastEventID is set, the events
+// published after it are returned as a backlog; reset is true when that ID is
+// no longer in the history, and the client should refetch everything.
+func (b *Broker) Subscribe(userID uint, lastEventID string) (sub *Subscription, backlog []Event, reset bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{C: make(chan Event, 64), userID: userID}
+	if b.closed {
+		close(sub.C)
+		return sub, nil, false
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[*Subscription]struct{}{}
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, false
+	}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return sub, nil, true
+	}
+	h := b.history[userID]
+	for i, e := range h {
+		if e.ID == last {
+			return sub, append([]Event(nil), h[i+1:]...), false
+		}
+	}
+	return sub, nil, true
+}
+
+// Close ends every subscription, and any made later, so event streams
+// finish while the server shuts down. Clients reconnect elsewhere.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub)
+}
+
+func (b *Broker) removeLocked(sub *Subscription) {
+	subs := b.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.C)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+}
@@ -0,0 +1,80 @@
+package events
+
+import "testing"
+
+func TestResumeFromLastEventID(t *testing.T) {
+	b := NewBroker(3)
+	for i := 0; i < 4; i++ {
+		b.Publish(Event{Type: EntryCreated, UserID: 1, EntryID: uint(i + 1)})
+	}
+	b.Publish(Event{Type: EntryCreated, UserID: 2, EntryID: 99})
+
+	b.mu.Lock()
+	h := append([]Event(nil), b.history[1]...)
+	b.mu.Unlock()
+	if len(h) != 3 || h[0].EntryID != 2 {
+		t.Fatalf("history should keep the last 3 events, got %+v", h)
+	}
+
+	sub, backlog, reset := b.Subscribe(1, h[0].IDString())
+	defer b.Unsubscribe(sub)
+	if reset || len(backlog) != 2 || backlog[0].EntryID != 3 || backlog[1].EntryID != 4 {
+		t.Errorf("backlog = %+v, reset = %v", backlog, reset)
+	}
+
+	_, _, reset = b.Subscribe(1, "12345")
+	if !reset {
+		t.Error("an unknown Last-Event-ID should ask the client to reset")
+	}
+}
+
+func TestPublishOnlyReachesOwner(t *testing.T) {
+	b := NewBroker(10)
+	mine, _, _ := b.Subscribe(1, "")
+	other, _, _ := b.Subscribe(2, "")
+
+	b.Publish(Event{Type: EntryDeleted, UserID: 1, EntryID: 7})
+
+	select {
+	case e := <-mine.C:
+		if e.EntryID != 7 || e.ID == 0 {
+			t.Errorf("unexpected event %+v", e)
+		}
+	default:
+		t.Fatal("subscriber did not receive its event")
+	}
+	select {
+	case e := <-other.C:
+		t.Errorf("other user received %+v", e)
+	default:
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(100)
+	sub, _, _ := b.Subscribe(1, "")
+	for i := 0; i < cap(sub.C)+1; i++ {
+		b.Publish(Event{Type: EntryUpdated, UserID: 1})
+	}
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != cap(sub.C) {
+		t.Errorf("expected channel to be closed after %d buffered events, got %d", cap(sub.C), n)
+	}
+}
+
+func TestCloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker(100)
+	sub, _, _ := b.Subscribe(1, "")
+	b.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription still open after Close")
+	}
+	late, _, _ := b.Subscribe(2, "")
+	if _, ok := <-late.C; ok {
+		t.Error("subscription made after Close is open")
+	}
+	b.Unsubscribe(sub) // must not close twice
+}
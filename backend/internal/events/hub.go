@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"Base/internal/bus"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("Base/internal/events")
+
+// Hub connects a local Broker to the event bus: events are published to
+// every server instance through the bus and whatever arrives from the bus is
+// delivered to this instance's subscribers.
+type Hub struct {
+	*Broker
+	bus  bus.Bus
+	stop func()
+}
+
+func NewHub(broker *Broker, b bus.Bus) *Hub {
+	h := &Hub{Broker: broker, bus: b}
+	h.stop = b.Subscribe(bus.TopicEntries, h.receive)
+	return h
+}
+
+// Publish sends the event to all instances, including this one. The entry
+// payload is dropped if the event would not fit on the bus; clients then
+// refetch instead of patching their cache.
+func (h *Hub) Publish(ctx context.Context, e Event) {
+	ctx, span := tracer.Start(ctx, "events publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.destination.name", bus.TopicEntries),
+			attribute.String("event.type", e.Type),
+		))
+	defer span.End()
+
+	if e.ID == 0 {
+		e.ID = h.NextID()
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	payload, err := json.Marshal(e)
+	if err == nil && len(payload) > bus.MaxPayload {
+		e.Data = nil
+		payload, err = json.Marshal(e)
+	}
+	if err == nil {
+		// The change is already committed, so a client hanging up must not
+		// cancel the notification
+		err = h.bus.Publish(context.WithoutCancel(ctx), bus.TopicEntries, payload)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "delivered locally only")
+		slog.WarnContext(ctx, "event bus publish failed; delivering locally only", "error", err)
+		h.Broker.Publish(e)
+	}
+}
+
+func (h *Hub) receive(payload []byte) {
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		slog.Warn("event bus: dropping malformed event", "error", err)
+		return
+	}
+	h.Broker.Publish(e)
+}
+
+// Close stops listening to the bus and ends every subscription. The bus
+// itself is left open.
+func (h *Hub) Close() {
+	h.stop()
+	h.Broker.Close()
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"Base/internal/bus"
+)
+
+func TestHubFansOutAcrossInstances(t *testing.T) {
+	b := bus.NewMemory()
+	a := NewHub(NewBroker(10), b)
+	defer a.Close()
+	other := NewHub(NewBroker(10), b)
+	defer other.Close()
+
+	subA, _, _ := a.Subscribe(1, "")
+	subB, _, _ := other.Subscribe(1, "")
+
+	big := `"` + strings.Repeat("x", bus.MaxPayload) + `"`
+	a.Publish(context.Background(), Event{Type: EntryUpdated, UserID: 1, EntryID: 3, Data: []byte(big)})
+
+	var ids []uint64
+	for name, sub := range map[string]*Subscription{"publisher": subA, "replica": subB} {
+		select {
+		case e := <-sub.C:
+			if e.EntryID != 3 || e.Data != nil {
+				t.Errorf("%s got %+v, want entry 3 without oversized data", name, e)
+			}
+			ids = append(ids, e.ID)
+		default:
+			t.Fatalf("%s did not receive the event", name)
+		}
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("event IDs differ between instances: %v", ids)
+	}
+}
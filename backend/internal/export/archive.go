@@ -0,0 +1,258 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"Base/internal/i18n"
+	"Base/internal/models"
+)
+
+// FormatVersion is bumped whenever the layout of the archive changes.
+const FormatVersion = 2
+
+// Archive is everything the backend stores about a single user.
+type Archive struct {
+	GeneratedAt time.Time
+	User        models.User
+	Entries     []models.Entry
+	Reviews     []models.Review
+	Attachments []models.Attachment
+	Exports     []models.ExportJob
+	Sessions    []Session
+	Revisions   []Revision
+	Lang        i18n.Lang // language of README.md and index.html; Default when empty
+
+	// OpenAttachment returns the bytes of an attachment. Attachments are
+	// listed but not copied into the archive when it is nil.
+	OpenAttachment func(models.Attachment) (io.ReadCloser, error)
+}
+
+// attachmentFile describes an attachment and where its bytes are in the archive.
+type attachmentFile struct {
+	models.Attachment
+	Path string `json:"path,omitempty"`
+}
+
+// Session is a sign-in attempt on the account, as kept in the audit log.
+type Session struct {
+	At        time.Time `json:"at"`
+	Succeeded bool      `json:"succeeded"`
+	IP        string    `json:"ip"`
+	RequestID string    `json:"request_id"`
+}
+
+// Revision is a recorded change to the account or one of its entries, as
+// kept in the audit log: changes made by an admin or from the command line.
+type Revision struct {
+	At         time.Time       `json:"at"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uint            `json:"target_id"`
+	ActorID    uint            `json:"actor_id"` // 0 for the command line
+	Changes    json.RawMessage `json:"changes"`
+}
+
+// Profile is the user record without the password hash.
+type Profile struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type manifest struct {
+	FormatVersion int       `json:"format_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	UserID        uint      `json:"user_id"`
+	Files         []string  `json:"files"`
+}
+
+// Write renders the archive as a ZIP: machine-readable JSON files plus a
+// README.md and index.html for people who just want to read their data.
+func Write(w io.Writer, a *Archive) error {
+	zw := zip.NewWriter(w)
+
+	profile := Profile{
+		ID:        a.User.ID,
+		Name:      a.User.Name,
+		Email:     a.User.Email,
+		Role:      a.User.Role,
+		CreatedAt: a.User.CreatedAt,
+		UpdatedAt: a.User.UpdatedAt,
+	}
+
+	files := []string{"profile.json", "entries.json", "reviews.json", "attachments.json", "exports.json", "sessions.json", "revisions.json", "README.md", "index.html"}
+	if err := writeJSON(zw, "manifest.json", manifest{
+		FormatVersion: FormatVersion,
+		GeneratedAt:   a.GeneratedAt,
+		UserID:        a.User.ID,
+		Files:         files,
+	}); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "entries.json", a.Entries); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "reviews.json", a.Reviews); err != nil {
+		return err
+	}
+	attachments := make([]attachmentFile, len(a.Attachments))
+	for i, att := range a.Attachments {
+		attachments[i] = attachmentFile{Attachment: att}
+		if a.OpenAttachment == nil {
+			continue
+		}
+		path := fmt.Sprintf("attachments/%d-%s", att.ID, att.FileName)
+		if err := copyAttachment(zw, path, att, a.OpenAttachment); err != nil {
+			return err
+		}
+		attachments[i].Path = path
+	}
+	if err := writeJSON(zw, "attachments.json", attachments); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "exports.json", a.Exports); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "sessions.json", a.Sessions); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "revisions.json", a.Revisions); err != nil {
+		return err
+	}
+	if err := writeFile(zw, "README.md", func(w io.Writer) error {
+		return renderMarkdown(w, profile, a)
+	}); err != nil {
+		return err
+	}
+	if err := writeFile(zw, "index.html", func(w io.Writer) error {
+		tmpl, err := htmlTemplate.Clone()
+		if err != nil {
+			return err
+		}
+		return tmpl.Funcs(template.FuncMap{"t": a.t}).Execute(w, struct {
+			Profile Profile
+			*Archive
+		}{profile, a})
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeFile(zw *zip.Writer, name string, fn func(io.Writer) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if err := fn(f); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func copyAttachment(zw *zip.Writer, path string, att models.Attachment, open func(models.Attachment) (io.ReadCloser, error)) error {
+	return writeFile(zw, path, func(w io.Writer) error {
+		rc, err := open(att)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(w, rc)
+		return err
+	})
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	return writeFile(zw, name, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+// t translates a README/index.html string into the archive's language.
+func (a *Archive) t(key string, args ...string) string {
+	lang := a.Lang
+	if lang == "" {
+		lang = i18n.Default
+	}
+	return i18n.T(lang, key, args...)
+}
+
+func renderMarkdown(w io.Writer, p Profile, a *Archive) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", a.t("export.title", "name", p.Name))
+	fmt.Fprintf(&b, "%s\n\n", a.t("export.generated", "date", a.GeneratedAt.UTC().Format(time.RFC1123)))
+	fmt.Fprintf(&b, "## %s\n\n", a.t("export.profile"))
+	fmt.Fprintf(&b, "- **%s:** %s\n", a.t("export.name"), p.Name)
+	fmt.Fprintf(&b, "- **%s:** %s\n", a.t("export.email"), p.Email)
+	fmt.Fprintf(&b, "- **%s:** %s\n", a.t("export.role"), p.Role)
+	fmt.Fprintf(&b, "- **%s:** %s\n\n", a.t("export.member_since"), p.CreatedAt.UTC().Format("2006-01-02"))
+
+	fmt.Fprintf(&b, "## %s\n\n", a.t("export.entries", "count", strconv.Itoa(len(a.Entries))))
+	for _, e := range a.Entries {
+		fmt.Fprintf(&b, "### %s\n\n", e.Situation)
+		fmt.Fprintf(&b, "_%s · %s · %s_\n\n", e.CreatedAt.UTC().Format("2006-01-02 15:04"), e.Icon, e.Colour)
+		if e.Tags != "" {
+			fmt.Fprintf(&b, "%s: %s\n\n", a.t("export.tags"), e.Tags)
+		}
+		fmt.Fprintf(&b, "%s\n\n", e.Text)
+	}
+
+	fmt.Fprintf(&b, "## %s\n\n", a.t("export.files"))
+	for _, name := range []string{"profile", "entries", "reviews", "attachments", "exports", "sessions", "revisions"} {
+		fmt.Fprintf(&b, "- `%s.json` – %s\n", name, a.t("export.file."+name))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlTemplate's "t" func is replaced per archive with one bound to its language.
+var htmlTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
+	"t": func(key string, args ...string) string { return key },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{t "export.title" "name" .Profile.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2937; }
+article { border: 1px solid #e5e7eb; border-radius: .5rem; padding: 1rem; margin-bottom: 1rem; }
+.meta { color: #6b7280; font-size: .875rem; }
+p.text { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{t "export.title" "name" .Profile.Name}}</h1>
+<p class="meta">{{t "export.generated" "date" (.GeneratedAt.UTC.Format "Mon, 02 Jan 2006 15:04:05 MST")}}</p>
+<h2>{{t "export.profile"}}</h2>
+<ul>
+<li><strong>{{t "export.name"}}:</strong> {{.Profile.Name}}</li>
+<li><strong>{{t "export.email"}}:</strong> {{.Profile.Email}}</li>
+<li><strong>{{t "export.role"}}:</strong> {{.Profile.Role}}</li>
+<li><strong>{{t "export.member_since"}}:</strong> {{.Profile.CreatedAt.UTC.Format "2006-01-02"}}</li>
+</ul>
+<h2>{{t "export.entries" "count" (printf "%d" (len .Entries))}}</h2>
+{{range .Entries}}<article>
+<h3>{{.Situation}}</h3>
+<p class="meta">{{.CreatedAt.UTC.Format "2006-01-02 15:04"}} · {{.Icon}} · {{.Colour}}{{if .Tags}} · {{.Tags}}{{end}}</p>
+<p class="text">{{.Text}}</p>
+</article>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/i18n"
+	"Base/internal/models"
+)
+
+func TestWrite(t *testing.T) {
+	user := models.User{Name: "ann", Email: "ann@example.com", Password: "bcrypt-hash", Role: "user"}
+	user.ID = 7
+	entry := models.Entry{Situation: "Monday standup", Text: "Share the demo", UserID: 7}
+	entry.ID = 3
+	att := models.Attachment{EntryID: 3, UserID: 7, FileName: "photo.jpg", StorageKey: "k"}
+	att.ID = 1
+	a := &Archive{
+		GeneratedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		User:        user,
+		Entries:     []models.Entry{entry},
+		Attachments: []models.Attachment{att},
+		Sessions:    []Session{{At: time.Now(), Succeeded: true, IP: "192.0.2.1"}},
+		Revisions:   []Revision{{Action: "entry.update", TargetType: "entry", TargetID: 3, ActorID: 1, Changes: json.RawMessage(`{"colour":{"before":"blue","after":"red"}}`)}},
+		Lang:        i18n.RU,
+		OpenAttachment: func(models.Attachment) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("jpeg bytes")), nil
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, a); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	var m manifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.FormatVersion != FormatVersion || m.UserID != 7 {
+		t.Errorf("manifest = %+v", m)
+	}
+	for _, name := range m.Files {
+		if _, ok := files[name]; !ok {
+			t.Errorf("manifest lists %s, which is missing", name)
+		}
+	}
+	if strings.Contains(files["profile.json"], "bcrypt-hash") {
+		t.Error("profile.json contains the password hash")
+	}
+	if got := files["attachments/1-photo.jpg"]; got != "jpeg bytes" {
+		t.Errorf("attachment = %q", got)
+	}
+	if !strings.Contains(files["attachments.json"], `"path": "attachments/1-photo.jpg"`) {
+		t.Errorf("attachments.json = %s", files["attachments.json"])
+	}
+	if !strings.Contains(files["sessions.json"], "192.0.2.1") || !strings.Contains(files["revisions.json"], `"before": "blue"`) {
+		t.Errorf("sessions.json = %s\nrevisions.json = %s", files["sessions.json"], files["revisions.json"])
+	}
+	for _, name := range []string{"README.md", "index.html"} {
+		if !strings.Contains(files[name], "Monday standup") || !strings.Contains(files[name], "Экспорт данных") {
+			t.Errorf("%s is not the Russian rendering of the entries:\n%s", name, files[name])
+		}
+	}
+}
@@ -1,27 +1,40 @@
 package handlers
 
 import (
+	"Base/internal/apierror"
+	"Base/internal/audit"
+	"Base/internal/config"
+	"Base/internal/events"
+	"Base/internal/middleware"
 	"Base/internal/models"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func GetAllUsers(c *gin.Context) {
+	p, ok := pageFrom(c)
+	if !ok {
+		return
+	}
 	var users []models.User
-	if err := DB.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+	if err := p.apply(db(c).Order("id asc")).Find(&users).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, trimPage(c, p, users))
 }
 func UpdateUser(c *gin.Context) {
 	idParam := c.Param("id")
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		apierror.Abort(c, apierror.FromBinding(err))
 		return
 	}
 
@@ -29,8 +42,8 @@ func UpdateUser(c *gin.Context) {
 
 	// Since we need to use the ID from the URL to find the record to update:
 	var userToUpdate models.User
-	if err := DB.First(&userToUpdate, "id = ?", idParam).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	if err := db(c).First(&userToUpdate, "id = ?", idParam).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound)
 		return
 	}
 
@@ -38,77 +51,155 @@ func UpdateUser(c *gin.Context) {
 		"name": user.Name,
 		"role": user.Role, // Allow updating role too if needed
 	}
+	updated := userToUpdate
+	updated.Name, updated.Role = user.Name, user.Role
 
 	if user.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
 			return
 		}
 		updates["password"] = string(hashedPassword)
+		updated.Password = string(hashedPassword)
 	}
 
-	if err := DB.Model(&userToUpdate).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&userToUpdate).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordAudit(c, tx, audit.UserUpdated, audit.TargetUser, userToUpdate.ID, userToUpdate, updated)
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.user_updated")})
 }
 
 func DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID required"})
+		apierror.Abort(c, apierror.InvalidID)
 		return
 	}
 
-	if err := DB.Delete(&models.User{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Where("id = ?", id).Limit(1).Find(&user).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.User{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		// Also delete entries
+		if err := tx.Delete(&models.Entry{}, "user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if user.ID == 0 {
+			return nil
+		}
+		return recordAudit(c, tx, audit.UserDeleted, audit.TargetUser, user.ID, user, nil)
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
-	// Also delete entries
-	if err := DB.Delete(&models.Entry{}, "user_id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user entries"})
-		return
+	if err := purgeEntryData(c.Request.Context(), "user_id = ?", id); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to clean up entry data", "target_user_id", id, "error", err)
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.user_deleted")})
 }
 
 func GetAllEntries(c *gin.Context) {
+	p, ok := pageFrom(c)
+	if !ok {
+		return
+	}
 	var entries []models.Entry
-	if err := DB.Find(&entries).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	if err := p.apply(db(c).Order("id asc")).Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, entries)
+	c.JSON(http.StatusOK, trimPage(c, p, entries))
 }
 
 func UpdateAnyEntry(c *gin.Context) {
 	id := c.Param("id")
 	var entry models.Entry
-	if err := DB.Where("id = ?", id).First(&entry).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+	if err := db(c).Where("id = ?", id).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
 		return
 	}
+	before := entry
 	if err := c.ShouldBindJSON(&entry); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		apierror.Abort(c, apierror.FromBinding(err))
 		return
 	}
-	if err := DB.Save(&entry).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry"})
+	if err := validateReminder(&entry); err != nil {
+		apierror.Abort(c, reminderError(err))
+		return
+	}
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&entry).Error; err != nil {
+			return err
+		}
+		return recordAudit(c, tx, audit.EntryUpdated, audit.TargetEntry, entry.ID, before, entry)
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
+	publishEntry(c.Request.Context(), events.EntryUpdated, &entry)
 	c.JSON(http.StatusOK, entry)
 }
 
 func DeleteAnyEntry(c *gin.Context) {
 	id := c.Param("id")
-	result := DB.Where("id = ?", id).Delete(&models.Entry{})
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+	var entry models.Entry
+	if err := db(c).Where("id = ?", id).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
+		return
+	}
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordAudit(c, tx, audit.EntryDeleted, audit.TargetEntry, entry.ID, entry, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.EntryNotFound)
+		return
+	} else if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
+	if err := purgeEntryData(c.Request.Context(), "entry_id = ?", id); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to clean up entry data", "entry_id", id, "error", err)
+	}
+	publishEntry(c.Request.Context(), events.EntryDeleted, &entry)
+
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.entry_deleted")})
+}
+
+// LegacySunset is when the unversioned routes go away.
+var LegacySunset = config.Defaults().API.LegacySunset
+
+func SetLegacySunset(t time.Time) {
+	LegacySunset = t
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully"})
+// GetDeprecatedRouteUsage reports how often each legacy unversioned route
+// has been called by this instance, and when the routes will be removed.
+func GetDeprecatedRouteUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"deprecated_at": middleware.LegacyDeprecatedAt,
+		"sunset":        LegacySunset,
+		"routes":        middleware.LegacyUsage(),
+	})
 }
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"Base/internal/apierror"
+
+	"Base/internal/anki"
+	"Base/internal/models"
+	"Base/internal/transfer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxAnkiImportSize = 50 << 20 // 50 MiB
+
+// ExportAnki downloads the current user's entries as an Anki .apkg deck,
+// with the situation on the front of each card and the text on the back.
+func ExportAnki(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var entries []models.Entry
+	if err := db(c).Where("user_id = ?", userID).Order("created_at asc").Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	var reviews []models.Review
+	if err := db(c).Where("user_id = ?", userID).Order("reviewed_at asc").Find(&reviews).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+	byEntry := map[uint][]anki.Review{}
+	for _, r := range reviews {
+		byEntry[r.EntryID] = append(byEntry[r.EntryID], anki.Review{At: r.ReviewedAt, Ease: r.Ease, Interval: r.Interval})
+	}
+
+	deck := &anki.Deck{Name: "Reminder-Card"}
+	for _, e := range entries {
+		deck.Notes = append(deck.Notes, anki.Note{
+			GUID:    anki.GUID(fmt.Sprintf("%d", e.ID)),
+			Front:   e.Situation,
+			Back:    e.Text,
+			Tags:    e.TagList(),
+			Created: e.CreatedAt,
+			Reviews: byEntry[e.ID],
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := anki.Write(&buf, deck); err != nil {
+		apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
+		return
+	}
+
+	filename := fmt.Sprintf("reminder-card-%s.apkg", time.Now().UTC().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+type ankiImportReport struct {
+	Deck       string      `json:"deck"`
+	Total      int         `json:"total"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Skipped    []importRow `json:"skipped"`
+	Reviews    int         `json:"reviews"`
+}
+
+// ImportAnki creates entries from an uploaded .apkg or .colpkg file, keeping
+// note tags and the review log. Notes that can't become a valid entry (for
+// example cloze notes with an empty back) are skipped and reported; the rest
+// are inserted in one transaction.
+func ImportAnki(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAnkiImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.FileRequired).Localized("detail.file_required_max", "max", "50 MiB"))
+		return
+	}
+	name := strings.ToLower(fileHeader.Filename)
+	if !strings.HasSuffix(name, ".apkg") && !strings.HasSuffix(name, ".colpkg") {
+		apierror.Abort(c, apierror.New(apierror.UnsupportedFormat).Localized("detail.expected_apkg"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.FileUnreadable)
+		return
+	}
+	defer file.Close()
+
+	deck, err := anki.Read(file, fileHeader.Size)
+	if err != nil {
+		e := apierror.New(apierror.InvalidFile).Detailf("%v", err)
+		if errors.Is(err, anki.ErrUnsupported) {
+			e.Status = http.StatusUnprocessableEntity
+		}
+		apierror.Abort(c, e)
+		return
+	}
+
+	report := ankiImportReport{Deck: deck.Name, Total: len(deck.Notes), Skipped: []importRow{}}
+	type pending struct {
+		entry   models.Entry
+		reviews []anki.Review
+	}
+	var toCreate []pending
+	// Duplicates are detected in the same transaction as the insert, see importRows.
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		seen, err := existingEntryKeys(tx, userID)
+		if err != nil {
+			return err
+		}
+		for i, note := range deck.Notes {
+			row := transfer.Row{Line: i + 1, Record: transfer.Record{
+				Situation: note.Front,
+				Text:      note.Back,
+				Tags:      strings.Join(note.Tags, " "),
+			}}
+			row.Normalize()
+			key := entryKey(row.Record.Situation, row.Record.Text)
+			switch {
+			case !row.Valid():
+				report.Skipped = append(report.Skipped, importRow{Line: row.Line, Status: "invalid", Record: row.Record, Errors: rowErrors(c, row)})
+			case seen[key]:
+				report.Duplicates++
+			default:
+				seen[key] = true
+				toCreate = append(toCreate, pending{
+					entry: models.Entry{
+						Model:     gorm.Model{CreatedAt: note.Created},
+						Situation: row.Record.Situation,
+						Text:      row.Record.Text,
+						Icon:      row.Record.Icon,
+						Colour:    row.Record.Colour,
+						Tags:      row.Record.Tags,
+						UserID:    userID,
+					},
+					reviews: note.Reviews,
+				})
+			}
+		}
+		for _, p := range toCreate {
+			if err := tx.Create(&p.entry).Error; err != nil {
+				return err
+			}
+			for _, r := range p.reviews {
+				review := models.Review{
+					EntryID:    p.entry.ID,
+					UserID:     userID,
+					ReviewedAt: r.At,
+					Ease:       r.Ease,
+					Interval:   r.Interval,
+					Source:     "anki",
+				}
+				if err := tx.Create(&review).Error; err != nil {
+					return err
+				}
+				report.Reviews++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+	report.Imported = len(toCreate)
+	if report.Imported > 0 {
+		publishEntriesChanged(c.Request.Context(), userID)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
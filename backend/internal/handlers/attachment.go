@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/config"
+
+	"Base/internal/models"
+	"Base/internal/storage"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+var Blobs storage.BlobStore
+
+func SetBlobStore(store storage.BlobStore) {
+	Blobs = store
+}
+
+// Attachments are the upload size limit, the accepted types and whether
+// photo metadata is stripped by default.
+var Attachments = config.Defaults().Attachments
+
+func SetAttachments(settings config.Attachments) {
+	Attachments = settings
+}
+
+func attachmentTypeAllowed(contentType string) bool {
+	for _, t := range Attachments.AllowedTypes {
+		if t == contentType || strings.HasSuffix(t, "/") && strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadAttachment attaches a file to one of the current user's entries.
+// The type is sniffed from the content rather than trusted from the client,
+// and an optional "sha256" form field is verified against the stored bytes.
+// Thumbnails of photos are made in the background. With strip_metadata=true
+// EXIF and GPS data are removed before the photo is stored; photos in a
+// format that can't be stripped are then refused.
+func UploadAttachment(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var entry models.Entry
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
+		return
+	}
+
+	maxSize := Attachments.MaxBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20) // allow for multipart overhead
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Abort(c, apierror.New(apierror.FileTooLarge).Localized("detail.file_too_large", "max", strconv.FormatInt(maxSize, 10)))
+			return
+		}
+		apierror.Abort(c, apierror.FileRequired)
+		return
+	}
+	if fileHeader.Size > maxSize {
+		apierror.Abort(c, apierror.New(apierror.FileTooLarge).Localized("detail.file_too_large", "max", strconv.FormatInt(maxSize, 10)))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.FileUnreadable)
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, 3072)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		apierror.Abort(c, apierror.FileUnreadable)
+		return
+	}
+	head = head[:n]
+	contentType, _, _ := mime.ParseMediaType(mimetype.Detect(head).String())
+	if !attachmentTypeAllowed(contentType) {
+		apierror.Abort(c, apierror.New(apierror.FileTypeNotAllowed).Localized("detail.file_type_not_allowed", "type", contentType))
+		return
+	}
+
+	strip := Attachments.StripMetadata
+	if v, err := strconv.ParseBool(c.PostForm("strip_metadata")); err == nil {
+		strip = v
+	}
+	strip = strip && strings.HasPrefix(contentType, "image/")
+	if strip && !strippableImages[contentType] {
+		apierror.Abort(c, apierror.New(apierror.FileTypeNotAllowed).Localized("detail.metadata_not_strippable", "type", contentType))
+		return
+	}
+
+	suffix, err := randomToken()
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.StorageError).Wrap(err))
+		return
+	}
+	key := fmt.Sprintf("entries/%d/%s", entry.ID, suffix[:32])
+
+	// hasher sees the bytes as uploaded, for the client's checksum
+	hasher := sha256.New()
+	var body io.Reader = io.TeeReader(io.MultiReader(bytes.NewReader(head), file), hasher)
+	size, storedSum := fileHeader.Size, ""
+	var capturedAt *time.Time
+	if strip {
+		// The original must never reach the blob store
+		data, err := io.ReadAll(body)
+		if err != nil {
+			apierror.Abort(c, apierror.FileUnreadable)
+			return
+		}
+		var clean []byte
+		clean, capturedAt = stripPhoto(data)
+		cleanSum := sha256.Sum256(clean)
+		body, size, storedSum = bytes.NewReader(clean), int64(len(clean)), hex.EncodeToString(cleanSum[:])
+	}
+	if err := Blobs.Put(c.Request.Context(), key, body, size, contentType); err != nil {
+		slog.ErrorContext(c.Request.Context(), "attachment upload failed", "error", err)
+		apierror.Abort(c, apierror.New(apierror.StorageError).Wrap(err))
+		return
+	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+
+	if want := c.PostForm("sha256"); want != "" && !strings.EqualFold(want, sum) {
+		_ = Blobs.Delete(context.Background(), key)
+		apierror.Abort(c, apierror.ChecksumMismatch)
+		return
+	}
+	if storedSum == "" {
+		storedSum = sum
+	}
+
+	attachment := models.Attachment{
+		EntryID:     entry.ID,
+		UserID:      userID,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      storedSum,
+		StorageKey:  key,
+		CapturedAt:  capturedAt,
+	}
+	if err := db(c).Create(&attachment).Error; err != nil {
+		_ = Blobs.Delete(context.Background(), key)
+		apierror.Abort(c, apierror.DatabaseError)
+		return
+	}
+
+	enqueueImageProcessing(c.Request.Context(), &attachment)
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachments lists the attachments of one of the current user's entries.
+func GetAttachments(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var attachments []models.Attachment
+	if err := db(c).Preload("Thumbnails").Where("entry_id = ? AND user_id = ?", c.Param("id"), userID).
+		Order("created_at asc").Find(&attachments).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// DownloadAttachment streams an attachment, honouring Range and conditional
+// requests so audio can be scrubbed and large photos resumed.
+func DownloadAttachment(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var attachment models.Attachment
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&attachment).Error; err != nil {
+		apierror.Abort(c, apierror.AttachmentNotFound)
+		return
+	}
+
+	blob, err := Blobs.Open(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		apierror.Abort(c, apierror.New(apierror.AttachmentNotFound).Localized("detail.attachment_data_missing"))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.StorageError).Wrap(err))
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("ETag", `"`+attachment.SHA256+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	http.ServeContent(c.Writer, c.Request, attachment.FileName, blob.ModTime(), blob)
+}
+
+// DeleteAttachment removes one attachment and its stored bytes.
+func DeleteAttachment(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var attachment models.Attachment
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&attachment).Error; err != nil {
+		apierror.Abort(c, apierror.AttachmentNotFound)
+		return
+	}
+	if err := purgeAttachments(c.Request.Context(), "id = ?", attachment.ID); err != nil {
+		apierror.Abort(c, apierror.New(apierror.StorageError).Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.attachment_deleted")})
+}
+
+// purgeEntryData deletes what belongs to deleted entries: the reviews and
+// attachments matching query, which may select by entry_id or user_id.
+func purgeEntryData(ctx context.Context, query interface{}, args ...interface{}) error {
+	if err := DB.WithContext(ctx).Unscoped().Where(query, args...).Delete(&models.Review{}).Error; err != nil {
+		return err
+	}
+	return purgeAttachments(ctx, query, args...)
+}
+
+// purgeAttachments deletes the blobs and rows of every attachment matching
+// the query. Rows whose blob couldn't be deleted are kept so a later purge
+// can retry them.
+func purgeAttachments(ctx context.Context, query interface{}, args ...interface{}) error {
+	db := DB.WithContext(ctx)
+	var attachments []models.Attachment
+	if err := db.Where(query, args...).Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	var ids []uint
+	var failed error
+	for _, a := range attachments {
+		var thumbs []models.Thumbnail
+		db.Where("attachment_id = ?", a.ID).Find(&thumbs)
+		keys := []string{a.StorageKey}
+		for _, t := range thumbs {
+			keys = append(keys, t.StorageKey)
+		}
+
+		ok := true
+		for _, key := range keys {
+			if err := Blobs.Delete(ctx, key); err != nil {
+				slog.ErrorContext(ctx, "failed to delete blob", "key", key, "error", err)
+				failed, ok = err, false
+			}
+		}
+		if ok {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) > 0 {
+		if err := db.Unscoped().Where("attachment_id IN ?", ids).Delete(&models.Thumbnail{}).Error; err != nil {
+			return err
+		}
+		if err := db.Unscoped().Delete(&models.Attachment{}, ids).Error; err != nil {
+			return err
+		}
+	}
+	return failed
+}
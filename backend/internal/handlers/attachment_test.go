@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Base/internal/jobs"
+	"Base/internal/middleware"
+	"Base/internal/models"
+	"Base/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func postAttachment(t *testing.T, data []byte, strip string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "photo")
+	fw.Write(data)
+	mw.WriteField("strip_metadata", strip)
+	mw.Close()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.POST("/entries/:id/attachments", func(c *gin.Context) { c.Set("userID", uint(1)) }, UploadAttachment)
+	req := httptest.NewRequest(http.MethodPost, "/entries/1/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUploadStripsMetadataBeforeStoring(t *testing.T) {
+	setupBatchDB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetBlobStore(store)
+	t.Cleanup(func() { SetBlobStore(nil) })
+	queue := jobs.NewQueue(1, 4)
+	SetJobs(queue)
+	// Stop the thumbnail job before the test's database goes away
+	t.Cleanup(func() { queue.Shutdown(context.Background()) })
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	comment := []byte("\xFF\xFE\x00\x12at 52.52N 13.40E")
+	photo := append(append(append([]byte{}, buf.Bytes()[:2]...), comment...), buf.Bytes()[2:]...)
+
+	if w := postAttachment(t, photo, "true"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+	var a models.Attachment
+	DB.First(&a)
+	blob, err := store.Open(context.Background(), a.StorageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := io.ReadAll(blob)
+	blob.Close()
+	if bytes.Contains(stored, []byte("52.52N")) || int64(len(stored)) != a.Size {
+		t.Errorf("stored %d bytes (size %d) still holding the comment", len(stored), a.Size)
+	}
+
+	heic := append([]byte{0, 0, 0, 24}, []byte("ftypheic\x00\x00\x00\x00mif1heic")...)
+	if w := postAttachment(t, heic, "true"); w.Code != http.StatusUnsupportedMediaType || !bytes.Contains(w.Body.Bytes(), []byte("image/heic")) {
+		t.Errorf("HEIC with strip_metadata: status = %d, body = %s", w.Code, w.Body)
+	}
+	if w := postAttachment(t, heic, "false"); w.Code != http.StatusCreated {
+		t.Errorf("HEIC without strip_metadata: status = %d, body = %s", w.Code, w.Body)
+	}
+}
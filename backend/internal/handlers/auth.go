@@ -1,31 +1,55 @@
 package handlers
 
 import (
+	"Base/internal/apierror"
+	"Base/internal/audit"
+	"Base/internal/metrics"
 	"Base/internal/middleware"
 	"Base/internal/models"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// AdminPassword, when set, lets the admin log in by name with it.
+var AdminPassword string
+
+func SetAdminPassword(password string) {
+	AdminPassword = password
+}
+
+// Tokens signs the session tokens handed out at login; until SetTokens it
+// has no secret and refuses to.
+var Tokens = middleware.NewTokens("")
+
+func SetTokens(tokens *middleware.Tokens) {
+	Tokens = tokens
+}
+
+type registerRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Language string `json:"language" binding:"omitempty,oneof=en ru"`
+}
+
 // CreateUser registers a new user account.
 func CreateUser(c *gin.Context) {
-	var input struct {
-		Name     string `json:"name" binding:"required"`
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
-	}
+	var input registerRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.FromBinding(err))
 		return
 	}
+	if input.Language == "" {
+		input.Language = string(middleware.GetLang(c))
+	}
 
 	// Check if email already taken (including soft-deleted users)
 	var existing models.User
-	err := DB.Unscoped().Where("email = ?", input.Email).First(&existing).Error
+	err := db(c).Unscoped().Where("email = ?", input.Email).First(&existing).Error
 	
 	if err == nil {
 		// A record was found. Check if it's currently active (not soft-deleted)
@@ -33,7 +57,7 @@ func CreateUser(c *gin.Context) {
 			// Record is soft-deleted. We can resurrect it!
 			hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 			if hashErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+				apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(hashErr))
 				return
 			}
 			
@@ -41,29 +65,31 @@ func CreateUser(c *gin.Context) {
 			existing.Name = input.Name
 			existing.Password = string(hashedPassword)
 			existing.Role = "user"
+			existing.Language = input.Language
 			
 			// Use Unscoped to update the soft-deleted record and clear DeletedAt
-			if updateErr := DB.Unscoped().Model(&existing).Updates(map[string]interface{}{
+			if updateErr := db(c).Unscoped().Model(&existing).Updates(map[string]interface{}{
 				"name":       existing.Name,
 				"password":   existing.Password,
 				"role":       existing.Role,
+				"language":   existing.Language,
 				"deleted_at": nil,
 			}).Error; updateErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore user"})
+				apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(updateErr))
 				return
 			}
-			c.JSON(http.StatusCreated, gin.H{"message": "User recreated successfully", "id": existing.ID})
+			c.JSON(http.StatusCreated, gin.H{"message": tr(c, "message.user_recreated"), "id": existing.ID})
 			return
 		}
 
 		// Otherwise, it represents an active user. Block the registration.
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		apierror.Abort(c, apierror.EmailTaken)
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
 		return
 	}
 
@@ -72,77 +98,119 @@ func CreateUser(c *gin.Context) {
 		Email:    input.Email,
 		Password: string(hashedPassword),
 		Role:     "user",
+		Language: input.Language,
 	}
 
-	if err := DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if err := db(c).Create(&user).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully", "id": user.ID})
+	c.JSON(http.StatusCreated, gin.H{"message": tr(c, "message.user_created"), "id": user.ID})
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"required"`
 }
 
 // Login authenticates a user and returns a JWT token.
 func Login(c *gin.Context) {
-	var input struct {
-		Email    string `json:"email"`
-		Name     string `json:"name"`
-		Password string `json:"password" binding:"required"`
-	}
+	var foundUser models.User
+	defer recordLogin(c, &foundUser)
+	var input loginRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		apierror.Abort(c, apierror.FromBinding(err))
 		return
 	}
 
 	// Support admin login by name, regular users by email
-	var foundUser models.User
 	if input.Name != "" {
-		adminPassword := os.Getenv("ADMIN_PASSWORD")
+		adminPassword := AdminPassword
 		if adminPassword != "" && input.Password == adminPassword {
-			if err := DB.Where("name = ? AND role = ?", input.Name, "admin").First(&foundUser).Error; err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			if err := db(c).Where("name = ? AND role = ?", input.Name, "admin").First(&foundUser).Error; err != nil {
+				apierror.Abort(c, apierror.InvalidCredentials)
 				return
 			}
 		} else {
-			if err := DB.Where("name = ?", input.Name).First(&foundUser).Error; err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			if err := db(c).Where("name = ?", input.Name).First(&foundUser).Error; err != nil {
+				apierror.Abort(c, apierror.InvalidCredentials)
 				return
 			}
 			if err := bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(input.Password)); err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+				apierror.Abort(c, apierror.InvalidCredentials)
 				return
 			}
 		}
 	} else if input.Email != "" {
-		if err := DB.Where("email = ?", input.Email).First(&foundUser).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		if err := db(c).Where("email = ?", input.Email).First(&foundUser).Error; err != nil {
+			apierror.Abort(c, apierror.InvalidCredentials)
 			return
 		}
 		if err := bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(input.Password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			apierror.Abort(c, apierror.InvalidCredentials)
 			return
 		}
 	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email or name required"})
+		apierror.Abort(c, apierror.CredentialsMissing)
 		return
 	}
 
-	token, err := middleware.CreateToken(foundUser.ID, foundUser.Name, foundUser.Role)
+	issueSession(c, &foundUser)
+}
+
+// recordLogin counts the attempt once Login is done with it. Successes go
+// to the audit log; wrong credentials are batched by failedLogins.
+func recordLogin(c *gin.Context, user *models.User) {
+	result := "success"
+	if c.IsAborted() {
+		result = "failure"
+	}
+	metrics.Logins.WithLabelValues(result).Inc()
+
+	if c.IsAborted() {
+		if last := c.Errors.Last(); last != nil && apierror.From(last.Err).Code == apierror.InvalidCredentials {
+			failedLogins.Add(user.ID, c.ClientIP(), middleware.GetRequestID(c))
+		}
+		return
+	}
+	e := newAuditEvent(c, audit.LoginSucceeded, audit.TargetUser, user.ID)
+	e.ActorID = user.ID
+	if err := audit.Append(db(c), e); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to audit login", "error", err)
+	}
+}
+
+// RefreshSession exchanges a valid token for a fresh one, picking up any
+// change to the user's name, role or language since it was issued.
+func RefreshSession(c *gin.Context) {
+	var user models.User
+	if err := db(c).First(&user, c.GetUint("userID")).Error; err != nil {
+		apierror.Abort(c, apierror.InvalidToken)
+		return
+	}
+	issueSession(c, &user)
+}
+
+// issueSession answers with a new token for user, also set as cookies.
+func issueSession(c *gin.Context, user *models.User) {
+	token, err := Tokens.CreateToken(user.ID, user.Name, user.Role, user.Language)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
 		return
 	}
 
 	middleware.SetCookie(c, token)
-	middleware.SetRoleCookie(c, foundUser.Role)
-	c.JSON(http.StatusOK, gin.H{"token": token, "role": foundUser.Role, "username": foundUser.Name})
+	middleware.SetRoleCookie(c, user.Role)
+	c.JSON(http.StatusOK, gin.H{"token": token, "role": user.Role, "username": user.Name, "language": user.Language})
 }
 
 // Logout clears the auth cookies.
 func Logout(c *gin.Context) {
 	c.SetCookie("token", "", -1, "/", "", false, true)
 	c.SetCookie("role", "", -1, "/", "", true, true)
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.logged_out")})
 }
 
 // GetUsername returns the username for the current authenticated user.
@@ -154,13 +222,13 @@ func GetUsername(c *gin.Context) {
 		tokenString = strings.TrimPrefix(authHeader, "Bearer ")
 	}
 	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized)
 		return
 	}
 
-	username, err := middleware.GetUsernameFromToken(tokenString)
+	username, err := Tokens.GetUsernameFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		apierror.Abort(c, apierror.InvalidToken)
 		return
 	}
 
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Base/internal/apierror"
+	"Base/internal/audit"
+
+	"Base/internal/entrysync"
+	"Base/internal/events"
+	"Base/internal/middleware"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxBatchOperations = 500
+
+// Batch modes
+const (
+	batchAtomic  = "atomic"   // all operations succeed or none are applied
+	batchPerItem = "per_item" // failed operations don't affect the others
+)
+
+// batchOp is one operation in a batch request.
+type batchOp struct {
+	Op         string                     `json:"op"` // update, tag, move or delete
+	ID         uint                       `json:"id"`
+	Fields     map[string]json.RawMessage `json:"fields,omitempty"`      // update
+	AddTags    []string                   `json:"add_tags,omitempty"`    // tag
+	RemoveTags []string                   `json:"remove_tags,omitempty"` // tag
+	UserID     uint                       `json:"user_id,omitempty"`     // move: the new owner
+}
+
+type batchRequest struct {
+	Mode       string    `json:"mode"`
+	Operations []batchOp `json:"operations"`
+}
+
+type batchResult struct {
+	Index  int                 `json:"index"`
+	ID     uint                `json:"id"`
+	Status string              `json:"status"` // ok, failed or rolled_back
+	Error  *apierror.ItemError `json:"error,omitempty"`
+	Entry  *models.Entry       `json:"entry,omitempty"`
+}
+
+type batchResponse struct {
+	Mode      string        `json:"mode"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []batchResult `json:"results"`
+}
+
+var errBatchFailed = errors.New("batch failed")
+
+// BatchEntries applies a list of operations to the current user's entries.
+func BatchEntries(c *gin.Context) {
+	userID := c.GetUint("userID")
+	runBatch(c, false, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user_id = ?", userID)
+	})
+}
+
+// AdminBatchEntries applies a list of operations to any entries. Only
+// admins may move entries to another user.
+func AdminBatchEntries(c *gin.Context) {
+	runBatch(c, true, func(tx *gorm.DB) *gorm.DB { return tx })
+}
+
+// runBatch executes the operations in order, each in its own savepoint. In
+// atomic mode any failure rolls the whole batch back and the response is
+// 422; in per_item mode the successful operations are kept.
+func runBatch(c *gin.Context, admin bool, scope func(*gorm.DB) *gorm.DB) {
+	var req batchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.FromBinding(err))
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = batchAtomic
+	}
+	if req.Mode != batchAtomic && req.Mode != batchPerItem {
+		apierror.Abort(c, apierror.New(apierror.InvalidBatch).Localized("detail.batch_mode"))
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+		apierror.Abort(c, apierror.New(apierror.InvalidBatch).Localized("detail.batch_size", "max", strconv.Itoa(maxBatchOperations)))
+		return
+	}
+
+	resp := batchResponse{Mode: req.Mode, Results: make([]batchResult, len(req.Operations))}
+	var published []entryEvent
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		for i, op := range req.Operations {
+			var entry *models.Entry
+			var evs []entryEvent
+			err := tx.Transaction(func(itx *gorm.DB) error {
+				var before models.Entry
+				if admin {
+					itx.Where("id = ?", op.ID).Limit(1).Find(&before)
+				}
+				var err error
+				if entry, evs, err = applyBatchOp(itx, scope, admin, op); err != nil || !admin {
+					return err
+				}
+				return auditBatchOp(c, itx, op, before, entry)
+			})
+
+			resp.Results[i] = batchResult{Index: i, ID: op.ID, Status: "ok", Entry: entry}
+			if err != nil {
+				resp.Results[i].Status = "failed"
+				resp.Results[i].Error = apierror.Item(middleware.GetLang(c), err)
+				resp.Failed++
+				continue
+			}
+			resp.Succeeded++
+			published = append(published, evs...)
+		}
+		if req.Mode == batchAtomic && resp.Failed > 0 {
+			return errBatchFailed
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errBatchFailed):
+		for i := range resp.Results {
+			if resp.Results[i].Status == "ok" {
+				resp.Results[i].Status = "rolled_back"
+				resp.Results[i].Entry = nil
+			}
+		}
+		resp.Succeeded = 0
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	case err != nil:
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	publishEntryEvents(c, published)
+	c.JSON(http.StatusOK, resp)
+}
+
+// batchAuditActions maps batch operations to audit log actions.
+var batchAuditActions = map[string]string{
+	"update": audit.EntryUpdated,
+	"tag":    audit.EntryTagged,
+	"move":   audit.EntryMoved,
+	"delete": audit.EntryDeleted,
+}
+
+// auditBatchOp records an admin's batch operation that changed an entry.
+func auditBatchOp(c *gin.Context, tx *gorm.DB, op batchOp, before models.Entry, after *models.Entry) error {
+	if after != nil && before.UserID == after.UserID && op.Op == "move" {
+		return nil // moved to its own owner: nothing happened
+	}
+	var afterValue any
+	if after != nil {
+		afterValue = after
+	}
+	if err := recordAudit(c, tx, batchAuditActions[op.Op], audit.TargetEntry, before.ID, before, afterValue); err != nil {
+		return dbFailure(tx, err)
+	}
+	return nil
+}
+
+// applyBatchOp applies one operation. Its errors are shown to the client,
+// so database failures are logged and replaced with database_error.
+func applyBatchOp(tx *gorm.DB, scope func(*gorm.DB) *gorm.DB, admin bool, op batchOp) (*models.Entry, []entryEvent, error) {
+	if op.ID == 0 {
+		return nil, nil, apierror.New(apierror.MissingFields).Localized("detail.field_required", "field", "id")
+	}
+
+	var entry models.Entry
+	if err := scope(tx).Where("id = ?", op.ID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierror.EntryNotFound
+		}
+		return nil, nil, dbFailure(tx, err)
+	}
+
+	switch op.Op {
+	case "update":
+		if len(op.Fields) == 0 {
+			return nil, nil, apierror.New(apierror.MissingFields).Localized("detail.field_required", "field", "fields")
+		}
+		if err := entrysync.Apply(&entry, op.Fields); err != nil {
+			return nil, nil, err
+		}
+
+	case "tag":
+		tags := append(entry.TagList(), op.AddTags...)
+		remove := make(map[string]bool, len(op.RemoveTags))
+		for _, t := range strings.Fields(models.JoinTags(op.RemoveTags)) {
+			remove[strings.ToLower(t)] = true
+		}
+		kept := tags[:0]
+		for _, t := range tags {
+			if !remove[strings.ToLower(strings.Join(strings.Fields(t), "_"))] {
+				kept = append(kept, t)
+			}
+		}
+		entry.Tags = models.JoinTags(kept)
+
+	case "move":
+		if !admin {
+			return nil, nil, apierror.New(apierror.Forbidden).Localized("detail.move_admin_only")
+		}
+		if op.UserID == 0 {
+			return nil, nil, apierror.New(apierror.MissingFields).Localized("detail.field_required", "field", "user_id")
+		}
+		if op.UserID == entry.UserID {
+			return &entry, nil, nil
+		}
+		if err := tx.First(&models.User{}, op.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, apierror.UserNotFound
+			}
+			return nil, nil, dbFailure(tx, err)
+		}
+		previous := entry
+		entry.UserID = op.UserID
+		if err := tx.Save(&entry).Error; err != nil {
+			return nil, nil, dbFailure(tx, err)
+		}
+		// Attachments and reviews follow the entry to its new owner.
+		for _, m := range []interface{}{&models.Attachment{}, &models.Review{}} {
+			if err := tx.Model(m).Where("entry_id = ?", entry.ID).Update("user_id", op.UserID).Error; err != nil {
+				return nil, nil, dbFailure(tx, err)
+			}
+		}
+		return &entry, []entryEvent{
+			{kind: events.EntryDeleted, entry: previous},
+			{kind: events.EntryCreated, entry: entry},
+		}, nil
+
+	case "delete":
+		if err := tx.Delete(&entry).Error; err != nil {
+			return nil, nil, dbFailure(tx, err)
+		}
+		return nil, []entryEvent{{kind: events.EntryDeleted, entry: entry, purge: true}}, nil
+
+	default:
+		return nil, nil, apierror.New(apierror.InvalidBatch).Localized("detail.batch_unknown_op", "op", op.Op)
+	}
+
+	if err := validateEntry(&entry); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Save(&entry).Error; err != nil {
+		return nil, nil, dbFailure(tx, err)
+	}
+	return &entry, []entryEvent{{kind: events.EntryUpdated, entry: entry}}, nil
+}
+
+func dbFailure(tx *gorm.DB, err error) error {
+	slog.ErrorContext(tx.Statement.Context, "batch operation failed", "error", err)
+	return apierror.DatabaseError
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/audit"
+	"Base/internal/models"
+	"Base/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupTestDB points the handlers at a fresh, fully migrated database
+// for the length of the test.
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	SetDB(db)
+	t.Cleanup(func() { SetDB(nil) })
+	return db
+}
+
+func setupBatchDB(t *testing.T) {
+	t.Helper()
+	db := setupTestDB(t)
+	for _, owner := range []uint{1, 1, 2} {
+		db.Create(&models.Entry{Situation: "s", Text: "t", Icon: "star", Colour: "purple", Tags: "old", UserID: owner})
+	}
+}
+
+func postBatch(t *testing.T, handler gin.HandlerFunc, body string) (int, batchResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/batch", func(c *gin.Context) { c.Set("userID", uint(1)) }, handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+	var resp batchResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestBatchAtomicRollsBack(t *testing.T) {
+	setupBatchDB(t)
+
+	code, resp := postBatch(t, BatchEntries, `{"operations":[
+		{"op":"update","id":1,"fields":{"colour":"blue"}},
+		{"op":"delete","id":3}
+	]}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", code)
+	}
+	if resp.Results[0].Status != "rolled_back" || resp.Results[1].Status != "failed" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+
+	var e models.Entry
+	DB.First(&e, 1)
+	if e.Colour != "purple" {
+		t.Errorf("entry 1 colour = %q, the update should have been rolled back", e.Colour)
+	}
+}
+
+func TestBatchPerItemKeepsSuccesses(t *testing.T) {
+	setupBatchDB(t)
+	DB.Create(&models.Review{EntryID: 2, UserID: 1, ReviewedAt: time.Now(), Ease: 3})
+	DB.Create(&models.Review{EntryID: 1, UserID: 1, ReviewedAt: time.Now(), Ease: 3})
+
+	code, resp := postBatch(t, BatchEntries, `{"mode":"per_item","operations":[
+		{"op":"tag","id":1,"add_tags":["new one"],"remove_tags":["OLD"]},
+		{"op":"move","id":2,"user_id":2},
+		{"op":"delete","id":2}
+	]}`)
+	if code != http.StatusOK || resp.Succeeded != 2 || resp.Failed != 1 {
+		t.Fatalf("status = %d, response = %+v", code, resp)
+	}
+	if e := resp.Results[1].Error; e == nil || e.Code != apierror.Forbidden {
+		t.Errorf("users must not be able to move entries, got %+v", e)
+	}
+
+	var e models.Entry
+	DB.First(&e, 1)
+	if e.Tags != "new_one" {
+		t.Errorf("tags = %q", e.Tags)
+	}
+	if err := DB.First(&models.Entry{}, 2).Error; err == nil {
+		t.Error("entry 2 should be deleted")
+	}
+	var reviews []models.Review
+	DB.Unscoped().Find(&reviews)
+	if len(reviews) != 1 || reviews[0].EntryID != 1 {
+		t.Errorf("reviews = %+v, want only entry 1's", reviews)
+	}
+}
+
+func TestBatchRejectsColoursOutsideTheCatalog(t *testing.T) {
+	setupBatchDB(t)
+
+	code, resp := postBatch(t, BatchEntries, `{"mode":"per_item","operations":[
+		{"op":"update","id":1,"fields":{"colour":"red"}},
+		{"op":"update","id":2,"fields":{"icon":"rocket"}}
+	]}`)
+	if code != http.StatusOK || resp.Failed != 2 {
+		t.Fatalf("status = %d, response = %+v", code, resp)
+	}
+	for _, r := range resp.Results {
+		if r.Error == nil || r.Error.Code != apierror.ValidationFailed {
+			t.Errorf("result = %+v, want %s", r, apierror.ValidationFailed)
+		}
+	}
+}
+
+func TestAdminBatchMove(t *testing.T) {
+	setupBatchDB(t)
+	DB.Create(&models.User{Name: "b", Email: "b@example.com", Password: "x"})
+	DB.Create(&models.User{Name: "c", Email: "c@example.com", Password: "x"})
+
+	code, resp := postBatch(t, AdminBatchEntries, `{"operations":[{"op":"move","id":3,"user_id":1}]}`)
+	if code != http.StatusOK || resp.Results[0].Entry == nil || resp.Results[0].Entry.UserID != 1 {
+		t.Fatalf("status = %d, response = %+v", code, resp)
+	}
+	var events []models.AuditEvent
+	DB.Find(&events)
+	if len(events) != 1 || events[0].Action != audit.EntryMoved || events[0].TargetID != 3 || events[0].ActorID != 1 ||
+		!strings.Contains(events[0].Changes, `"user_id":{"before":2,"after":1}`) {
+		t.Errorf("audit events = %+v", events)
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Base/internal/apierror"
+
+	"Base/internal/i18n"
+	"Base/internal/ical"
+	"Base/internal/metrics"
+	"Base/internal/middleware"
+	"Base/internal/models"
+	"Base/internal/transfer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicURL is the base of links handed out by the API, such as calendar
+// feeds. It is configured rather than taken from the request, whose Host
+// header the client controls.
+var PublicURL = "http://localhost:8080"
+
+func SetPublicURL(u string) {
+	PublicURL = u
+}
+
+// calendarURLs builds the subscription links for a feed token.
+func calendarURLs(token string) gin.H {
+	feed := fmt.Sprintf("%s/calendar/%s.ics", PublicURL, token)
+	_, rest, _ := strings.Cut(feed, "://")
+	return gin.H{
+		"feed_url":   feed,
+		"webcal_url": "webcal://" + rest,
+	}
+}
+
+// RotateCalendarToken creates a new private ICS feed URL for the current
+// user, invalidating any previous one.
+func RotateCalendarToken(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	token, err := randomToken()
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
+		return
+	}
+	if err := db(c).Model(&models.User{}).Where("id = ?", userID).Update("calendar_token", token).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, calendarURLs(token))
+}
+
+// RevokeCalendarToken disables the current user's ICS feed.
+func RevokeCalendarToken(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	if err := db(c).Model(&models.User{}).Where("id = ?", userID).Update("calendar_token", nil).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.calendar_disabled")})
+}
+
+// CalendarFeed publishes the entries with a reminder time as an iCalendar
+// feed. The secret token in the URL is the only credential, since calendar
+// apps can't send our auth headers. ?kind=todo publishes VTODOs instead of VEVENTs.
+func CalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	if token == "" {
+		apierror.Abort(c, apierror.CalendarNotFound)
+		return
+	}
+
+	var user models.User
+	if err := db(c).Where("calendar_token = ?", token).First(&user).Error; err != nil {
+		apierror.Abort(c, apierror.CalendarNotFound)
+		return
+	}
+
+	var entries []models.Entry
+	if err := db(c).Where("user_id = ? AND remind_at IS NOT NULL", user.ID).Order("remind_at asc").Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	kind := ical.KindEvent
+	if strings.EqualFold(c.Query("kind"), "todo") {
+		kind = ical.KindTodo
+	}
+
+	// Calendar apps don't send a useful Accept-Language, so the stored
+	// preference wins when there is one.
+	lang, ok := i18n.Parse(user.Language)
+	if !ok {
+		lang = middleware.GetLang(c)
+	}
+
+	cal := &ical.Calendar{Name: i18n.T(lang, "calendar.name")}
+	for _, e := range entries {
+		cal.Events = append(cal.Events, ical.Event{
+			Kind:         kind,
+			UID:          fmt.Sprintf("entry-%d@reminder-card", e.ID),
+			Summary:      e.Situation,
+			Description:  e.Text,
+			Start:        *e.RemindAt,
+			RRule:        e.Recurrence,
+			Categories:   e.TagList(),
+			Created:      e.CreatedAt,
+			LastModified: e.UpdatedAt,
+			Alarm:        true,
+			AlarmText:    i18n.T(lang, "calendar.alarm", "summary", e.Situation),
+		})
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="reminders.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	if err := ical.Write(c.Writer, cal); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	metrics.CalendarEntriesServed.Add(float64(len(cal.Events)))
+}
+
+// ImportCalendar turns the events and todos of an uploaded .ics file into
+// entries, keeping their start time and recurrence rule as the reminder.
+// It supports dry_run and reports per-event results like ImportEntries.
+func ImportCalendar(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.FileRequired).Localized("detail.file_required_max", "max", "10 MiB"))
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", c.Query("dry_run")))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.FileUnreadable)
+		return
+	}
+	defer file.Close()
+
+	events, err := ical.Read(file)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.InvalidFile).Localized("detail.invalid_ical", "reason", err.Error()))
+		return
+	}
+
+	rows := make([]transfer.Row, len(events))
+	for i, ev := range events {
+		text := ev.Description
+		if strings.TrimSpace(text) == "" {
+			text = ev.Summary
+		}
+		rows[i] = transfer.Row{Line: i + 1, Record: transfer.Record{
+			Situation:  ev.Summary,
+			Text:       text,
+			Tags:       strings.Join(ev.Categories, " "),
+			Recurrence: ev.RRule,
+		}}
+		if !ev.Start.IsZero() {
+			start := ev.Start
+			rows[i].Record.RemindAt = &start
+		}
+		if !ev.Created.IsZero() {
+			created := ev.Created
+			rows[i].Record.CreatedAt = &created
+		}
+	}
+
+	importRows(c, userID, "ics", rows, dryRun)
+}
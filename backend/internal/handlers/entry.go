@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"Base/internal/apierror"
+	"Base/internal/catalog"
+	"Base/internal/events"
+	"Base/internal/ical"
 	"Base/internal/middleware"
 	"Base/internal/models"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -15,36 +22,47 @@ func SetDB(database *gorm.DB) {
 	DB = database
 }
 
+// db binds DB to the request, so queries show up in its trace and stop when
+// the client goes away.
+func db(c *gin.Context) *gorm.DB {
+	return DB.WithContext(c.Request.Context())
+}
+
 // Создание записи (Оптимизировано: берем ID из токена сразу)
 func CreateEntry(c *gin.Context) {
 	tokenString := middleware.ExtractToken(c)
 	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized)
 		return
 	}
 
-	userID, err := middleware.GetUserIDFromToken(tokenString)
+	userID, err := Tokens.GetUserIDFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		apierror.Abort(c, apierror.InvalidToken)
 		return
 	}
 
 	var entry models.Entry
 	if err := c.ShouldBindJSON(&entry); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		apierror.Abort(c, apierror.FromBinding(err))
 		return
 	}
 	if entry.Situation == "" || entry.Text == "" || entry.Colour == "" || entry.Icon == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "All fields are required"})
+		apierror.Abort(c, apierror.MissingFields)
+		return
+	}
+	if err := validateReminder(&entry); err != nil {
+		apierror.Abort(c, reminderError(err))
 		return
 	}
 
 	entry.UserID = userID // Устанавливаем ID напрямую из токена
 
-	if err := DB.Create(&entry).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+	if err := db(c).Create(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
+	publishEntry(c.Request.Context(), events.EntryCreated, &entry)
 
 	c.JSON(http.StatusCreated, entry)
 }
@@ -53,46 +71,70 @@ func CreateEntry(c *gin.Context) {
 func GetEntries(c *gin.Context) {
 	tokenString := middleware.ExtractToken(c)
 	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		apierror.Abort(c, apierror.Unauthorized)
 		return
 	}
 
-	userID, err := middleware.GetUserIDFromToken(tokenString)
+	userID, err := Tokens.GetUserIDFromToken(tokenString)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		apierror.Abort(c, apierror.InvalidToken)
+		return
+	}
+
+	p, ok := pageFrom(c)
+	if !ok {
 		return
 	}
 
 	var entries []models.Entry
 	// Исправлено: GORM требует явного указания колонки user_id
-	if err := DB.Where("user_id = ?", userID).Order("created_at desc").Find(&entries).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	q := db(c).Where("user_id = ?", userID).Order("created_at desc").Order("id desc")
+	if err := p.apply(q).Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, entries)
+	c.JSON(http.StatusOK, trimPage(c, p, entries))
+}
+
+// GetEntry возвращает одну запись текущего пользователя
+func GetEntry(c *gin.Context) {
+	var entry models.Entry
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), c.GetUint("userID")).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, entry)
 }
 
 // UpdateEntry обновляет существующую запись
 func UpdateEntry(c *gin.Context) {
 	id := c.Param("id")
 	tokenString := middleware.ExtractToken(c)
-	userID, _ := middleware.GetUserIDFromToken(tokenString)
+	userID, _ := Tokens.GetUserIDFromToken(tokenString)
 
 	var entry models.Entry
 	// Проверяем, существует ли запись и принадлежит ли она пользователю
-	if err := DB.Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+	if err := db(c).Where("id = ? AND user_id = ?", id, userID).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
 		return
 	}
 
 	// Привязываем новые данные
 	if err := c.ShouldBindJSON(&entry); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+		apierror.Abort(c, apierror.FromBinding(err))
+		return
+	}
+	if err := validateReminder(&entry); err != nil {
+		apierror.Abort(c, reminderError(err))
 		return
 	}
 
-	DB.Save(&entry)
+	if err := db(c).Save(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+	publishEntry(c.Request.Context(), events.EntryUpdated, &entry)
 	c.JSON(http.StatusOK, entry)
 }
 
@@ -100,14 +142,61 @@ func UpdateEntry(c *gin.Context) {
 func DeleteEntry(c *gin.Context) {
 	id := c.Param("id")
 	tokenString := middleware.ExtractToken(c)
-	userID, _ := middleware.GetUserIDFromToken(tokenString)
-
-	result := DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Entry{})
+	userID, _ := Tokens.GetUserIDFromToken(tokenString)
 
+	result := db(c).Where("id = ? AND user_id = ?", id, userID).Delete(&models.Entry{})
+	if result.Error != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(result.Error))
+		return
+	}
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or unauthorized"})
+		apierror.Abort(c, apierror.EntryNotFound)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Entry deleted successfully"})
+	// Повторения и вложения удаляем вместе с записью
+	if err := purgeEntryData(c.Request.Context(), "entry_id = ?", id); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to clean up entry data", "entry_id", id, "error", err)
+	}
+	publishEntry(c.Request.Context(), events.EntryDeleted, &models.Entry{Model: gorm.Model{ID: entryID(id)}, UserID: userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": tr(c, "message.entry_deleted")})
+}
+
+var errRecurrenceNeedsRemindAt = errors.New("recurrence requires remind_at")
+
+// validateReminder checks the optional reminder fields of an entry, and that
+// its icon and colour are ones the cards can render.
+func validateReminder(entry *models.Entry) error {
+	if entry.Icon != "" && !catalog.IsIcon(entry.Icon) {
+		return apierror.New(apierror.ValidationFailed).Localized("detail.unknown_icon", "icon", entry.Icon)
+	}
+	if entry.Colour != "" && !catalog.IsColour(entry.Colour) {
+		return apierror.New(apierror.ValidationFailed).Localized("detail.unknown_colour", "colour", entry.Colour)
+	}
+	if err := ical.ValidateRRule(entry.Recurrence); err != nil {
+		return err
+	}
+	if entry.Recurrence != "" && entry.RemindAt == nil {
+		return errRecurrenceNeedsRemindAt
+	}
+	return nil
+}
+
+// reminderError is the API error for a validateReminder failure.
+func reminderError(err error) *apierror.Error {
+	var apiErr *apierror.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	if errors.Is(err, errRecurrenceNeedsRemindAt) {
+		return apierror.New(apierror.InvalidReminder).Localized("detail.recurrence_requires_remind_at")
+	}
+	return apierror.New(apierror.InvalidReminder).Detailf("%v", err)
+}
+
+// entryID parses an :id path parameter, returning 0 if it isn't a number.
+func entryID(param string) uint {
+	id, _ := strconv.ParseUint(param, 10, 64)
+	return uint(id)
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"Base/internal/events"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var Events *events.Hub
+
+func SetEvents(hub *events.Hub) {
+	Events = hub
+}
+
+const sseHeartbeat = 15 * time.Second
+
+// publishEntry notifies the entry owner's open dashboards about a change.
+func publishEntry(ctx context.Context, kind string, entry *models.Entry) {
+	if Events == nil {
+		return
+	}
+	data, _ := json.Marshal(entry)
+	Events.Publish(ctx, events.Event{Type: kind, UserID: entry.UserID, EntryID: entry.ID, Data: data})
+}
+
+// publishEntriesChanged tells a user's clients to refetch after a bulk change.
+func publishEntriesChanged(ctx context.Context, userID uint) {
+	if Events == nil {
+		return
+	}
+	Events.Publish(ctx, events.Event{Type: events.EntriesChanged, UserID: userID})
+}
+
+// StreamEvents is a Server-Sent Events stream of the current user's entry
+// changes. Clients reconnecting with Last-Event-ID get what they missed, or
+// a "reset" event if that is no longer known and they should refetch.
+func StreamEvents(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	sub, backlog, reset := Events.Subscribe(userID, lastID)
+	defer Events.Unsubscribe(sub)
+
+	// The stream outlives the server's write timeout; it ends when the
+	// client goes away or the server shuts down
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.WarnContext(c.Request.Context(), "event stream will be cut at the write timeout", "error", err)
+	}
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx, Render)
+	c.Status(http.StatusOK)
+
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	if reset {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range backlog {
+		writeEvent(c, e)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return // dropped for being too slow; the client will resume
+			}
+			writeEvent(c, e)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, e events.Event) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.IDString(), e.Type, data)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/audit"
+	"Base/internal/config"
+	"Base/internal/export"
+	"Base/internal/i18n"
+	"Base/internal/jobs"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var Jobs *jobs.Queue
+
+func SetJobs(queue *jobs.Queue) {
+	Jobs = queue
+}
+
+// Exports is where finished archives are kept until their download link
+// expires, and how long that is.
+var Exports = config.Defaults().Exports
+
+func SetExports(settings config.Exports) {
+	Exports = settings
+}
+
+// exportLease is how long a job may stay pending or running before it is
+// taken for abandoned by a process that died or shut down mid-export.
+const exportLease = time.Hour
+
+// activeExport are the statuses of a job that is still on its way; a user
+// has at most one, which a unique index enforces.
+var activeExport = []string{models.ExportPending, models.ExportRunning}
+
+// RequestExport queues a personal data export for the current user.
+func RequestExport(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	if err := failStaleExports(db(c).Where("user_id = ?", userID)); err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	job := models.ExportJob{UserID: userID, Status: models.ExportPending}
+	res := db(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&job)
+	if res.Error != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(res.Error))
+		return
+	}
+	if res.RowsAffected == 0 {
+		var active models.ExportJob
+		if err := db(c).Where("user_id = ? AND status IN ?", userID, activeExport).First(&active).Error; err != nil {
+			apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+			return
+		}
+		apierror.Abort(c, apierror.New(apierror.ExportInProgress).With("job", active))
+		return
+	}
+
+	jobID := job.ID
+	if err := Jobs.EnqueueContext(c.Request.Context(), fmt.Sprintf("export-%d", jobID), func(ctx context.Context) error {
+		return runExport(ctx, jobID)
+	}); err != nil {
+		if err := failExport(db(c), &job, err); err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to mark export failed", "export_id", job.ID, "error", err)
+		}
+		apierror.Abort(c, apierror.Unavailable)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExport reports the status of an export and, once ready, its download link.
+func GetExport(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var job models.ExportJob
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.ExportNotFound)
+		return
+	}
+
+	if job.Status == models.ExportReady && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		if err := expireExport(db(c), &job); err != nil {
+			apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+			return
+		}
+	}
+
+	resp := gin.H{"job": job}
+	if job.Status == models.ExportReady {
+		resp["download_url"] = fmt.Sprintf("/api/v1/exports/%d/download?token=%s", job.ID, job.DownloadToken)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadExport serves a finished archive. It is authorised by the one-off
+// token in the link rather than the session, so it works from a plain <a href>.
+func DownloadExport(c *gin.Context) {
+	token := c.Query("token")
+
+	var job models.ExportJob
+	if err := db(c).Where("id = ?", c.Param("id")).First(&job).Error; err != nil || token == "" ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(job.DownloadToken)) != 1 {
+		apierror.Abort(c, apierror.ExportNotFound)
+		return
+	}
+
+	if job.Status != models.ExportReady {
+		apierror.Abort(c, apierror.ExportUnavailable)
+		return
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		if err := expireExport(db(c), &job); err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to expire export", "export_id", job.ID, "error", err)
+		}
+		apierror.Abort(c, apierror.LinkExpired)
+		return
+	}
+
+	c.FileAttachment(job.FilePath, fmt.Sprintf("reminder-card-export-%d.zip", job.ID))
+}
+
+func runExport(ctx context.Context, jobID uint) error {
+	// Status updates must land even if shutdown cancels the export itself
+	db := DB.WithContext(context.WithoutCancel(ctx))
+
+	var job models.ExportJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&job).Update("status", models.ExportRunning).Error; err != nil {
+		return err
+	}
+
+	path, size, err := buildExport(ctx, &job)
+	if err != nil {
+		return errors.Join(err, failExport(db, &job, err))
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		_ = os.Remove(path)
+		return errors.Join(err, failExport(db, &job, err))
+	}
+
+	now := time.Now()
+	expires := now.Add(Exports.LinkTTL)
+	if err := db.Model(&job).Updates(map[string]interface{}{
+		"status":         models.ExportReady,
+		"file_path":      path,
+		"size":           size,
+		"download_token": token,
+		"completed_at":   now,
+		"expires_at":     expires,
+	}).Error; err != nil {
+		_ = os.Remove(path)
+		return errors.Join(err, failExport(db, &job, err))
+	}
+	return nil
+}
+
+// failExport records why job failed.
+func failExport(db *gorm.DB, job *models.ExportJob, cause error) error {
+	return db.Model(job).Updates(map[string]interface{}{"status": models.ExportFailed, "error": cause.Error()}).Error
+}
+
+func buildExport(ctx context.Context, job *models.ExportJob) (string, int64, error) {
+	if err := os.MkdirAll(Exports.Dir, 0o700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(Exports.Dir, fmt.Sprintf("export-%d-%d.zip", job.UserID, job.ID))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := WriteArchive(ctx, f, job.UserID); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+// WriteArchive writes everything stored about a user to w as the ZIP
+// archive offered by data exports.
+func WriteArchive(ctx context.Context, w io.Writer, userID uint) error {
+	archive := export.Archive{GeneratedAt: time.Now()}
+	db := DB.WithContext(ctx)
+	if err := db.First(&archive.User, userID).Error; err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	if lang, ok := i18n.Parse(archive.User.Language); ok {
+		archive.Lang = lang
+	} else {
+		archive.Lang = i18n.Default
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&archive.Entries).Error; err != nil {
+		return fmt.Errorf("load entries: %w", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("reviewed_at asc").Find(&archive.Reviews).Error; err != nil {
+		return fmt.Errorf("load reviews: %w", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&archive.Attachments).Error; err != nil {
+		return fmt.Errorf("load attachments: %w", err)
+	}
+	archive.OpenAttachment = func(a models.Attachment) (io.ReadCloser, error) {
+		return Blobs.Open(ctx, a.StorageKey)
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at asc").Find(&archive.Exports).Error; err != nil {
+		return fmt.Errorf("load exports: %w", err)
+	}
+	if err := loadHistory(db, &archive); err != nil {
+		return err
+	}
+
+	return export.Write(w, &archive)
+}
+
+// loadHistory adds the sign-ins and the recorded changes to the account
+// and its entries from the audit log.
+func loadHistory(db *gorm.DB, archive *export.Archive) error {
+	userID := archive.User.ID
+	var logins []models.AuditEvent
+	if err := db.Where("target_type = ? AND target_id = ? AND action IN ?", audit.TargetUser, userID,
+		[]string{audit.LoginSucceeded, audit.LoginFailed}).Order("id asc").Find(&logins).Error; err != nil {
+		return fmt.Errorf("load sessions: %w", err)
+	}
+	for _, e := range logins {
+		archive.Sessions = append(archive.Sessions, export.Session{
+			At: e.CreatedAt, Succeeded: e.Action == audit.LoginSucceeded, IP: e.IP, RequestID: e.RequestID,
+		})
+	}
+
+	entryIDs := db.Unscoped().Model(&models.Entry{}).Select("id").Where("user_id = ?", userID)
+	var changes []models.AuditEvent
+	if err := db.Where("(target_type = ? AND target_id = ? AND action NOT IN ?) OR (target_type = ? AND target_id IN (?))",
+		audit.TargetUser, userID, []string{audit.LoginSucceeded, audit.LoginFailed}, audit.TargetEntry, entryIDs).
+		Order("id asc").Find(&changes).Error; err != nil {
+		return fmt.Errorf("load revisions: %w", err)
+	}
+	for _, e := range changes {
+		r := export.Revision{At: e.CreatedAt, Action: e.Action, TargetType: e.TargetType, TargetID: e.TargetID,
+			ActorID: e.ActorID, Changes: json.RawMessage("null")}
+		if e.Changes != "" {
+			r.Changes = json.RawMessage(e.Changes)
+		}
+		archive.Revisions = append(archive.Revisions, r)
+	}
+	return nil
+}
+
+// PurgeExpiredExports removes archives whose download links have lapsed
+// and fails jobs abandoned past their lease. serve runs it periodically on
+// the job queue.
+func PurgeExpiredExports(ctx context.Context) error {
+	db := DB.WithContext(ctx)
+	if err := failStaleExports(db); err != nil {
+		return err
+	}
+	var expired []models.ExportJob
+	if err := db.Where("status = ? AND expires_at < ?", models.ExportReady, time.Now()).Find(&expired).Error; err != nil {
+		return err
+	}
+	var errs []error
+	for i := range expired {
+		if err := expireExport(db, &expired[i]); err != nil {
+			errs = append(errs, fmt.Errorf("export %d: %w", expired[i].ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failStaleExports marks the jobs in scope that have been pending or
+// running for longer than exportLease as failed, so their users can export
+// again.
+func failStaleExports(scope *gorm.DB) error {
+	return scope.Model(&models.ExportJob{}).
+		Where("status IN ? AND updated_at < ?", activeExport, time.Now().Add(-exportLease)).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": "export was abandoned"}).Error
+}
+
+func expireExport(db *gorm.DB, job *models.ExportJob) error {
+	if job.FilePath != "" {
+		if err := os.Remove(job.FilePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := db.Model(job).Updates(map[string]interface{}{"status": models.ExportExpired, "file_path": "", "download_token": ""}).Error; err != nil {
+		return err
+	}
+	job.Status = models.ExportExpired
+	return nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"Base/internal/audit"
+	"Base/internal/config"
+	"Base/internal/jobs"
+	"Base/internal/middleware"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupExportDB(t *testing.T) {
+	t.Helper()
+	db := setupTestDB(t)
+	exports := Exports
+	SetExports(config.Exports{Dir: t.TempDir(), LinkTTL: time.Hour})
+	t.Cleanup(func() { SetExports(exports) })
+
+	db.Create(&models.User{Name: "ann", Email: "ann@example.com", Password: "x"})
+	db.Create(&models.Entry{Situation: "s", Text: "t", UserID: 1})
+	for _, e := range []*models.AuditEvent{
+		{ActorID: 1, Action: audit.LoginSucceeded, TargetType: audit.TargetUser, TargetID: 1, IP: "192.0.2.1"},
+		{ActorID: 9, Action: audit.EntryUpdated, TargetType: audit.TargetEntry, TargetID: 1, Changes: `{"colour":{"before":"blue","after":"red"}}`},
+		{ActorID: 9, Action: audit.EntryUpdated, TargetType: audit.TargetEntry, TargetID: 2},
+	} {
+		if err := audit.Append(db, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func exportRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Errors())
+	authed := r.Group("/", func(c *gin.Context) { c.Set("userID", uint(1)) })
+	authed.POST("/exports", RequestExport)
+	authed.GET("/exports/:id", GetExport)
+	r.GET("/exports/:id/download", DownloadExport)
+	return r
+}
+
+func TestExportLifecycle(t *testing.T) {
+	setupExportDB(t)
+	queue := jobs.NewQueue(1, 4)
+	SetJobs(queue)
+	r := exportRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/exports", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("request: status = %d, body = %s", w.Code, w.Body)
+	}
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/exports/1", nil))
+	var status struct {
+		Job         models.ExportJob `json:"job"`
+		DownloadURL string           `json:"download_url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status.Job.Status != models.ExportReady {
+		t.Fatalf("status = %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, status.DownloadURL[len("/api/v1"):], nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("download: status = %d", w.Code)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+	var sessions, revisions []map[string]any
+	_ = json.Unmarshal([]byte(files["sessions.json"]), &sessions)
+	_ = json.Unmarshal([]byte(files["revisions.json"]), &revisions)
+	if len(sessions) != 1 || sessions[0]["ip"] != "192.0.2.1" {
+		t.Errorf("sessions.json = %s", files["sessions.json"])
+	}
+	// The change to another user's entry is not part of this archive
+	if len(revisions) != 1 || revisions[0]["target_id"] != 1.0 {
+		t.Errorf("revisions.json = %s", files["revisions.json"])
+	}
+
+	var job models.ExportJob
+	DB.First(&job, 1)
+	DB.Model(&job).Update("expires_at", time.Now().Add(-time.Minute))
+	if err := PurgeExpiredExports(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(job.FilePath); !os.IsNotExist(err) {
+		t.Errorf("archive still on disk after the purge: %v", err)
+	}
+	DB.First(&job, 1)
+	if job.Status != models.ExportExpired || job.DownloadToken != "" {
+		t.Errorf("job after purge = %+v", job)
+	}
+}
+
+func TestExportFailureIsRecorded(t *testing.T) {
+	setupExportDB(t)
+	DB.Exec("DELETE FROM users")
+	job := models.ExportJob{UserID: 1, Status: models.ExportPending}
+	DB.Create(&job)
+
+	if err := runExport(context.Background(), job.ID); err == nil {
+		t.Fatal("export of a missing user succeeded")
+	}
+	DB.First(&job, job.ID)
+	if job.Status != models.ExportFailed || job.Error == "" {
+		t.Errorf("job = %+v, want failed with the error", job)
+	}
+}
+
+func TestExportInProgressUntilItsLeaseRunsOut(t *testing.T) {
+	setupExportDB(t)
+	queue := jobs.NewQueue(1, 4)
+	SetJobs(queue)
+	t.Cleanup(func() { queue.Shutdown(context.Background()) })
+	r := exportRouter()
+
+	stuck := models.ExportJob{UserID: 1, Status: models.ExportRunning}
+	DB.Create(&stuck)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/exports", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("request during a running export: status = %d, body = %s", w.Code, w.Body)
+	}
+	if err := DB.Create(&models.ExportJob{UserID: 1, Status: models.ExportPending}).Error; err == nil {
+		t.Error("a second active export was stored")
+	}
+
+	DB.Model(&stuck).UpdateColumn("updated_at", time.Now().Add(-2*exportLease))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/exports", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("request after the lease: status = %d, body = %s", w.Code, w.Body)
+	}
+	DB.First(&stuck, stuck.ID)
+	if stuck.Status != models.ExportFailed {
+		t.Errorf("abandoned job = %+v, want failed", stuck)
+	}
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"Base/internal/apierror"
+	"Base/internal/audit"
+	"Base/internal/health"
+	"Base/internal/middleware"
+	"Base/internal/models"
+	oa "Base/internal/openapi"
+	"Base/internal/transfer"
+)
+
+// message is the body of responses that only confirm an action.
+var message = oa.Object(map[string]*oa.Schema{"message": oa.String()}, "message")
+
+// probeStatus is the body of the /health and /livez probes.
+var probeStatus = oa.Object(map[string]*oa.Schema{"status": oa.Enum(health.StatusOK, health.StatusDraining)}, "status")
+
+// importForm is the multipart body of the file import endpoints.
+func importForm(extra map[string]*oa.Schema) *oa.Schema {
+	props := map[string]*oa.Schema{
+		"file":    {Type: oa.Types{"string"}, ContentMediaType: "application/octet-stream"},
+		"dry_run": oa.Describe(oa.Enum("true", "false"), "Validate and preview without importing"),
+	}
+	for k, v := range extra {
+		props[k] = v
+	}
+	return oa.Object(props, "file")
+}
+
+// pageParams and pageLink describe paginated list endpoints.
+var pageParams = []oa.Parameter{
+	oa.Query("limit", "Page size; without it the whole list is returned", &oa.Schema{Type: oa.Types{"integer"}, Minimum: &one, Maximum: &maxPage}),
+	oa.Query("offset", "Number of items to skip", &oa.Schema{Type: oa.Types{"integer"}, Minimum: &zero}),
+}
+
+var (
+	zero, one, maxPage = 0.0, 1.0, float64(maxPageSize)
+	pageLink           = map[string]oa.Header{"Link": {Description: `<url>; rel="next" when there are more items`, Schema: oa.String()}}
+)
+
+// paged is a list response with a Link to the next page.
+func paged(description string, s *oa.Schema) *oa.Response {
+	r := oa.Reply(description, s)
+	r.Headers = pageLink
+	return r
+}
+
+// auditParams are the filters of the audit log endpoints.
+var auditParams = []oa.Parameter{
+	oa.Query("actor_id", "User who acted; 0 for the command line and failed logins", oa.Integer()),
+	oa.Query("action", "Action, such as user.delete or auth.login_failed", oa.String()),
+	oa.Query("target_type", "Kind of record acted on", oa.Enum(audit.TargetUser, audit.TargetEntry)),
+	oa.Query("target_id", "ID of the record acted on", oa.Integer()),
+	oa.Query("since", "Only events at or after this time", oa.DateTime()),
+	oa.Query("until", "Only events before this time", oa.DateTime()),
+}
+
+var dryRunQuery = oa.Query("dry_run", "Validate and preview without importing", oa.Boolean())
+
+// ProblemResponse is how every error is answered.
+var ProblemResponse = &oa.Response{
+	Description: "Error, as RFC 7807 problem details with a stable code",
+	Content:     map[string]oa.MediaType{apierror.ContentType: {Schema: oa.SchemaOf(apierror.Problem{})}},
+}
+
+// Operations describes every handler for the OpenAPI document, keyed by
+// "METHOD /route". Legacy routes reuse their successor's description, so
+// only those whose request or response differ are listed here.
+func Operations() map[string]*oa.Operation {
+	entry := oa.SchemaOf(models.Entry{})
+	entries := oa.SchemaOf([]models.Entry{})
+	user := oa.SchemaOf(models.User{})
+	attachment := oa.SchemaOf(models.Attachment{})
+	exportJob := oa.SchemaOf(models.ExportJob{})
+	loggedIn := oa.Object(map[string]*oa.Schema{
+		"token": oa.String(), "role": oa.String(), "username": oa.String(), "language": oa.String(),
+	}, "token", "role", "username", "language")
+	calendarLinks := oa.Object(map[string]*oa.Schema{"feed_url": oa.String(), "webcal_url": oa.String()}, "feed_url", "webcal_url")
+
+	return map[string]*oa.Operation{
+		// Session and account
+		"POST /api/v1/session": {
+			Tags: []string{"auth"}, OperationID: "login", Summary: "Log in",
+			Description: "Authenticates by email or name and returns a JWT, also set as the token cookie.",
+			Security:    oa.Public(),
+			RequestBody: oa.Body(oa.SchemaOf(loginRequest{})),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Logged in", loggedIn)},
+		},
+		"POST /api/v1/session/refresh": {
+			Tags: []string{"auth"}, OperationID: "refreshSession", Summary: "Refresh the session token",
+			Responses: map[string]*oa.Response{"200": oa.Reply("A new token", loggedIn)},
+		},
+		"DELETE /api/v1/session": {
+			Tags: []string{"auth"}, OperationID: "logout", Summary: "Log out",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Logged out", message)},
+		},
+		"POST /user/logout": {
+			Tags: []string{"auth"}, OperationID: "legacyLogout", Summary: "Log out", Deprecated: true,
+			Responses: map[string]*oa.Response{"200": oa.Reply("Logged out", message)},
+		},
+		"POST /api/v1/users": {
+			Tags: []string{"auth"}, OperationID: "register", Summary: "Register",
+			Description: "Creates an account. Supports Idempotency-Key.",
+			Security:    oa.Public(),
+			RequestBody: oa.Body(oa.SchemaOf(registerRequest{})),
+			Responses: map[string]*oa.Response{"201": oa.Reply("Registered", oa.Object(map[string]*oa.Schema{
+				"message": oa.String(), "id": oa.Integer(),
+			}, "message", "id"))},
+		},
+		"GET /api/v1/me": {
+			Tags: []string{"account"}, OperationID: "getMe", Summary: "Current user",
+			Responses: map[string]*oa.Response{"200": oa.Reply("The current user", oa.Object(map[string]*oa.Schema{
+				"id": oa.Integer(), "username": oa.String(), "email": oa.String(), "role": oa.String(), "language": oa.String(),
+			}, "id", "username", "email", "role", "language"))},
+		},
+		"POST /user/getusername": {
+			Tags: []string{"account"}, OperationID: "legacyGetUsername", Summary: "Current user's name", Deprecated: true,
+			Responses: map[string]*oa.Response{"200": oa.Reply("The current user's name", oa.Object(map[string]*oa.Schema{
+				"username": oa.String(),
+			}, "username"))},
+		},
+		"GET /api/v1/me/preferences": {
+			Tags: []string{"account"}, OperationID: "getPreferences", Summary: "Get preferences",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Stored and effective language", oa.Object(map[string]*oa.Schema{
+				"language": oa.String(), "effective_language": oa.String(),
+			}, "language", "effective_language"))},
+		},
+		"PUT /api/v1/me/preferences": {
+			Tags: []string{"account"}, OperationID: "updatePreferences", Summary: "Update preferences",
+			Description: "Stores the preferred language and reissues the session token, which carries it.",
+			RequestBody: oa.Body(oa.SchemaOf(preferencesRequest{})),
+			Responses: map[string]*oa.Response{"200": oa.Reply("Saved", oa.Object(map[string]*oa.Schema{
+				"message": oa.String(), "language": oa.String(), "token": oa.String(),
+			}, "message", "language", "token"))},
+		},
+
+		// Entries
+		"GET /api/v1/entries": {
+			Tags: []string{"entries"}, OperationID: "listEntries", Summary: "List entries",
+			Parameters: pageParams,
+			Responses:  map[string]*oa.Response{"200": paged("The current user's entries, newest first", entries)},
+		},
+		"POST /api/v1/entries": {
+			Tags: []string{"entries"}, OperationID: "createEntry", Summary: "Create an entry",
+			Description: "Supports Idempotency-Key.",
+			RequestBody: oa.Body(entry),
+			Responses:   map[string]*oa.Response{"201": oa.Reply("Created", entry)},
+		},
+		"GET /api/v1/entries/:id": {
+			Tags: []string{"entries"}, OperationID: "getEntry", Summary: "Get an entry",
+			Responses: map[string]*oa.Response{"200": oa.Reply("The entry", entry)},
+		},
+		"PUT /api/v1/entries/:id": {
+			Tags: []string{"entries"}, OperationID: "updateEntry", Summary: "Update an entry",
+			RequestBody: oa.Body(entry),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Updated", entry)},
+		},
+		"DELETE /api/v1/entries/:id": {
+			Tags: []string{"entries"}, OperationID: "deleteEntry", Summary: "Delete an entry",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Deleted", message)},
+		},
+		"POST /api/v1/entries/sync": {
+			Tags: []string{"entries"}, OperationID: "syncEntries", Summary: "Delta sync",
+			Description: "Applies offline changes and returns everything changed since the cursor.",
+			RequestBody: oa.Body(oa.SchemaOf(syncRequest{})),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Changes since the cursor and one result per change sent", oa.SchemaOf(syncResponse{}))},
+		},
+		"POST /api/v1/entries/batch": {
+			Tags: []string{"entries"}, OperationID: "batchEntries", Summary: "Batch update, tag or delete entries",
+			Description: "Supports Idempotency-Key.",
+			RequestBody: oa.Body(oa.SchemaOf(batchRequest{})),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("Results per operation", oa.SchemaOf(batchResponse{})),
+				"422": oa.Reply("An atomic batch failed and was rolled back", oa.SchemaOf(batchResponse{})),
+			},
+		},
+		"GET /api/v1/entries/:id/suggested-date": {
+			Tags: []string{"entries"}, OperationID: "suggestEntryDate", Summary: "Suggest a date from photo metadata",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Suggested date", oa.Object(map[string]*oa.Schema{
+				"date":          oa.Nullable(oa.DateTime()),
+				"source":        oa.Enum("created_at", "photo"),
+				"attachment_id": oa.Integer(),
+			}, "date", "source"))},
+		},
+
+		// Import and export
+		"GET /api/v1/entries/export": {
+			Tags: []string{"transfer"}, OperationID: "exportEntries", Summary: "Export entries",
+			Parameters: []oa.Parameter{oa.Query("format", "File format", oa.Enum("json", "csv", "md", "markdown"))},
+			Responses: map[string]*oa.Response{"200": {
+				Description: "The entries as a file download",
+				Content: map[string]oa.MediaType{
+					"application/json; charset=utf-8": {Schema: oa.SchemaOf([]transfer.Record{})},
+					"text/csv; charset=utf-8":         {Schema: oa.String()},
+					"text/markdown; charset=utf-8":    {Schema: oa.String()},
+				},
+			}},
+		},
+		"POST /api/v1/entries/import": {
+			Tags: []string{"transfer"}, OperationID: "importEntries", Summary: "Import entries from JSON, CSV or Markdown",
+			Parameters:  []oa.Parameter{dryRunQuery},
+			RequestBody: oa.Multipart(importForm(map[string]*oa.Schema{"format": oa.Enum("json", "csv", "md", "markdown")})),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("Import report", oa.SchemaOf(importReport{})),
+				"422": oa.Reply("Some rows are invalid; nothing was imported", oa.SchemaOf(importReport{})),
+			},
+		},
+		"GET /api/v1/entries/export/anki": {
+			Tags: []string{"transfer"}, OperationID: "exportAnki", Summary: "Export entries as an Anki deck",
+			Responses: map[string]*oa.Response{"200": oa.Binary("Anki .apkg package", "application/octet-stream")},
+		},
+		"POST /api/v1/entries/import/anki": {
+			Tags: []string{"transfer"}, OperationID: "importAnki", Summary: "Import an Anki deck",
+			RequestBody: oa.Multipart(importForm(nil)),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Import report", oa.SchemaOf(ankiImportReport{}))},
+		},
+
+		// Attachments
+		"GET /api/v1/entries/:id/attachments": {
+			Tags: []string{"attachments"}, OperationID: "listAttachments", Summary: "List an entry's attachments",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Attachments, oldest first", oa.SchemaOf([]models.Attachment{}))},
+		},
+		"POST /api/v1/entries/:id/attachments": {
+			Tags: []string{"attachments"}, OperationID: "uploadAttachment", Summary: "Attach a file to an entry",
+			RequestBody: oa.Multipart(oa.Object(map[string]*oa.Schema{
+				"file":           {Type: oa.Types{"string"}, ContentMediaType: "application/octet-stream"},
+				"sha256":         oa.Describe(oa.String(), "Expected hex SHA-256 of the file"),
+				"strip_metadata": oa.Describe(oa.Enum("true", "false"), "Remove EXIF and GPS data from photos before they are stored; only JPEG and PNG photos are accepted with it"),
+			}, "file")),
+			Responses: map[string]*oa.Response{"201": oa.Reply("Uploaded", attachment)},
+		},
+		"GET /api/v1/attachments/:id": {
+			Tags: []string{"attachments"}, OperationID: "downloadAttachment", Summary: "Download an attachment",
+			Description: "Supports Range and conditional requests.",
+			Responses: map[string]*oa.Response{
+				"200": oa.Binary("The file", "application/octet-stream"),
+				"206": oa.Binary("Part of the file", "application/octet-stream"),
+				"304": {Description: "Not modified"},
+			},
+		},
+		"GET /api/v1/attachments/:id/thumbnails/:size": {
+			Tags: []string{"attachments"}, OperationID: "downloadThumbnail", Summary: "Download a photo thumbnail",
+			Parameters: []oa.Parameter{{Name: "size", In: "path", Required: true, Description: "Longest side in pixels", Schema: oa.Enum("128", "512", "1024")}},
+			Responses: map[string]*oa.Response{
+				"200": oa.Binary("The thumbnail", "image/jpeg", "image/png"),
+				"304": {Description: "Not modified"},
+			},
+		},
+		"DELETE /api/v1/attachments/:id": {
+			Tags: []string{"attachments"}, OperationID: "deleteAttachment", Summary: "Delete an attachment",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Deleted", message)},
+		},
+
+		// Live updates
+		"GET /api/v1/events": {
+			Tags: []string{"events"}, OperationID: "streamEvents", Summary: "Stream entry changes",
+			Description: "Server-Sent Events. Reconnect with Last-Event-ID to resume.",
+			Parameters:  []oa.Parameter{oa.Query("last_event_id", "Alternative to the Last-Event-ID header", oa.String())},
+			Responses: map[string]*oa.Response{"200": {
+				Description: "Event stream",
+				Content:     map[string]oa.MediaType{"text/event-stream": {Schema: oa.String()}},
+			}},
+		},
+
+		// Calendar
+		"POST /api/v1/calendar/token": {
+			Tags: []string{"calendar"}, OperationID: "rotateCalendarToken", Summary: "Create or rotate the private ICS feed URL",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Subscription links", calendarLinks)},
+		},
+		"DELETE /api/v1/calendar/token": {
+			Tags: []string{"calendar"}, OperationID: "revokeCalendarToken", Summary: "Disable the ICS feed",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Disabled", message)},
+		},
+		"POST /api/v1/calendar/import": {
+			Tags: []string{"calendar"}, OperationID: "importCalendar", Summary: "Import events and todos from an .ics file",
+			Parameters:  []oa.Parameter{dryRunQuery},
+			RequestBody: oa.Multipart(importForm(nil)),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("Import report", oa.SchemaOf(importReport{})),
+				"422": oa.Reply("Some events are invalid; nothing was imported", oa.SchemaOf(importReport{})),
+			},
+		},
+		"GET /calendar/:token": {
+			Tags: []string{"calendar"}, OperationID: "calendarFeed", Summary: "Private ICS feed",
+			Description: "Authorised by the secret token in the URL.",
+			Security:    oa.Public(),
+			Parameters: []oa.Parameter{
+				{Name: "token", In: "path", Required: true, Description: "Feed token, optionally with an .ics suffix", Schema: oa.String()},
+				oa.Query("kind", "Publish VTODOs instead of VEVENTs", oa.Enum("event", "todo")),
+			},
+			Responses: map[string]*oa.Response{"200": {
+				Description: "iCalendar feed",
+				Content:     map[string]oa.MediaType{"text/calendar; charset=utf-8": {Schema: oa.String()}},
+			}},
+		},
+
+		// Personal data exports
+		"POST /api/v1/exports": {
+			Tags: []string{"exports"}, OperationID: "requestExport", Summary: "Start a personal data export",
+			Responses: map[string]*oa.Response{"202": oa.Reply("Export queued", exportJob)},
+		},
+		"GET /api/v1/exports/:id": {
+			Tags: []string{"exports"}, OperationID: "getExport", Summary: "Export status",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Status and, once ready, the download link", oa.Object(map[string]*oa.Schema{
+				"job": exportJob, "download_url": oa.String(),
+			}, "job"))},
+		},
+		"GET /api/v1/exports/:id/download": {
+			Tags: []string{"exports"}, OperationID: "downloadExport", Summary: "Download a finished export",
+			Description: "Authorised by the one-off token in the link.",
+			Security:    oa.Public(),
+			Parameters:  []oa.Parameter{{Name: "token", In: "query", Required: true, Schema: oa.String()}},
+			Responses:   map[string]*oa.Response{"200": oa.Binary("ZIP archive", "application/zip")},
+		},
+
+		// Administration
+		"GET /api/v1/admin/users": {
+			Tags: []string{"admin"}, OperationID: "adminListUsers", Summary: "List all users",
+			Parameters: pageParams,
+			Responses:  map[string]*oa.Response{"200": paged("Users by ID", oa.SchemaOf([]models.User{}))},
+		},
+		"PUT /api/v1/admin/users/:id": {
+			Tags: []string{"admin"}, OperationID: "adminUpdateUser", Summary: "Update a user's name, role or password",
+			RequestBody: oa.Body(user),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Updated", message)},
+		},
+		"DELETE /api/v1/admin/users/:id": {
+			Tags: []string{"admin"}, OperationID: "adminDeleteUser", Summary: "Delete a user",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Deleted", message)},
+		},
+		"GET /api/v1/admin/entries": {
+			Tags: []string{"admin"}, OperationID: "adminListEntries", Summary: "List all entries",
+			Parameters: pageParams,
+			Responses:  map[string]*oa.Response{"200": paged("Entries by ID", entries)},
+		},
+		"PUT /api/v1/admin/entries/:id": {
+			Tags: []string{"admin"}, OperationID: "adminUpdateEntry", Summary: "Update any entry",
+			RequestBody: oa.Body(entry),
+			Responses:   map[string]*oa.Response{"200": oa.Reply("Updated", entry)},
+		},
+		"DELETE /api/v1/admin/entries/:id": {
+			Tags: []string{"admin"}, OperationID: "adminDeleteEntry", Summary: "Delete any entry",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Deleted", message)},
+		},
+		"POST /api/v1/admin/entries/batch": {
+			Tags: []string{"admin"}, OperationID: "adminBatchEntries", Summary: "Batch update, tag, move or delete any entries",
+			RequestBody: oa.Body(oa.SchemaOf(batchRequest{})),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("Results per operation", oa.SchemaOf(batchResponse{})),
+				"422": oa.Reply("An atomic batch failed and was rolled back", oa.SchemaOf(batchResponse{})),
+			},
+		},
+		"GET /api/v1/admin/deprecations": {
+			Tags: []string{"admin"}, OperationID: "adminDeprecations", Summary: "Usage of the legacy routes",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Call counts of the deprecated routes", oa.Object(map[string]*oa.Schema{
+				"deprecated_at": oa.DateTime(),
+				"sunset":        oa.DateTime(),
+				"routes":        oa.SchemaOf([]middleware.RouteUsage{}),
+			}, "deprecated_at", "sunset", "routes"))},
+		},
+		"GET /api/v1/admin/audit": {
+			Tags: []string{"admin"}, OperationID: "adminListAuditEvents", Summary: "Search the audit log",
+			Parameters: append(append([]oa.Parameter{}, auditParams...), pageParams...),
+			Responses:  map[string]*oa.Response{"200": paged("Audit events, newest first", oa.SchemaOf([]auditRecord{}))},
+		},
+		"GET /api/v1/admin/audit/export": {
+			Tags: []string{"admin"}, OperationID: "adminExportAuditEvents", Summary: "Download the audit log",
+			Description: "Oldest first, with the chain hashes so the file can be verified offline.",
+			Parameters:  append([]oa.Parameter{oa.Query("format", "File format", oa.Enum("jsonl", "csv"))}, auditParams...),
+			Responses: map[string]*oa.Response{"200": {
+				Description: "Audit events",
+				Content: map[string]oa.MediaType{
+					"application/x-ndjson":    {Schema: oa.String()},
+					"text/csv; charset=utf-8": {Schema: oa.String()},
+				},
+			}},
+		},
+		"GET /api/v1/admin/audit/verify": {
+			Tags: []string{"admin"}, OperationID: "adminVerifyAuditLog", Summary: "Check the audit log's hash chain",
+			Responses: map[string]*oa.Response{"200": oa.Reply("Verification report", oa.SchemaOf(audit.Report{}))},
+		},
+
+		// Probes, registered by routes.SetupProbes
+		"GET /ping": {
+			Tags: []string{"health"}, OperationID: "ping", Summary: "Answer pong",
+			Security:  oa.Public(),
+			Responses: map[string]*oa.Response{"200": oa.Reply("pong", message)},
+		},
+		"GET /health": {
+			Tags: []string{"health"}, OperationID: "health", Summary: "Whether the server takes requests",
+			Description: "Runs no checks; answers 503 draining once shutdown has started.",
+			Security:    oa.Public(),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("ok", probeStatus),
+				"503": oa.Reply("draining", probeStatus),
+			},
+		},
+		"GET /livez": {
+			Tags: []string{"health"}, OperationID: "livez", Summary: "Liveness probe",
+			Security:  oa.Public(),
+			Responses: map[string]*oa.Response{"200": oa.Reply("The process serves HTTP", probeStatus)},
+		},
+		"GET /readyz": {
+			Tags: []string{"health"}, OperationID: "readyz", Summary: "Readiness probe",
+			Description: "Runs every dependency check; answers 503 if one fails or the server is draining.",
+			Security:    oa.Public(),
+			Responses: map[string]*oa.Response{
+				"200": oa.Reply("Every check passed", oa.SchemaOf(health.Report{})),
+				"503": oa.Reply("A check failed", oa.SchemaOf(health.Report{})),
+			},
+		},
+	}
+}
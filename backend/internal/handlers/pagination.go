@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	"Base/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxPageSize = 500
+
+// page is the optional ?limit=&offset= of a list endpoint. Without a limit
+// the whole list is returned, as before pagination was added.
+type page struct {
+	Limit  int `form:"limit" json:"limit" binding:"omitempty,min=1,max=500"`
+	Offset int `form:"offset" json:"offset" binding:"omitempty,min=0"`
+}
+
+// pageFrom reads the page parameters, answering 400 if they are invalid.
+func pageFrom(c *gin.Context) (page, bool) {
+	var p page
+	if err := c.ShouldBindQuery(&p); err != nil {
+		apierror.Abort(c, apierror.FromBinding(err))
+		return p, false
+	}
+	return p, true
+}
+
+// apply limits q to the page, fetching one extra row to tell whether
+// there is a next page.
+func (p page) apply(q *gorm.DB) *gorm.DB {
+	if p.Limit == 0 {
+		return q
+	}
+	return q.Offset(p.Offset).Limit(p.Limit + 1)
+}
+
+// trimPage drops the extra row fetched by apply and, when there are more
+// rows, links to the next page with a Link rel="next" header.
+func trimPage[T any](c *gin.Context, p page, items []T) []T {
+	if p.Limit == 0 || len(items) <= p.Limit {
+		return items
+	}
+	next := *c.Request.URL
+	q := next.Query()
+	q.Set("limit", strconv.Itoa(p.Limit))
+	q.Set("offset", strconv.Itoa(p.Offset+p.Limit))
+	next.RawQuery = q.Encode()
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	return items[:p.Limit]
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"Base/internal/apierror"
+	"Base/internal/i18n"
+	"Base/internal/middleware"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tr translates a message into the language of the current request.
+func tr(c *gin.Context, key string, args ...string) string {
+	return i18n.T(middleware.GetLang(c), key, args...)
+}
+
+// GetMe returns the current user's profile.
+func GetMe(c *gin.Context) {
+	var user models.User
+	if err := db(c).First(&user, c.GetUint("userID")).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"id":       user.ID,
+		"username": user.Name,
+		"email":    user.Email,
+		"role":     user.Role,
+		"language": user.Language,
+	})
+}
+
+// GetPreferences returns the current user's stored preferences and the
+// language the API is answering in.
+func GetPreferences(c *gin.Context) {
+	var user models.User
+	if err := db(c).First(&user, c.GetUint("userID")).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"language": user.Language, "effective_language": middleware.GetLang(c)})
+}
+
+type preferencesRequest struct {
+	Language string `json:"language" binding:"omitempty,oneof=en ru"`
+}
+
+// UpdatePreferences stores the current user's preferred language. An empty
+// language goes back to following Accept-Language. The session token is
+// reissued because it carries the preference.
+func UpdatePreferences(c *gin.Context) {
+	var input preferencesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Abort(c, apierror.FromBinding(err))
+		return
+	}
+
+	var user models.User
+	if err := db(c).First(&user, c.GetUint("userID")).Error; err != nil {
+		apierror.Abort(c, apierror.UserNotFound)
+		return
+	}
+	if err := db(c).Model(&user).Update("language", input.Language).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	token, err := Tokens.CreateToken(user.ID, user.Name, user.Role, input.Language)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.InternalError).Wrap(err))
+		return
+	}
+	middleware.SetCookie(c, token)
+
+	lang, ok := i18n.Parse(input.Language)
+	if !ok {
+		lang = i18n.Match(c.GetHeader("Accept-Language"))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  i18n.T(lang, "message.preferences_saved"),
+		"language": input.Language,
+		"token":    token,
+	})
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Base/internal/apierror"
+
+	"Base/internal/entrysync"
+	"Base/internal/events"
+	"Base/internal/i18n"
+	"Base/internal/middleware"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxSyncChanges = 500
+	// syncOverlap re-sends changes from just before the cursor so that rows
+	// whose transaction committed after the previous sync read aren't missed.
+	// Clients apply changes by ID, so seeing one twice is harmless.
+	syncOverlap = 2 * time.Second
+)
+
+type syncRequest struct {
+	Cursor  string             `json:"cursor"`
+	Changes []entrysync.Change `json:"changes"`
+}
+
+type syncEntry struct {
+	models.Entry
+	Deleted bool `json:"deleted"`
+}
+
+type syncResponse struct {
+	Cursor  string              `json:"cursor"`
+	Changes []syncEntry         `json:"changes"`
+	Results []entrysync.Outcome `json:"results"`
+}
+
+// entryEvent is a change to publish once its transaction has committed.
+type entryEvent struct {
+	kind  string
+	entry models.Entry
+	purge bool // delete the entry's reviews and attachments
+}
+
+// publishEntryEvents runs the after-commit side effects of entry changes.
+func publishEntryEvents(c *gin.Context, evs []entryEvent) {
+	for _, ev := range evs {
+		if ev.purge {
+			if err := purgeEntryData(c.Request.Context(), "entry_id = ?", ev.entry.ID); err != nil {
+				slog.ErrorContext(c.Request.Context(), "failed to clean up entry data", "entry_id", ev.entry.ID, "error", err)
+			}
+		}
+		publishEntry(c.Request.Context(), ev.kind, &ev.entry)
+	}
+}
+
+// SyncEntries is the delta sync endpoint for offline clients. The client
+// sends the cursor from its previous sync and the changes it made since;
+// they are applied in order in one transaction, with one result per change.
+// The response lists every entry changed since the cursor, soft-deleted
+// ones included as tombstones, and the cursor to send next time.
+func SyncEntries(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req syncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.FromBinding(err))
+		return
+	}
+	since, err := entrysync.DecodeCursor(req.Cursor)
+	if err != nil {
+		apierror.Abort(c, apierror.InvalidCursor)
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		apierror.Abort(c, apierror.New(apierror.TooManyChanges).Localized("detail.too_many_changes", "max", strconv.Itoa(maxSyncChanges)))
+		return
+	}
+
+	results := make([]entrysync.Outcome, 0, len(req.Changes))
+	var published []entryEvent
+	err = db(c).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, change := range req.Changes {
+			out, ev, err := applySyncChange(tx, middleware.GetLang(c), userID, change, now)
+			if err != nil {
+				return err
+			}
+			results = append(results, out)
+			if ev != nil {
+				published = append(published, *ev)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	publishEntryEvents(c, published)
+
+	cursor := time.Now()
+	var entries []models.Entry
+	q := db(c).Where("user_id = ?", userID)
+	if !since.IsZero() {
+		from := since.Add(-syncOverlap)
+		q = db(c).Unscoped().Where("user_id = ? AND (updated_at > ? OR deleted_at > ?)", userID, from, from)
+	}
+	if err := q.Order("updated_at asc").Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	resp := syncResponse{
+		Cursor:  entrysync.EncodeCursor(cursor),
+		Changes: make([]syncEntry, len(entries)),
+		Results: results,
+	}
+	for i, e := range entries {
+		resp.Changes[i] = syncEntry{Entry: e, Deleted: e.DeletedAt.Valid}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// applySyncChange applies one client change inside the sync transaction.
+// Problems with the change itself are reported in the outcome, in lang;
+// only database errors are returned.
+func applySyncChange(tx *gorm.DB, lang i18n.Lang, userID uint, change entrysync.Change, now time.Time) (entrysync.Outcome, *entryEvent, error) {
+	rejected := func(err error) (entrysync.Outcome, *entryEvent, error) {
+		out := entrysync.Outcome{ClientID: change.ClientID, ID: change.ID, Status: entrysync.Rejected, Error: apierror.Item(lang, err)}
+		return out, nil, nil
+	}
+
+	// A session, so the query can be run again after a lost insert race
+	locked := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Session(&gorm.Session{})
+	var existing models.Entry
+	var err error
+	switch {
+	case change.ID != 0:
+		err = locked.Where("id = ? AND user_id = ?", change.ID, userID).First(&existing).Error
+	case change.ClientID != "":
+		err = locked.Where("client_id = ? AND user_id = ?", change.ClientID, userID).First(&existing).Error
+	default:
+		return rejected(apierror.New(apierror.MissingFields).Localized("detail.sync_id_required"))
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if change.ID != 0 {
+			return rejected(apierror.EntryNotFound)
+		}
+		if change.Deleted {
+			// Created and deleted offline: nothing to do.
+			return entrysync.Outcome{ClientID: change.ClientID, Status: entrysync.Unchanged}, nil, nil
+		}
+
+		entry := models.Entry{UserID: userID, ClientID: change.ClientID}
+		if err := entrysync.Apply(&entry, change.Fields); err != nil {
+			return rejected(err)
+		}
+		if err := validateEntry(&entry); err != nil {
+			return rejected(err)
+		}
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if created.Error != nil {
+			return entrysync.Outcome{}, nil, created.Error
+		}
+		if created.RowsAffected == 1 {
+			out := entrysync.Outcome{ClientID: change.ClientID, ID: entry.ID, Status: entrysync.Applied, UpdatedAt: &entry.UpdatedAt}
+			return out, &entryEvent{kind: events.EntryCreated, entry: entry}, nil
+		}
+		// A concurrent sync created the entry first (client IDs are unique
+		// per user), so the change is merged into that one instead
+		err = locked.Where("client_id = ? AND user_id = ?", change.ClientID, userID).First(&existing).Error
+	}
+	if err != nil {
+		return entrysync.Outcome{}, nil, err
+	}
+
+	res, err := entrysync.Merge(existing, change, now)
+	if err != nil {
+		return rejected(err)
+	}
+
+	var ev *entryEvent
+	switch {
+	case res.Delete:
+		if err := tx.Delete(&existing).Error; err != nil {
+			return entrysync.Outcome{}, nil, err
+		}
+		ev = &entryEvent{kind: events.EntryDeleted, entry: existing, purge: true}
+	case res.Save:
+		if err := validateEntry(&res.Entry); err != nil {
+			return rejected(err)
+		}
+		if err := tx.Save(&res.Entry).Error; err != nil {
+			return entrysync.Outcome{}, nil, err
+		}
+		res.Outcome.UpdatedAt = &res.Entry.UpdatedAt
+		ev = &entryEvent{kind: events.EntryUpdated, entry: res.Entry}
+	}
+	return res.Outcome, ev, nil
+}
+
+// validateEntry applies the rules CreateEntry enforces to a changed entry.
+func validateEntry(entry *models.Entry) error {
+	if entry.Situation == "" || entry.Text == "" || entry.Colour == "" || entry.Icon == "" {
+		return apierror.MissingFields
+	}
+	if err := validateReminder(entry); err != nil {
+		return reminderError(err)
+	}
+	return nil
+}
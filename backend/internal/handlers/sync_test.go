@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"Base/internal/entrysync"
+	"Base/internal/i18n"
+	"Base/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestSyncMergesIntoAConcurrentlyCreatedEntry(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Another sync of the same offline entry commits between this one's
+	// lookup and its insert
+	var first models.Entry
+	raced := false
+	db.Callback().Create().Before("gorm:create").Register("test:race", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.Entry); !ok || raced {
+			return
+		}
+		raced = true
+		first = models.Entry{UserID: 1, ClientID: "phone-1", Situation: "s", Text: "first", Icon: "star", Colour: "purple"}
+		if err := tx.Session(&gorm.Session{NewDB: true}).Create(&first).Error; err != nil {
+			t.Error(err)
+		}
+	})
+
+	change := entrysync.Change{
+		ClientID:  "phone-1",
+		UpdatedAt: time.Now().Add(time.Minute),
+		Fields: map[string]json.RawMessage{
+			"situation": json.RawMessage(`"s"`), "text": json.RawMessage(`"second"`),
+			"icon": json.RawMessage(`"star"`), "colour": json.RawMessage(`"purple"`),
+		},
+	}
+	var out entrysync.Outcome
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		out, _, err = applySyncChange(tx, i18n.Default, 1, change, time.Now())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raced {
+		t.Fatal("the competing insert never ran")
+	}
+
+	var n int64
+	db.Model(&models.Entry{}).Where("user_id = ? AND client_id = ?", 1, "phone-1").Count(&n)
+	if n != 1 || out.ID != first.ID || out.Status == entrysync.Rejected {
+		t.Errorf("%d entries for the client ID, outcome %+v; want the change merged into entry %d", n, out, first.ID)
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"Base/internal/apierror"
+
+	"Base/internal/imaging"
+	"Base/internal/models"
+	"Base/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// thumbnailSizes are the longest-side lengths generated for every photo.
+var thumbnailSizes = []int{128, 512, 1024}
+
+// processableImages are the types the pure-Go decoders can read.
+var processableImages = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// strippableImages are the photo types imaging.StripMetadata can clean.
+var strippableImages = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// stripPhoto removes EXIF (including GPS) and other metadata from a JPEG or
+// PNG, keeping its orientation, and returns the capture date it held.
+func stripPhoto(data []byte) ([]byte, *time.Time) {
+	meta, err := imaging.ReadExif(data)
+	if err != nil {
+		meta = &imaging.Exif{Orientation: 1}
+	}
+	return imaging.StripMetadata(data, meta.Orientation), meta.CapturedAt
+}
+
+// enqueueImageProcessing schedules thumbnail generation for an image
+// attachment. Other attachments are marked ready straight away.
+func enqueueImageProcessing(ctx context.Context, attachment *models.Attachment) {
+	db := DB.WithContext(ctx)
+	if !processableImages[attachment.ContentType] {
+		attachment.Status = models.AttachmentReady
+		db.Model(attachment).Update("status", attachment.Status)
+		return
+	}
+
+	attachment.Status = models.AttachmentProcessing
+	db.Model(attachment).Update("status", attachment.Status)
+
+	id := attachment.ID
+	if err := Jobs.EnqueueContext(ctx, fmt.Sprintf("thumbnails-%d", id), func(ctx context.Context) error {
+		return processImage(ctx, id)
+	}); err != nil {
+		attachment.Status = models.AttachmentFailed
+		db.Model(attachment).Update("status", attachment.Status)
+	}
+}
+
+// processImage reads the EXIF data of a photo and stores upright thumbnails
+// in every configured size.
+func processImage(ctx context.Context, attachmentID uint) (err error) {
+	db := DB.WithContext(context.WithoutCancel(ctx))
+	var attachment models.Attachment
+	if err := db.First(&attachment, attachmentID).Error; err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			db.Model(&attachment).Update("status", models.AttachmentFailed)
+		}
+	}()
+
+	data, err := readBlob(ctx, attachment.StorageKey, Attachments.MaxBytes)
+	if err != nil {
+		return err
+	}
+
+	meta, err := imaging.ReadExif(data)
+	if err != nil {
+		meta = &imaging.Exif{Orientation: 1} // unreadable EXIF shouldn't block thumbnails
+	}
+
+	updates := map[string]interface{}{}
+	// A stripped photo's capture date was saved on upload
+	if meta.CapturedAt != nil {
+		updates["captured_at"] = meta.CapturedAt
+	}
+
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	w, h := imaging.OrientedSize(img.Bounds().Dx(), img.Bounds().Dy(), meta.Orientation)
+	updates["width"], updates["height"] = w, h
+
+	for _, size := range thumbnailSizes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		thumb := imaging.Thumbnail(img, size, meta.Orientation)
+		encoded, contentType, err := imaging.Encode(thumb)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%s-thumb-%d", attachment.StorageKey, size)
+		if err := Blobs.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+			return fmt.Errorf("store thumbnail: %w", err)
+		}
+		row := models.Thumbnail{
+			AttachmentID: attachment.ID,
+			Size:         size,
+			Width:        thumb.Bounds().Dx(),
+			Height:       thumb.Bounds().Dy(),
+			ContentType:  contentType,
+			StorageKey:   key,
+		}
+		if err := db.Where("attachment_id = ? AND size = ?", attachment.ID, size).
+			Assign(row).FirstOrCreate(&row).Error; err != nil {
+			return err
+		}
+	}
+
+	updates["status"] = models.AttachmentReady
+	return db.Model(&attachment).Updates(updates).Error
+}
+
+func readBlob(ctx context.Context, key string, limit int64) ([]byte, error) {
+	blob, err := Blobs.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	return io.ReadAll(io.LimitReader(blob, limit))
+}
+
+// DownloadThumbnail serves one of the generated thumbnails of a photo.
+func DownloadThumbnail(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var attachment models.Attachment
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&attachment).Error; err != nil {
+		apierror.Abort(c, apierror.AttachmentNotFound)
+		return
+	}
+	var thumb models.Thumbnail
+	if err := db(c).Where("attachment_id = ? AND size = ?", attachment.ID, c.Param("size")).First(&thumb).Error; err != nil {
+		if attachment.Status == models.AttachmentProcessing {
+			apierror.Abort(c, apierror.ThumbnailPending)
+			return
+		}
+		apierror.Abort(c, apierror.ThumbnailNotFound)
+		return
+	}
+
+	blob, err := Blobs.Open(c.Request.Context(), thumb.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		apierror.Abort(c, apierror.ThumbnailNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.StorageError).Wrap(err))
+		return
+	}
+	defer blob.Close()
+
+	c.Header("Content-Type", thumb.ContentType)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, "", blob.ModTime(), blob)
+}
+
+// SuggestEntryDate proposes a date for an entry from the earliest capture
+// date among its photos, falling back to when the entry was created.
+func SuggestEntryDate(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var entry models.Entry
+	if err := db(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&entry).Error; err != nil {
+		apierror.Abort(c, apierror.EntryNotFound)
+		return
+	}
+
+	var attachment models.Attachment
+	err := db(c).Where("entry_id = ? AND captured_at IS NOT NULL", entry.ID).Order("captured_at asc").First(&attachment).Error
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"date": entry.CreatedAt, "source": "created_at"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": attachment.CapturedAt, "source": "photo", "attachment_id": attachment.ID})
+}
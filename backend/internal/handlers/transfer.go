@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Base/internal/apierror"
+
+	"Base/internal/middleware"
+	"Base/internal/models"
+	"Base/internal/transfer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxImportSize = 10 << 20 // 10 MiB
+
+// ExportEntries downloads all of the current user's entries as JSON, CSV or Markdown.
+func ExportEntries(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	format, err := transfer.ParseFormat(c.DefaultQuery("format", "json"))
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.UnsupportedFormat).Detailf("%v", err))
+		return
+	}
+
+	var entries []models.Entry
+	if err := db(c).Where("user_id = ?", userID).Order("created_at asc").Find(&entries).Error; err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	filename := fmt.Sprintf("entries-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", format.ContentType())
+	c.Status(http.StatusOK)
+	if err := transfer.Encode(c.Writer, format, transfer.FromEntries(entries)); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+type importRow struct {
+	Line   int                   `json:"line"`
+	Status string                `json:"status"` // ok, invalid or duplicate
+	Record transfer.Record       `json:"record"`
+	Errors []*apierror.ItemError `json:"errors,omitempty"`
+}
+
+// rowErrors reports a row's problems in the client's language.
+func rowErrors(c *gin.Context, row transfer.Row) []*apierror.ItemError {
+	var out []*apierror.ItemError
+	for _, err := range row.Errors {
+		out = append(out, apierror.Item(middleware.GetLang(c), err))
+	}
+	return out
+}
+
+type importReport struct {
+	DryRun     bool        `json:"dry_run"`
+	Format     string      `json:"format"`
+	Total      int         `json:"total"`
+	Valid      int         `json:"valid"`
+	Invalid    int         `json:"invalid"`
+	Duplicates int         `json:"duplicates"`
+	Imported   int         `json:"imported"`
+	Rows       []importRow `json:"rows"`
+}
+
+// ImportEntries bulk-imports entries from an uploaded JSON, CSV or Markdown file.
+// With dry_run=true nothing is written and the per-row report is returned as a
+// preview. Otherwise all valid, non-duplicate rows are inserted in a single
+// transaction, and nothing is inserted if any row is invalid.
+func ImportEntries(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.FileRequired).Localized("detail.file_required_max", "max", "10 MiB"))
+		return
+	}
+
+	var format transfer.Format
+	if f := c.PostForm("format"); f != "" {
+		format, err = transfer.ParseFormat(f)
+	} else {
+		format, err = transfer.FormatFromFilename(fileHeader.Filename)
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.UnsupportedFormat).Detailf("%v", err))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dry_run", c.Query("dry_run")))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.Abort(c, apierror.FileUnreadable)
+		return
+	}
+	defer file.Close()
+
+	rows, err := transfer.Decode(file, format)
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.InvalidFile).Detailf("%v", err))
+		return
+	}
+
+	importRows(c, userID, string(format), rows, dryRun)
+}
+
+// importRows validates decoded rows, detects duplicates and, unless this is
+// a dry run, inserts the new entries in a single transaction.
+func importRows(c *gin.Context, userID uint, format string, rows []transfer.Row, dryRun bool) {
+	report := importReport{DryRun: dryRun, Format: format, Total: len(rows), Rows: make([]importRow, 0, len(rows))}
+	var toCreate []models.Entry
+	// Duplicates are detected in the same transaction as the insert, so two
+	// imports of the same file can't both see the entries as new.
+	err := db(c).Transaction(func(tx *gorm.DB) error {
+		seen, err := existingEntryKeys(tx, userID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			row.Normalize()
+			out := importRow{Line: row.Line, Record: row.Record, Errors: rowErrors(c, row)}
+			key := entryKey(row.Record.Situation, row.Record.Text)
+			switch {
+			case !row.Valid():
+				out.Status = "invalid"
+				report.Invalid++
+			case seen[key]:
+				out.Status = "duplicate"
+				report.Duplicates++
+			default:
+				out.Status = "ok"
+				report.Valid++
+				seen[key] = true
+				entry := models.Entry{
+					Situation:  row.Record.Situation,
+					Text:       row.Record.Text,
+					Icon:       row.Record.Icon,
+					Colour:     row.Record.Colour,
+					Tags:       row.Record.Tags,
+					RemindAt:   row.Record.RemindAt,
+					Recurrence: row.Record.Recurrence,
+					UserID:     userID,
+				}
+				if row.Record.CreatedAt != nil {
+					entry.CreatedAt = *row.Record.CreatedAt
+				}
+				toCreate = append(toCreate, entry)
+			}
+			report.Rows = append(report.Rows, out)
+		}
+		if dryRun || report.Invalid > 0 || len(toCreate) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&toCreate, 100).Error
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if report.Invalid > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	report.Imported = len(toCreate)
+	if report.Imported > 0 {
+		publishEntriesChanged(c.Request.Context(), userID)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// existingEntryKeys returns the duplicate-detection keys of the user's current
+// entries. It locks the user's row first, so concurrent imports for the same
+// user take turns until tx ends.
+func existingEntryKeys(tx *gorm.DB, userID uint) (map[string]bool, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", userID).Find(&models.User{}).Error; err != nil {
+		return nil, err
+	}
+	var existing []models.Entry
+	if err := tx.Select("situation", "text").Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		keys[entryKey(e.Situation, e.Text)] = true
+	}
+	return keys, nil
+}
+
+// entryKey identifies an entry by content, ignoring case and surrounding whitespace.
+func entryKey(situation, text string) string {
+	return strings.ToLower(strings.TrimSpace(situation)) + "\x00" + strings.ToLower(strings.TrimSpace(text))
+}
@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"Base/internal/migrate"
+	"Base/internal/storage"
+)
+
+// Database pings the connection pool.
+func Database(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// Migrations fails while the schema is behind the code.
+func Migrations(m *migrate.Migrator) Check {
+	return func(ctx context.Context) error {
+		pending, err := m.Unapplied(ctx)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%d migrations pending", pending)
+		}
+		return nil
+	}
+}
+
+// Workers fails when no background worker is running. Busy workers count:
+// long jobs such as exports must not take the instance out of rotation.
+func Workers(running func() int) Check {
+	return func(context.Context) error {
+		if running() == 0 {
+			return fmt.Errorf("no background workers running")
+		}
+		return nil
+	}
+}
+
+// Blobs checks that the attachment store is reachable.
+func Blobs(store storage.BlobStore) Check {
+	return store.Ping
+}
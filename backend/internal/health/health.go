@@ -0,0 +1,149 @@
+// Package health serves the liveness and readiness probes.
+//
+// /livez answers 200 while the process can serve HTTP at all. /readyz runs
+// every dependency check and answers 503 if one fails or the server is
+// draining, with a per-check breakdown:
+//
+//	{"status":"fail","checks":{"database":{"status":"ok","latency_ms":1.2},
+//	 "blobs":{"status":"fail","latency_ms":2001,"error":"context deadline exceeded"}}}
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// Check reports a dependency as healthy by returning nil.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the body of /readyz.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Probes holds the readiness checks and whether the server is draining.
+type Probes struct {
+	timeout  time.Duration
+	mu       sync.RWMutex
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// New returns probes whose checks each get timeout to finish.
+func New(timeout time.Duration) *Probes {
+	return &Probes{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Add registers a readiness check under name.
+func (p *Probes) Add(name string, check Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checks[name] = check
+}
+
+// Drain marks the server as shutting down; /readyz and /health answer 503
+// from then on.
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+}
+
+func (p *Probes) Draining() bool {
+	return p.draining.Load()
+}
+
+// Run runs every check concurrently.
+func (p *Probes) Run(ctx context.Context) Report {
+	p.mu.RLock()
+	checks := make(map[string]Check, len(p.checks))
+	for name, check := range p.checks {
+		checks[name] = check
+	}
+	p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := run(ctx, check)
+			res := Result{Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status, res.Error = StatusFail, err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	if p.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// run gives up when ctx expires even if check ignores it.
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Live answers /livez.
+func (p *Probes) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Ready answers /readyz.
+func (p *Probes) Ready(c *gin.Context) {
+	report := p.Run(c.Request.Context())
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
+
+// Health answers the older /health endpoint: "ok", or 503 "draining" once
+// shutdown has started. It runs no checks.
+func (p *Probes) Health(c *gin.Context) {
+	if p.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": StatusDraining})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyReportsEveryCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := New(50 * time.Millisecond)
+	p.Add("database", func(context.Context) error { return nil })
+	p.Add("jobs", Workers(func() int { return 0 }))
+	r := gin.New()
+	r.GET("/livez", p.Live)
+	r.GET("/readyz", p.Ready)
+	r.GET("/health", p.Health)
+
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+
+	code, report := get("/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != StatusFail {
+		t.Fatalf("no workers: %d %+v", code, report)
+	}
+	if db := report.Checks["database"]; db.Status != StatusOK || db.Error != "" {
+		t.Errorf("database = %+v", db)
+	}
+	if jobs := report.Checks["jobs"]; jobs.Status != StatusFail || jobs.Error != "no background workers running" {
+		t.Errorf("jobs = %+v", jobs)
+	}
+
+	p.Add("jobs", func(context.Context) error { return nil })
+	if code, report := get("/readyz"); code != http.StatusOK || report.Status != StatusOK || len(report.Checks) != 2 {
+		t.Errorf("healthy: %d %+v", code, report)
+	}
+
+	p.Drain()
+	if code, report := get("/readyz"); code != http.StatusServiceUnavailable || report.Status != StatusDraining {
+		t.Errorf("draining /readyz: %d %+v", code, report)
+	}
+	if code, report := get("/health"); code != http.StatusServiceUnavailable || report.Status != StatusDraining {
+		t.Errorf("draining /health: %d %+v", code, report)
+	}
+	if code, _ := get("/livez"); code != http.StatusOK {
+		t.Errorf("draining /livez: %d, want 200", code)
+	}
+}
+
+func TestSlowCheckTimesOut(t *testing.T) {
+	p := New(20 * time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	p.Add("blobs", func(context.Context) error { <-block; return errors.New("unreachable") })
+
+	start := time.Now()
+	report := p.Run(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatal("Run waited for a check that ignores its context")
+	}
+	if b := report.Checks["blobs"]; b.Status != StatusFail || b.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("blobs = %+v", b)
+	}
+}
@@ -0,0 +1,129 @@
+// Package i18n holds the translated strings shown to users: API error
+// titles and details (keyed by their stable codes), validation messages,
+// confirmations and the text of generated documents such as calendar
+// alarms and data export READMEs.
+//
+// Messages use {name} placeholders, filled from key/value pairs:
+//
+//	i18n.T(i18n.RU, "validation.min", "field", "password", "param", "6")
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang is a supported language.
+type Lang string
+
+const (
+	EN Lang = "en"
+	RU Lang = "ru"
+
+	Default = EN
+)
+
+//go:embed locales/*.json
+var files embed.FS
+
+var catalogs = map[Lang]map[string]string{}
+
+func init() {
+	for _, lang := range Supported() {
+		data, err := files.ReadFile(fmt.Sprintf("locales/%s.json", lang))
+		if err != nil {
+			panic(err)
+		}
+		msgs := map[string]string{}
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			panic(fmt.Sprintf("i18n: locales/%s.json: %v", lang, err))
+		}
+		catalogs[lang] = msgs
+	}
+}
+
+// Supported lists the available languages, default first.
+func Supported() []Lang {
+	return []Lang{EN, RU}
+}
+
+// Parse returns the supported language for a tag such as "ru" or "ru-RU".
+func Parse(tag string) (Lang, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, l := range Supported() {
+		if tag == string(l) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Match picks the best supported language for an Accept-Language header,
+// falling back to Default.
+func Match(acceptLanguage string) Lang {
+	type choice struct {
+		lang Lang
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		lang, ok := Parse(fields[0])
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			if v, found := strings.CutPrefix(strings.TrimSpace(p), "q="); found {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	if len(choices) == 0 {
+		return Default
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang
+}
+
+// Has reports whether lang has its own message for key.
+func Has(lang Lang, key string) bool {
+	_, ok := catalogs[lang][key]
+	return ok
+}
+
+// T returns the message for key in lang, falling back to the default
+// language and then to the key itself. args are placeholder name/value pairs.
+func T(lang Lang, key string, args ...string) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		if msg, ok = catalogs[Default][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+args[i]+"}", args[i+1])
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Translator returns T bound to lang, for templates and helpers.
+func Translator(lang Lang) func(key string, args ...string) string {
+	return func(key string, args ...string) string { return T(lang, key, args...) }
+}
@@ -0,0 +1,63 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func TestCatalogsMatch(t *testing.T) {
+	for key, en := range catalogs[EN] {
+		for _, lang := range Supported()[1:] {
+			msg, ok := catalogs[lang][key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			want, got := placeholder.FindAllString(en, -1), placeholder.FindAllString(msg, -1)
+			sort.Strings(want)
+			sort.Strings(got)
+			if strings.Join(want, " ") != strings.Join(got, " ") {
+				t.Errorf("%s %q: placeholders %v, want %v", lang, key, got, want)
+			}
+		}
+	}
+	for _, lang := range Supported()[1:] {
+		for key := range catalogs[lang] {
+			if !Has(EN, key) {
+				t.Errorf("%s has %q, which is not in the English catalog", lang, key)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := map[string]Lang{
+		"":                        EN,
+		"ru-RU,ru;q=0.9,en;q=0.8": RU,
+		"de-DE,en;q=0.5,ru;q=0.7": RU,
+		"fr, de":                  EN,
+		"en-GB;q=0.9, ru;q=0":     EN,
+		"RU":                      RU,
+	}
+	for header, want := range cases {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(RU, "validation.min", "field", "password", "param", "6"); got != "Поле password: минимальная длина — 6" {
+		t.Errorf("got %q", got)
+	}
+	if got := T("de", "error.entry_not_found"); got != "Entry not found" {
+		t.Errorf("unsupported languages should fall back to English, got %q", got)
+	}
+	if got := T(EN, "no.such.key"); got != "no.such.key" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+// Package ical writes and reads the subset of iCalendar (RFC 5545) needed to
+// publish reminders as a subscribable feed and to import events from .ics files.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Component kinds.
+const (
+	KindEvent = "VEVENT"
+	KindTodo  = "VTODO"
+)
+
+// Event is a VEVENT or VTODO. Start is DTSTART for events and DUE for
+// todos; recurring or alarmed todos get a DTSTART too.
+type Event struct {
+	Kind         string
+	UID          string
+	Summary      string
+	Description  string
+	Start        time.Time
+	AllDay       bool
+	RRule        string
+	Categories   []string
+	Created      time.Time
+	LastModified time.Time
+	Alarm        bool
+	AlarmText    string // VALARM description; defaults to Summary
+}
+
+var validFreq = map[string]bool{
+	"SECONDLY": true, "MINUTELY": true, "HOURLY": true, "DAILY": true,
+	"WEEKLY": true, "MONTHLY": true, "YEARLY": true,
+}
+
+// ValidateRRule performs a light syntactic check of a recurrence rule:
+// KEY=VALUE parts separated by semicolons with a valid FREQ. Control
+// characters and colons are refused, since the rule is written into
+// feeds as is and could otherwise start new content lines.
+func ValidateRRule(rule string) error {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil
+	}
+	if i := strings.IndexFunc(rule, func(r rune) bool { return r == ':' || unicode.IsControl(r) }); i >= 0 {
+		return fmt.Errorf("recurrence rule contains %q", rule[i:i+1])
+	}
+	hasFreq := false
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" || value == "" {
+			return fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		if strings.EqualFold(key, "FREQ") {
+			if !validFreq[strings.ToUpper(value)] {
+				return fmt.Errorf("invalid recurrence frequency %q", value)
+			}
+			hasFreq = true
+		}
+	}
+	if !hasFreq {
+		return fmt.Errorf("recurrence rule %q has no FREQ", rule)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	start := time.Date(2024, 2, 29, 8, 30, 0, 0, time.UTC)
+	cal := &Calendar{Name: "Reminders", Events: []Event{{
+		UID:         "entry-1@reminder-card",
+		Summary:     "Call mum; say hi, really",
+		Description: strings.Repeat("Long line with ünïcödé ", 10) + "\nsecond line",
+		Start:       start,
+		RRule:       "FREQ=WEEKLY;BYDAY=TH",
+		Categories:  []string{"family", "calls"},
+		Alarm:       true,
+	}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > maxLineLen {
+			t.Fatalf("line longer than %d octets: %q", maxLineLen, line)
+		}
+	}
+
+	events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	got, want := events[0], cal.Events[0]
+	if got.Summary != want.Summary || got.Description != want.Description || got.RRule != want.RRule {
+		t.Errorf("got %+v", got)
+	}
+	if !got.Start.Equal(start) {
+		t.Errorf("start = %v, want %v", got.Start, start)
+	}
+	if len(got.Categories) != 2 || got.Categories[1] != "calls" {
+		t.Errorf("categories = %v", got.Categories)
+	}
+}
+
+func TestReadForeignCalendar(t *testing.T) {
+	src := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Moscow\r\nEND:VTIMEZONE\r\n" +
+		"BEGIN:VEVENT\r\nUID:a\r\nDTSTART;TZID=Europe/Moscow:20240105T100000\r\n" +
+		"SUMMARY:Stand\r\n -up\r\nRRULE:FREQ=DAILY;COUNT=5\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:ignored\r\nTRIGGER:-PT5M\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nUID:b\r\nDUE;VALUE=DATE:20240110\r\nSUMMARY:Taxes\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := Read(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Summary != "Stand-up" || events[0].Description != "" {
+		t.Errorf("alarm properties leaked into event or unfolding failed: %+v", events[0])
+	}
+	if want := time.Date(2024, 1, 5, 7, 0, 0, 0, time.UTC); !events[0].Start.Equal(want) {
+		t.Errorf("start = %v, want %v", events[0].Start, want)
+	}
+	if events[1].Kind != KindTodo || !events[1].AllDay || events[1].Start.Day() != 10 {
+		t.Errorf("todo = %+v", events[1])
+	}
+}
+
+func TestValidateRRule(t *testing.T) {
+	for rule, ok := range map[string]bool{
+		"":                       true,
+		"FREQ=MONTHLY;BYDAY=1MO": true,
+		"RRULE:FREQ=YEARLY":      true,
+		"BYDAY=MO":               false,
+		"FREQ=FORTNIGHTLY":       false,
+		"FREQ=DAILY;COUNT":       false,
+		"COUNT=1\r\nBEGIN:VEVENT\r\nSUMMARY:x;FREQ=DAILY": false,
+		"FREQ=DAILY;UNTIL=20240101T000000Z\x00":           false,
+		"FREQ=DAILY;X-NOTE=a:b":                           false,
+	} {
+		if err := ValidateRRule(rule); (err == nil) != ok {
+			t.Errorf("ValidateRRule(%q) = %v", rule, err)
+		}
+	}
+}
+
+func TestWriteRefusesInjectedRules(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	cal := &Calendar{Events: []Event{
+		{UID: "a", Summary: "a", Start: start, RRule: "COUNT=1\r\nBEGIN:VEVENT\r\nUID:evil;FREQ=DAILY"},
+		{Kind: KindTodo, UID: "b", Summary: "b", Start: start, RRule: "FREQ=WEEKLY", Alarm: true},
+		{Kind: KindTodo, UID: "c", Summary: "c", Start: start},
+	}}
+	var buf bytes.Buffer
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "evil") || strings.Count(out, "BEGIN:VEVENT") != 1 {
+		t.Errorf("injected rule reached the feed:\n%s", out)
+	}
+
+	todos := strings.Split(out, "BEGIN:VTODO")
+	if recurring := todos[1]; !strings.Contains(recurring, "DTSTART:20240301T090000Z") || !strings.Contains(recurring, "DUE:20240301T090000Z") {
+		t.Errorf("recurring todo without DTSTART:\n%s", recurring)
+	}
+	if plain := todos[2]; strings.Contains(plain, "DTSTART") {
+		t.Errorf("plain todo has DTSTART:\n%s", plain)
+	}
+
+	events, err := Read(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0].RRule != "" || !events[1].Start.Equal(start) || events[1].RRule != "FREQ=WEEKLY" {
+		t.Errorf("read back %+v", events)
+	}
+}
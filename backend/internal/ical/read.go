@@ -0,0 +1,226 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Read parses every VEVENT and VTODO in an iCalendar stream. Events without
+// a start (DTSTART, or DUE for todos) are returned with a zero Start.
+func Read(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events []Event
+		stack  []string
+		props  []property
+	)
+	for n, raw := range lines {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		p, err := parseLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		switch p.name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(p.value))
+			if isItem(p.value) {
+				props = props[:0]
+			}
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1] != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", n+1, p.value)
+			}
+			stack = stack[:len(stack)-1]
+			if isItem(p.value) {
+				ev, err := toEvent(strings.ToUpper(p.value), props)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n+1, err)
+				}
+				events = append(events, ev)
+			}
+		default:
+			// Only properties directly inside the VEVENT/VTODO, not its VALARMs.
+			if len(stack) > 0 && isItem(stack[len(stack)-1]) {
+				props = append(props, p)
+			}
+		}
+	}
+	if len(stack) != 0 {
+		return nil, fmt.Errorf("unterminated %s", stack[len(stack)-1])
+	}
+	return events, nil
+}
+
+func isItem(kind string) bool {
+	kind = strings.ToUpper(kind)
+	return kind == KindEvent || kind == KindTodo
+}
+
+func toEvent(kind string, props []property) (Event, error) {
+	ev := Event{Kind: kind}
+	for _, p := range props {
+		switch p.name {
+		case "UID":
+			ev.UID = p.value
+		case "SUMMARY":
+			ev.Summary = unescapeText(p.value)
+		case "DESCRIPTION":
+			ev.Description = unescapeText(p.value)
+		case "RRULE":
+			ev.RRule = p.value
+		case "CATEGORIES":
+			for _, c := range splitEscaped(p.value, ',') {
+				if c = strings.TrimSpace(unescapeText(c)); c != "" {
+					ev.Categories = append(ev.Categories, c)
+				}
+			}
+		case "DTSTART", "DUE":
+			if p.name == "DUE" && kind != KindTodo || p.name == "DTSTART" && kind == KindTodo && !ev.Start.IsZero() {
+				continue
+			}
+			t, allDay, err := parseTime(p)
+			if err != nil {
+				return ev, err
+			}
+			ev.Start, ev.AllDay = t, allDay
+		case "CREATED":
+			if t, _, err := parseTime(p); err == nil {
+				ev.Created = t
+			}
+		case "LAST-MODIFIED":
+			if t, _, err := parseTime(p); err == nil {
+				ev.LastModified = t
+			}
+		}
+	}
+	return ev, nil
+}
+
+// parseTime handles UTC, floating, TZID-qualified and all-day values.
+// Floating times and unknown zones are interpreted as UTC.
+func parseTime(p property) (time.Time, bool, error) {
+	v := p.value
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(v) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid %s %q", p.name, v)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(utcLayout, v)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid %s %q", p.name, v)
+		}
+		return t, false, nil
+	}
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q", p.name, v)
+	}
+	return t.UTC(), false, nil
+}
+
+// unfold joins continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// parseLine splits "NAME;PARAM=VALUE;...:value", respecting quoted parameter values.
+func parseLine(line string) (property, error) {
+	p := property{params: map[string]string{}}
+	inQuotes := false
+	colon := -1
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ':':
+			if !inQuotes {
+				colon = i
+			}
+		}
+		if colon >= 0 {
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("malformed content line %q", line)
+	}
+	head := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(head[0])
+	for _, param := range head[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			p.params[strings.ToUpper(k)] = v
+		}
+	}
+	p.value = line[colon+1:]
+	return p, nil
+}
+
+func unescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// splitEscaped splits on sep, ignoring backslash-escaped separators.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == sep {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,133 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	utcLayout  = "20060102T150405Z"
+	dateLayout = "20060102"
+	maxLineLen = 75
+)
+
+// Calendar is a VCALENDAR with a display name.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write serialises the calendar with CRLF line endings and folded lines.
+func Write(w io.Writer, cal *Calendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Reminder-Card//Reminders//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	line("X-PUBLISHED-TTL", "PT1H")
+
+	now := time.Now().UTC().Format(utcLayout)
+	for _, e := range cal.Events {
+		kind := e.Kind
+		if kind == "" {
+			kind = KindEvent
+		}
+		line("BEGIN", kind)
+		line("UID", e.UID)
+		line("DTSTAMP", now)
+		// A rule that fails validation could break out of its content
+		// line, so it is left out rather than escaped
+		rrule := strings.TrimPrefix(strings.TrimSpace(e.RRule), "RRULE:")
+		if ValidateRRule(rrule) != nil {
+			rrule = ""
+		}
+		startProps := []string{"DTSTART"}
+		if kind == KindTodo {
+			// RRULE and a start-relative TRIGGER both need DTSTART
+			startProps = []string{"DUE"}
+			if rrule != "" || e.Alarm {
+				startProps = []string{"DTSTART", "DUE"}
+			}
+		}
+		for _, prop := range startProps {
+			if e.AllDay {
+				line(prop+";VALUE=DATE", e.Start.Format(dateLayout))
+			} else {
+				line(prop, e.Start.UTC().Format(utcLayout))
+			}
+		}
+		if rrule != "" {
+			line("RRULE", rrule)
+		}
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			cats := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				cats[i] = escapeText(c)
+			}
+			line("CATEGORIES", strings.Join(cats, ","))
+		}
+		if !e.Created.IsZero() {
+			line("CREATED", e.Created.UTC().Format(utcLayout))
+		}
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", e.LastModified.UTC().Format(utcLayout))
+		}
+		if kind == KindTodo {
+			line("STATUS", "NEEDS-ACTION")
+		}
+		if e.Alarm {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("TRIGGER;RELATED=START", "PT0M")
+			text := e.AlarmText
+			if text == "" {
+				text = e.Summary
+			}
+			line("DESCRIPTION", escapeText(text))
+			line("END", "VALARM")
+		}
+		line("END", kind)
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting a UTF-8 sequence.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := maxLineLen
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineLen - 1 // the leading space counts
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,222 @@
+// Package imaging does the server-side photo work for attachments in pure
+// Go: EXIF reading and stripping, orientation correction and thumbnails.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Exif holds the metadata we care about from a photo.
+type Exif struct {
+	Orientation int // 1-8 as defined by the TIFF spec; 1 when absent
+	CapturedAt  *time.Time
+	HasGPS      bool
+}
+
+const (
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+)
+
+var (
+	jpegSOI    = []byte{0xFF, 0xD8}
+	pngMagic   = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader = []byte("Exif\x00\x00")
+)
+
+// ReadExif extracts EXIF metadata from a JPEG or PNG. Images without EXIF
+// yield a zero-value result with Orientation 1.
+func ReadExif(data []byte) (*Exif, error) {
+	out := &Exif{Orientation: 1}
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		tiff = jpegExif(data)
+	case bytes.HasPrefix(data, pngMagic):
+		tiff = pngChunk(data, "eXIf")
+	}
+	if tiff == nil {
+		return out, nil
+	}
+	return out, parseTIFF(tiff, out)
+}
+
+// jpegExif returns the TIFF payload of the first Exif APP1 segment.
+func jpegExif(data []byte) []byte {
+	var found []byte
+	_ = walkJPEG(data, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			found = segment[4+len(exifHeader):]
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walkJPEG calls fn with each marker segment (including its 4-byte header)
+// up to the start of scan. Returning false stops the walk.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) (sosOffset int) {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return -1
+		}
+		marker := data[i+1]
+		if marker == 0xFF { // fill byte
+			i++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return i
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return -1
+		}
+		if !fn(marker, data[i:end]) {
+			return i
+		}
+		i = end
+	}
+	return -1
+}
+
+func pngChunk(data []byte, kind string) []byte {
+	for i := len(pngMagic); i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil
+		}
+		if string(data[i+4:i+8]) == kind {
+			return data[i+8 : i+8+length]
+		}
+		i = end
+	}
+	return nil
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+func parseTIFF(data []byte, out *Exif) error {
+	if len(data) < 8 {
+		return errors.New("exif: truncated header")
+	}
+	r := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return errors.New("exif: bad byte order")
+	}
+
+	ifd0, err := r.ifd(r.order.Uint32(data[4:]))
+	if err != nil {
+		return err
+	}
+	if v, ok := ifd0[tagOrientation]; ok {
+		if o := int(r.short(v)); o >= 1 && o <= 8 {
+			out.Orientation = o
+		}
+	}
+	_, out.HasGPS = ifd0[tagGPSIFD]
+
+	var taken, offset string
+	if v, ok := ifd0[tagDateTime]; ok {
+		taken = r.ascii(v)
+	}
+	if v, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := r.ifd(r.long(v)); err == nil {
+			if v, ok := sub[tagDateTimeOriginal]; ok {
+				taken = r.ascii(v)
+			}
+			if v, ok := sub[tagOffsetTimeOriginal]; ok {
+				offset = r.ascii(v)
+			}
+		}
+	}
+	out.CapturedAt = exifTime(taken, offset)
+	return nil
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // the raw 4-byte value/offset field
+}
+
+func (r *tiffReader) ifd(offset uint32) (map[uint16]ifdEntry, error) {
+	if int(offset)+2 > len(r.data) {
+		return nil, errors.New("exif: IFD out of range")
+	}
+	n := int(r.order.Uint16(r.data[offset:]))
+	start := int(offset) + 2
+	if start+n*12 > len(r.data) {
+		return nil, errors.New("exif: IFD truncated")
+	}
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		e := r.data[start+i*12:]
+		entries[r.order.Uint16(e)] = ifdEntry{
+			typ:   r.order.Uint16(e[2:]),
+			count: r.order.Uint32(e[4:]),
+			value: e[8:12],
+		}
+	}
+	return entries, nil
+}
+
+func (r *tiffReader) short(e ifdEntry) uint16 { return r.order.Uint16(e.value) }
+func (r *tiffReader) long(e ifdEntry) uint32  { return r.order.Uint32(e.value) }
+
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	var raw []byte
+	if e.count <= 4 {
+		raw = e.value[:e.count]
+	} else {
+		off := r.order.Uint32(e.value)
+		if uint64(off)+uint64(e.count) > uint64(len(r.data)) {
+			return ""
+		}
+		raw = r.data[off : off+e.count]
+	}
+	return strings.TrimRight(string(raw), "\x00 ")
+}
+
+// exifTime parses "2006:01:02 15:04:05". Without an OffsetTimeOriginal the
+// camera's local time is taken as UTC.
+func exifTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	loc := time.UTC
+	if offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, loc)
+	if err != nil || t.Year() < 1900 {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,120 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+// exifSegment builds a little-endian APP1 segment with an orientation, a
+// GPS IFD pointer and an Exif sub-IFD holding DateTimeOriginal.
+func exifSegment(orientation int, taken string) []byte {
+	le := binary.LittleEndian
+	tiff := make([]byte, 8)
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+
+	entry := func(tag, typ uint16, count, value uint32) []byte {
+		e := make([]byte, 12)
+		le.PutUint16(e, tag)
+		le.PutUint16(e[2:], typ)
+		le.PutUint32(e[4:], count)
+		le.PutUint32(e[8:], value)
+		return e
+	}
+
+	// IFD0 at 8: 3 entries -> 2 + 36 + 4 = 42 bytes; sub-IFD at 50; GPS IFD at 68; string at 74.
+	ifd0 := []byte{3, 0}
+	ifd0 = append(ifd0, entry(tagOrientation, 3, 1, uint32(orientation))...)
+	ifd0 = append(ifd0, entry(tagExifIFD, 4, 1, 50)...)
+	ifd0 = append(ifd0, entry(tagGPSIFD, 4, 1, 68)...)
+	ifd0 = append(ifd0, 0, 0, 0, 0)
+	tiff = append(tiff, ifd0...)
+
+	sub := []byte{1, 0}
+	sub = append(sub, entry(tagDateTimeOriginal, 2, uint32(len(taken)+1), 74)...)
+	sub = append(sub, 0, 0, 0, 0)
+	tiff = append(tiff, sub...)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // empty GPS IFD
+	tiff = append(tiff, append([]byte(taken), 0)...)
+
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, app1 []byte) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func TestReadAndStripExif(t *testing.T) {
+	data := testJPEG(t, 40, 20, exifSegment(6, "2022:07:14 18:30:05"))
+
+	ex, err := ReadExif(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.Orientation != 6 || !ex.HasGPS {
+		t.Errorf("got %+v", ex)
+	}
+	if want := time.Date(2022, 7, 14, 18, 30, 5, 0, time.UTC); ex.CapturedAt == nil || !ex.CapturedAt.Equal(want) {
+		t.Errorf("captured at %v, want %v", ex.CapturedAt, want)
+	}
+
+	stripped := StripMetadata(data, ex.Orientation)
+	after, err := ReadExif(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.HasGPS || after.CapturedAt != nil || after.Orientation != 6 {
+		t.Errorf("after strip: %+v", after)
+	}
+	if _, _, err := Decode(stripped); err != nil {
+		t.Errorf("stripped JPEG no longer decodes: %v", err)
+	}
+}
+
+func TestThumbnailOrientation(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	thumb := Thumbnail(src, 100, 6)
+	if b := thumb.Bounds(); b.Dx() != 50 || b.Dy() != 100 {
+		t.Errorf("thumbnail size = %dx%d, want 50x100", b.Dx(), b.Dy())
+	}
+
+	small := Thumbnail(image.NewNRGBA(image.Rect(0, 0, 30, 10)), 100, 1)
+	if b := small.Bounds(); b.Dx() != 30 || b.Dy() != 10 {
+		t.Errorf("small images must not be upscaled, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestOrientPixels(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red := color.NRGBA{R: 255, A: 255}
+	src.SetNRGBA(0, 0, red)
+
+	// Rotating 90° clockwise moves the top-left pixel to the top-right.
+	got := Orient(src, 6)
+	if got.NRGBAAt(0, 0) != red {
+		t.Errorf("orientation 6: expected red at (0,0) of a 1x2 image, got %v", got.NRGBAAt(0, 0))
+	}
+	// Rotating 90° counter-clockwise moves it to the bottom-left.
+	got = Orient(src, 8)
+	if got.NRGBAAt(0, 1) != red {
+		t.Errorf("orientation 8: expected red at (0,1), got %v", got.NRGBAAt(0, 1))
+	}
+}
@@ -0,0 +1,92 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// StripMetadata removes EXIF (including GPS), XMP, IPTC and comments from a
+// JPEG, or the text and EXIF chunks from a PNG, without re-encoding pixels.
+// A JPEG keeps a minimal EXIF block holding only its orientation so it still
+// displays the right way up. Other formats are returned unchanged.
+func StripMetadata(data []byte, orientation int) []byte {
+	switch {
+	case bytes.HasPrefix(data, jpegSOI):
+		return stripJPEG(data, orientation)
+	case bytes.HasPrefix(data, pngMagic):
+		return stripPNG(data)
+	}
+	return data
+}
+
+func stripJPEG(data []byte, orientation int) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write(jpegSOI)
+
+	inserted := false
+	insert := func() {
+		if !inserted && orientation > 1 {
+			out.Write(orientationSegment(orientation))
+		}
+		inserted = true
+	}
+
+	sos := walkJPEG(data, func(marker byte, segment []byte) bool {
+		switch marker {
+		case 0xE1, 0xED, 0xFE: // APP1 (EXIF/XMP), APP13 (IPTC), COM
+			return true
+		case 0xE0: // JFIF must stay first
+			out.Write(segment)
+			return true
+		}
+		insert()
+		out.Write(segment)
+		return true
+	})
+	if sos < 0 {
+		return data // not a JPEG we understand; leave it alone
+	}
+	insert()
+	out.Write(data[sos:])
+	return out.Bytes()
+}
+
+// orientationSegment builds an APP1 segment with a one-entry big-endian IFD.
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, IFD0 at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // Orientation SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write(pngMagic)
+	for i := len(pngMagic); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return data
+		}
+		chunk := data[i:end]
+		if !pngMetadataChunks[string(chunk[4:8])] {
+			if crc32.ChecksumIEEE(chunk[4:8+length]) != binary.BigEndian.Uint32(chunk[8+length:]) {
+				return data
+			}
+			out.Write(chunk)
+		}
+		i = end
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,124 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the images we are willing to decode (about 50 megapixels),
+// so a tiny file declaring huge dimensions can't exhaust memory.
+const MaxPixels = 50_000_000
+
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// Decode reads an image after checking its declared size. It returns the
+// decoder's format name ("jpeg", "png", "gif" or "webp").
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, format, err
+}
+
+// Thumbnail scales img so its longer side is at most maxSide pixels and then
+// applies the EXIF orientation. Images are never upscaled.
+func Thumbnail(img image.Image, maxSide, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			w, h = maxSide, max(1, h*maxSide/w)
+		} else {
+			w, h = max(1, w*maxSide/h), maxSide
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return Orient(dst, orientation)
+}
+
+// OrientedSize returns the displayed size of a w×h image with the given orientation.
+func OrientedSize(w, h, orientation int) (int, int) {
+	if orientation >= 5 && orientation <= 8 {
+		return h, w
+	}
+	return w, h
+}
+
+// Orient applies an EXIF orientation (1-8) so the result displays upright.
+func Orient(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := OrientedSize(w, h, orientation)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.SetNRGBA(dx, dy, src.NRGBAAt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// Encode writes a thumbnail as PNG when it has transparency and as JPEG
+// otherwise, returning the content type used.
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if hasAlpha(img) {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("encode png: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", fmt.Errorf("encode jpeg: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+func hasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("Base/internal/jobs")
+
+var (
+	ErrQueueFull   = errors.New("job queue is full")
+	ErrQueueClosed = errors.New("job queue is closed")
+)
+
+// Func is a unit of background work. The context is cancelled when the
+// queue is shut down.
+type Func func(ctx context.Context) error
+
+type task struct {
+	name   string
+	fn     Func
+	parent trace.SpanContext // the request that queued it, if traced
+}
+
+// Queue runs background jobs on a fixed pool of worker goroutines so that
+// slow work (exports, image processing) never blocks an HTTP request.
+type Queue struct {
+	tasks  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	workers atomic.Int32 // worker goroutines running
+}
+
+// NewQueue starts a queue with the given number of workers and pending-job buffer.
+func NewQueue(workers, buffer int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		tasks:  make(chan task, buffer),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		q.workers.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// Enqueue schedules fn to run on a worker. It never blocks: if the buffer is
+// full ErrQueueFull is returned and the caller decides how to report it.
+func (q *Queue) Enqueue(name string, fn Func) error {
+	return q.EnqueueContext(context.Background(), name, fn)
+}
+
+// EnqueueContext is Enqueue for work started by a request: the job's span
+// is linked into the trace found in ctx. ctx is not passed on to fn, which
+// still runs under the queue's own context.
+func (q *Queue) EnqueueContext(ctx context.Context, name string, fn Func) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.tasks <- task{name: name, fn: fn, parent: trace.SpanContextFromContext(ctx)}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs, lets the workers drain what is already queued
+// and waits for them. If ctx expires first, running jobs are cancelled.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.tasks)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Every queues fn every interval until the queue shuts down, for periodic
+// chores such as purging expired files. A tick that finds the buffer full
+// is skipped; the next one tries again.
+func (q *Queue) Every(name string, every time.Duration, fn Func) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-q.ctx.Done():
+				return
+			case <-ticker.C:
+				_ = q.Enqueue(name, fn)
+			}
+		}
+	}()
+}
+
+// Workers is how many worker goroutines are running, busy or not: the
+// number the queue was started with until Shutdown.
+func (q *Queue) Workers() int {
+	return int(q.workers.Load())
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	defer q.workers.Add(-1)
+	for t := range q.tasks {
+		q.run(t)
+	}
+}
+
+func (q *Queue) run(t task) {
+	ctx := trace.ContextWithRemoteSpanContext(q.ctx, t.parent)
+	ctx, span := tracer.Start(ctx, "job "+kind(t.name), trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("job.name", t.name)))
+	defer span.End()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "job panicked", "job", t.name, "panic", fmt.Sprint(r))
+			span.SetStatus(codes.Error, fmt.Sprint("panic: ", r))
+		}
+	}()
+	if err := t.fn(ctx); err != nil {
+		slog.ErrorContext(ctx, "job failed", "job", t.name, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// kind strips the ID callers append to job names ("export-42" is an
+// "export"), keeping span names low-cardinality.
+func kind(name string) string {
+	if i := strings.LastIndexByte(name, '-'); i > 0 && i < len(name)-1 && strings.Trim(name[i+1:], "0123456789") == "" {
+		return name[:i]
+	}
+	return name
+}
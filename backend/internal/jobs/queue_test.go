@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWorkersStayLiveWhileBusy(t *testing.T) {
+	q := NewQueue(2, 4)
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		q.Enqueue("export", func(context.Context) error { <-release; return nil })
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := q.Workers(); n != 2 {
+		t.Errorf("Workers while every worker is busy = %d, want 2", n)
+	}
+	close(release)
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := q.Workers(); n != 0 {
+		t.Errorf("Workers after Shutdown = %d, want 0", n)
+	}
+}
+
+func TestJobSpanJoinsEnqueuingTrace(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	defer otel.SetTracerProvider(prev)
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+	q := NewQueue(1, 4)
+	if err := q.EnqueueContext(ctx, "export-42", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	request.End()
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range rec.Ended() {
+		if s.Name() != "job export" {
+			continue
+		}
+		if s.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Error("job span is not a child of the enqueuing span")
+		}
+		return
+	}
+	t.Fatal(`no "job export" span`)
+}
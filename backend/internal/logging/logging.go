@@ -0,0 +1,123 @@
+// Package logging sets up log/slog. Records carry the attributes stored in
+// their context with With (request_id, user_id) and the trace ID, and
+// personal data or secrets are redacted before anything is written.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"Base/internal/config"
+	"Base/internal/tracing"
+)
+
+// Redacted replaces the value of a sensitive attribute.
+const Redacted = "[REDACTED]"
+
+// Setup makes a logger for cfg the default for slog and the log package.
+func Setup(cfg config.Log) {
+	slog.SetDefault(New(os.Stderr, cfg))
+}
+
+// New returns a logger writing to w in cfg's format.
+func New(w io.Writer, cfg config.Log) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redact}
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+type ctxKey struct{}
+
+// With returns a context whose log records carry args, given as for
+// slog.Logger.With.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	r := slog.Record{}
+	r.Add(args...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, attrs[:len(attrs):len(attrs)])
+}
+
+// contextHandler adds the context's attributes to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if id := tracing.TraceID(ctx); id != "" {
+		r.AddAttrs(slog.String("trace_id", id))
+	}
+	// The message skips ReplaceAttr, so scrub it here
+	r.Message = scrub(r.Message)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// sensitiveKeys are attribute names whose values are never logged; a key
+// containing one of sensitiveParts is treated the same.
+var (
+	sensitiveKeys  = map[string]bool{"email": true, "text": true, "situation": true, "dsn": true}
+	sensitiveParts = []string{"password", "token", "secret", "authorization", "cookie"}
+)
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+\S+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch v := a.Value.Any().(type) {
+	case string:
+		return slog.String(a.Key, scrub(v))
+	case error:
+		return slog.String(a.Key, scrub(v.Error()))
+	}
+	return a
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, part := range sensitiveParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrub blanks email addresses and tokens that end up inside free text,
+// such as error messages.
+func scrub(s string) string {
+	s = emailPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+Redacted)
+	return jwtPattern.ReplaceAllString(s, Redacted)
+}
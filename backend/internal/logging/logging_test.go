@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/config"
+	"Base/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// capture makes a JSON logger the default and returns what it writes.
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(New(&buf, config.Log{Level: slog.LevelDebug, Format: "json"}))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("not JSON: %s", line)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestRedaction(t *testing.T) {
+	buf := capture(t)
+	slog.Info("login by ann@example.com",
+		"password", "hunter22",
+		"download_token", "abc",
+		"Authorization", "Bearer abc",
+		"email", "ann@example.com",
+		"text", "my diary",
+		"error", errors.New("user bob@example.org not found; token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl"),
+		"entry_id", 7,
+	)
+	out := buf.String()
+	for _, secret := range []string{"hunter22", "abc", "ann@example.com", "my diary", "bob@example.org", "eyJ"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q: %s", secret, out)
+		}
+	}
+	if rec := records(t, buf)[0]; rec["entry_id"] != 7.0 {
+		t.Errorf("entry_id = %v, want it kept", rec["entry_id"])
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	buf := capture(t)
+	ctx := With(context.Background(), "request_id", "req-1")
+	ctx = With(ctx, "user_id", 42)
+	slog.InfoContext(ctx, "hello")
+	slog.Info("no context")
+
+	recs := records(t, buf)
+	if recs[0]["request_id"] != "req-1" || recs[0]["user_id"] != 42.0 {
+		t.Errorf("record = %v, want request_id and user_id", recs[0])
+	}
+	if _, ok := recs[1]["request_id"]; ok {
+		t.Errorf("record without context has a request_id: %v", recs[1])
+	}
+}
+
+func TestGORMLogsSlowQueriesWithoutValues(t *testing.T) {
+	buf := capture(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: NewGORM(time.Nanosecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+
+	ctx := With(context.Background(), "request_id", "req-2")
+	db.WithContext(ctx).Where("email = ?", "ann@example.com").Find(&[]models.User{})
+
+	recs := records(t, buf)
+	rec := recs[len(recs)-1]
+	if rec["msg"] != "slow query" || rec["request_id"] != "req-2" {
+		t.Fatalf("record = %v, want a slow query tagged with the request", rec)
+	}
+	if sql, _ := rec["sql"].(string); !strings.Contains(sql, "email = ?") {
+		t.Errorf("sql = %q, want the placeholder instead of the value", sql)
+	}
+}
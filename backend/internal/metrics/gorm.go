@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// Plugin times every GORM query and counts created entries. Install it
+// with db.Use(metrics.Plugin{}).
+type Plugin struct{}
+
+func (Plugin) Name() string { return "metrics" }
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, p := range []struct {
+		op        string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+		countRows bool
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register, true},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register, false},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register, false},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register, false},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register, false},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register, false},
+	} {
+		if err := p.before("metrics:before_"+p.op, start); err != nil {
+			return err
+		}
+		if err := p.after("metrics:after_"+p.op, observe(p.op, p.countRows)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func observe(op string, countEntries bool) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		dbQueryDuration.WithLabelValues(op, table).Observe(time.Since(v.(time.Time)).Seconds())
+		if countEntries && table == "entries" && db.Error == nil && db.RowsAffected > 0 {
+			EntriesCreated.Add(float64(db.RowsAffected))
+		}
+	}
+}
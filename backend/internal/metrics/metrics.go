@@ -0,0 +1,110 @@
+// Package metrics collects Prometheus metrics for the API and serves them
+// on /metrics.
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric of this process.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	// Logins counts login attempts by result: success or failure.
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts by result.",
+	}, []string{"result"})
+
+	// EntriesCreated counts new entries from every source: the API, batches,
+	// imports and sync. Inserts later rolled back are counted too.
+	EntriesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "entries_created_total",
+		Help: "Entries created.",
+	})
+
+	// CalendarEntriesServed counts the entries written into calendar feeds.
+	// Calendar apps poll the feed and get every entry each time, so this
+	// measures feed traffic, not reminders that came due.
+	CalendarEntriesServed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "calendar_feed_entries_served_total",
+		Help: "Entries served in calendar feeds, counted on every poll.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, dbQueryDuration,
+		Logins, EntriesCreated, CalendarEntriesServed,
+	)
+}
+
+// Middleware records every request under its route template, so
+// /api/v1/entries/42 counts as /api/v1/entries/:id. Requests that match no
+// route are recorded as "unmatched".
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// WatchPool exports the connection pool statistics of db.
+func WatchPool(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry. With a token, requests must carry it as
+// "Authorization: Bearer <token>".
+func Handler(token string) http.Handler {
+	h := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
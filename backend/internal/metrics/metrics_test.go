@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/entries/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/entries/1", "/entries/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if n := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/entries/:id", "204")); n != 2 {
+		t.Errorf("requests to /entries/:id = %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")); n != 1 {
+		t.Errorf("unmatched requests = %v, want 1", n)
+	}
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	h := Handler("s3cret")
+	for auth, want := range map[string]int{"": 401, "Bearer wrong": 401, "Bearer s3cret": 200} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("Authorization %q: %d, want %d", auth, w.Code, want)
+		}
+		if want == 200 && !strings.Contains(w.Body.String(), "go_goroutines") {
+			t.Error("metrics body lacks the Go collector")
+		}
+	}
+}
+
+func TestPluginTimesQueriesAndCountsEntries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Entry{}); err != nil {
+		t.Fatal(err)
+	}
+	before := testutil.ToFloat64(EntriesCreated)
+	db.Create(&[]models.Entry{{Situation: "a"}, {Situation: "b"}})
+	var entries []models.Entry
+	db.Find(&entries)
+
+	if n := testutil.ToFloat64(EntriesCreated) - before; n != 2 {
+		t.Errorf("entries created = %v, want 2", n)
+	}
+	if n := testutil.CollectAndCount(dbQueryDuration, "db_query_duration_seconds"); n < 2 {
+		t.Errorf("%d query series, want create and query", n)
+	}
+}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"Base/internal/apierror"
+	"Base/internal/i18n"
+	"Base/internal/logging"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -10,26 +13,42 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// Use a function to get the key to ensure it's loaded from ENV correctly
-func getSecretKey() []byte {
-	return []byte(os.Getenv("JWT_SECRET"))
+// errNoSecret keeps tokens from being signed or accepted with an empty key.
+var errNoSecret = errors.New("JWT secret is not configured")
+
+// Tokens issues session JWTs and checks them, all signed with one secret.
+type Tokens struct {
+	secret string
+}
+
+// NewTokens returns Tokens signing with secret; with an empty one nothing
+// is signed or accepted.
+func NewTokens(secret string) *Tokens {
+	return &Tokens{secret: secret}
 }
 
-func AuthMiddleware() gin.HandlerFunc {
+func (t *Tokens) getSecretKey() ([]byte, error) {
+	if t.secret == "" {
+		return nil, errNoSecret
+	}
+	return []byte(t.secret), nil
+}
+
+func (t *Tokens) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := ExtractToken(c)
 		if tokenString == "" {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized: Missing token"})
+			apierror.Abort(c, apierror.Unauthorized)
 			return
 		}
 
 		claims := &CustomClaims{}
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return getSecretKey(), nil
+			return t.getSecretKey()
 		})
 
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+			apierror.Abort(c, apierror.InvalidToken)
 			return
 		}
 
@@ -37,34 +56,48 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role", claims.Role)
+		logUser(c, claims.UserID)
+		if lang, ok := i18n.Parse(claims.Language); ok {
+			setLang(c, lang)
+		}
 		c.Next()
 	}
 }
-func AuthAdminMiddleware() gin.HandlerFunc {
+func (t *Tokens) AuthAdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := ExtractToken(c)
 		claims := &CustomClaims{}
 
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return getSecretKey(), nil
+			return t.getSecretKey()
 		})
 
 		if err != nil || !token.Valid || claims.Role != "admin" {
-			c.AbortWithStatusJSON(403, gin.H{"error": "Forbidden: Admin access required"})
+			apierror.Abort(c, apierror.Forbidden)
 			return
 		}
 
 		c.Set("userID", claims.UserID)
+		logUser(c, claims.UserID)
+		if lang, ok := i18n.Parse(claims.Language); ok {
+			setLang(c, lang)
+		}
 		c.Next()
 	}
 }
 
-// CreateToken builds a JWT containing user id and username
-func CreateToken(userID uint, username string, role string) (string, error) {
+// logUser tags the request's log records with the authenticated user.
+func logUser(c *gin.Context, userID uint) {
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "user_id", userID))
+}
+
+// CreateToken builds a JWT containing user id, username and preferred language
+func (t *Tokens) CreateToken(userID uint, username string, role string, language string) (string, error) {
 	claims := CustomClaims{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
+		Language: language,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -72,7 +105,11 @@ func CreateToken(userID uint, username string, role string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(getSecretKey())
+	key, err := t.getSecretKey()
+	if err != nil {
+		return "", err
+	}
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", err
 	}
@@ -94,13 +131,13 @@ func ExtractToken(c *gin.Context) string {
 	return t
 }
 
-func GetUsernameFromToken(tokenString string) (string, error) {
+func (t *Tokens) GetUsernameFromToken(tokenString string) (string, error) {
 	// Parse token with CustomClaims to extract username
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return getSecretKey(), nil
+		return t.getSecretKey()
 	})
 
 	if err != nil {
@@ -118,10 +155,11 @@ type CustomClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Language string `json:"lang,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GetUserIDFromToken(tokenString string) (uint, error) {
+func (t *Tokens) GetUserIDFromToken(tokenString string) (uint, error) {
 	// 1. Parse and validate the token with custom claims
 	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Validate the signing method
@@ -129,7 +167,7 @@ func GetUserIDFromToken(tokenString string) (uint, error) {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		// Return your secret key
-		return getSecretKey(), nil
+		return t.getSecretKey()
 	})
 
 	if err != nil {
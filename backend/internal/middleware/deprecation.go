@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Base/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+	LinkHeader        = "Link"
+)
+
+// LegacyDeprecatedAt is when the unversioned /user and /admin routes were
+// deprecated in favour of /api/v1.
+var LegacyDeprecatedAt = config.LegacyDeprecatedAt
+
+// RouteUsage is how often a deprecated route has been called since the
+// process started.
+type RouteUsage struct {
+	Method    string     `json:"method"`
+	Route     string     `json:"route"`
+	Successor string     `json:"successor"`
+	Calls     int64      `json:"calls"`
+	LastCall  *time.Time `json:"last_call,omitempty"`
+}
+
+type routeCounter struct {
+	method, route, successor string
+	calls                    atomic.Int64
+	last                     atomic.Int64 // unix nanoseconds
+}
+
+var (
+	deprecatedMu     sync.Mutex
+	deprecatedRoutes = map[string]*routeCounter{}
+)
+
+// Deprecated marks method+route as a legacy route replaced by successor, a
+// route template such as "/api/v1/entries/:id", and removed at sunset.
+// Responses carry Deprecation and Sunset headers and a Link to the successor
+// with the path parameters filled in, and every call is counted so
+// LegacyUsage shows which routes clients still depend on. Routes are
+// registered here up front, so ones that are never called show up with
+// zero calls.
+func Deprecated(method, route, successor string, sunset time.Time) gin.HandlerFunc {
+	key := method + " " + route
+	deprecatedMu.Lock()
+	counter, ok := deprecatedRoutes[key]
+	if !ok {
+		counter = &routeCounter{method: method, route: route, successor: successor}
+		deprecatedRoutes[key] = counter
+	}
+	deprecatedMu.Unlock()
+
+	deprecation := "@" + strconv.FormatInt(LegacyDeprecatedAt.Unix(), 10)
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		counter.calls.Add(1)
+		counter.last.Store(time.Now().UnixNano())
+
+		h := c.Writer.Header()
+		h.Set(DeprecationHeader, deprecation)
+		h.Set(SunsetHeader, sunsetAt)
+		h.Add(LinkHeader, "<"+expandRoute(successor, c.Params)+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// expandRoute substitutes the ":name" segments of a route template.
+func expandRoute(route string, params gin.Params) string {
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			if v, ok := params.Get(s[1:]); ok {
+				segments[i] = v
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// LegacyUsage returns the call counts of every deprecated route, most used first.
+func LegacyUsage() []RouteUsage {
+	deprecatedMu.Lock()
+	usage := make([]RouteUsage, 0, len(deprecatedRoutes))
+	for _, r := range deprecatedRoutes {
+		u := RouteUsage{Method: r.method, Route: r.route, Successor: r.successor, Calls: r.calls.Load()}
+		if last := r.last.Load(); last != 0 {
+			t := time.Unix(0, last).UTC()
+			u.LastCall = &t
+		}
+		usage = append(usage, u)
+	}
+	deprecatedMu.Unlock()
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Calls != usage[j].Calls {
+			return usage[i].Calls > usage[j].Calls
+		}
+		if usage[i].Route != usage[j].Route {
+			return usage[i].Route < usage[j].Route
+		}
+		return usage[i].Method < usage[j].Method
+	})
+	return usage
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecatedSetsHeadersAndCountsCalls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sunset := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	r := gin.New()
+	r.PUT("/legacy/entries/:id", Deprecated("PUT", "/legacy/entries/:id", "/api/v1/entries/:id", sunset), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/legacy/unused", Deprecated("GET", "/legacy/unused", "/api/v1/unused", sunset), func(c *gin.Context) {})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/legacy/entries/42", nil))
+
+		if got, want := w.Header().Get(DeprecationHeader), "@1792368000"; got != want {
+			t.Errorf("Deprecation = %q, want %q", got, want)
+		}
+		if got, want := w.Header().Get(SunsetHeader), "Sun, 31 Jan 2027 00:00:00 GMT"; got != want {
+			t.Errorf("Sunset = %q, want %q", got, want)
+		}
+		if got, want := w.Header().Get(LinkHeader), `</api/v1/entries/42>; rel="successor-version"`; got != want {
+			t.Errorf("Link = %q, want %q", got, want)
+		}
+	}
+
+	usage := map[string]RouteUsage{}
+	for _, u := range LegacyUsage() {
+		usage[u.Method+" "+u.Route] = u
+	}
+	if u := usage["PUT /legacy/entries/:id"]; u.Calls != 2 || u.LastCall == nil || u.Successor != "/api/v1/entries/:id" {
+		t.Errorf("usage of called route = %+v", u)
+	}
+	if u, ok := usage["GET /legacy/unused"]; !ok || u.Calls != 0 || u.LastCall != nil {
+		t.Errorf("usage of unused route = %+v (listed %v)", u, ok)
+	}
+}
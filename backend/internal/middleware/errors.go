@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"Base/internal/apierror"
+	"Base/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renders the last error recorded with c.Error (normally through
+// apierror.Abort) as an application/problem+json response. Errors recorded
+// after the response has started, e.g. while streaming a download, are
+// only logged. Both the response and the log line carry the trace ID.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderError(c)
+	}
+}
+
+// renderedKey marks a context whose error has already been rendered, so an
+// inner middleware that needs the final response (Idempotency) can render
+// it early without the outer Errors logging it twice.
+const renderedKey = "errorRendered"
+
+func renderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.GetBool(renderedKey) {
+		return
+	}
+	c.Set(renderedKey, true)
+
+	e := apierror.From(c.Errors.Last().Err)
+	if e.Cause != nil || e.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"method", c.Request.Method, "route", c.FullPath(), "status", e.Status, "error", e)
+	}
+	if c.Writer.Written() {
+		return
+	}
+
+	c.Header("Content-Type", apierror.ContentType)
+	p := e.Problem(GetLang(c), c.Request.URL.Path, GetRequestID(c))
+	p.TraceID = tracing.TraceID(c.Request.Context())
+	c.JSON(e.Status, p)
+}
+
+// NotFound is the handler for unknown routes.
+func NotFound(c *gin.Context) {
+	apierror.Abort(c, apierror.RouteNotFound)
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	ReplayedHeader    = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen  = 255
+	maxIdempotentBodySize = 1 << 20 // 1 MiB
+
+	// idempotencyLease is how long a key stays claimed by a request that
+	// hasn't finished. A claim older than that was left behind by a crash,
+	// and the key is handed to the next request.
+	idempotencyLease = 2 * time.Minute
+)
+
+// Idempotency makes mutating requests safe to retry. When a request carries
+// an Idempotency-Key header, the response is stored and a repeat with the
+// same key gets that response back instead of running the handler again. A
+// key reused with a different method, URL or body is rejected with 422.
+// Keys are scoped to the authenticated user, so register it after the auth
+// middleware. Requests without the header are passed through untouched.
+// Stored responses are replayed for ttl.
+func Idempotency(db *gorm.DB, ttl time.Duration) gin.HandlerFunc {
+	var lastPurge atomic.Int64
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			apierror.Abort(c, apierror.IdempotencyKeyTooLong)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.InvalidJSON).Wrap(err))
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			apierror.Abort(c, apierror.New(apierror.BodyTooLarge).Localized("detail.idempotent_body_too_large", "max", strconv.Itoa(maxIdempotentBodySize)))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		if last := lastPurge.Load(); now.Unix()-last >= 60 && lastPurge.CompareAndSwap(last, now.Unix()) {
+			db.Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+		}
+
+		scope := "anonymous"
+		if userID := c.GetUint("userID"); userID != 0 {
+			scope = fmt.Sprintf("user:%d", userID)
+		}
+		record := models.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: requestHash(c.Request, body),
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		existing, err := claimIdempotencyKey(db, &record, now)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, record.RequestHash)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		completed := false
+		defer func() {
+			// Forget the key if the request failed on our side, so it can be retried.
+			if !completed || rec.Status() >= http.StatusInternalServerError {
+				db.Delete(&models.IdempotencyKey{}, record.ID)
+			}
+		}()
+
+		c.Next()
+
+		// Handlers report failures with apierror.Abort and leave writing the
+		// problem to Errors, which runs after this middleware returns.
+		// Render it now so the stored response is the one the client gets.
+		renderError(c)
+		if !rec.Written() || rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"status":       rec.Status(),
+			"content_type": rec.Header().Get("Content-Type"),
+			"body":         rec.body.Bytes(),
+		}).Error; err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// claimIdempotencyKey inserts record, or returns the live record already
+// stored under the same key.
+func claimIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey, now time.Time) (*models.IdempotencyKey, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := db.Where("scope = ? AND key = ?", record.Scope, record.Key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // finished and deleted in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.Before(now) {
+			db.Delete(&existing)
+			continue
+		}
+		if existing.Status == 0 && existing.CreatedAt.Before(now.Add(-idempotencyLease)) {
+			// Abandoned by a crashed request. Only delete it if it is still
+			// unfinished, so a response stored meanwhile is kept.
+			db.Where("status = 0").Delete(&existing)
+			continue
+		}
+		return &existing, nil
+	}
+	return nil, fmt.Errorf("could not claim key %q", record.Key)
+}
+
+func replayIdempotent(c *gin.Context, record *models.IdempotencyKey, hash string) {
+	switch {
+	case record.RequestHash != hash:
+		apierror.Abort(c, apierror.IdempotencyKeyReused)
+	case record.Status == 0:
+		c.Header("Retry-After", "1")
+		apierror.Abort(c, apierror.IdempotencyKeyBusy)
+	default:
+		c.Header(ReplayedHeader, "true")
+		contentType := record.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		c.Data(record.Status, contentType, record.Body)
+		c.Abort()
+	}
+}
+
+// requestHash fingerprints what the key is allowed to be reused for.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of everything written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
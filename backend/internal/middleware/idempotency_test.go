@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	r := gin.New()
+	r.Use(Errors())
+	r.POST("/entries", func(c *gin.Context) { c.Set("userID", uint(1)) }, Idempotency(db, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/entries", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("k1", `{"text":"a"}`)
+	retry := send("k1", `{"text":"a"}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("codes %d/%d, handler ran %d times", first.Code, retry.Code, calls)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("retry got %q (replayed=%q), want %q", retry.Body, retry.Header().Get(ReplayedHeader), first.Body)
+	}
+	if ct := retry.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("replayed Content-Type = %q", ct)
+	}
+
+	if w := send("k1", `{"text":"b"}`); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"code":"idempotency_key_reused"`) {
+		t.Errorf("reusing a key with another payload: got %d %s, want 422", w.Code, w.Body)
+	}
+
+	send("", `{"text":"a"}`)
+	send("", `{"text":"a"}`)
+	if calls != 3 {
+		t.Errorf("requests without a key must not be deduplicated, handler ran %d times", calls)
+	}
+}
+
+func TestIdempotencyReclaimsAbandonedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	r := gin.New()
+	r.Use(Errors())
+	r.POST("/entries", Idempotency(db, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/entries", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	claim := func(key string, at time.Time) {
+		hash := requestHash(httptest.NewRequest(http.MethodPost, "/entries", nil), []byte(`{}`))
+		rec := models.IdempotencyKey{Scope: "anonymous", Key: key, RequestHash: hash, CreatedAt: at, ExpiresAt: at.Add(time.Hour)}
+		if err := db.Create(&rec).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claim("running", time.Now())
+	if w := send("running"); w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("key held by a running request: got %d, handler ran %d times, want 409", w.Code, calls)
+	}
+
+	claim("crashed", time.Now().Add(-2*idempotencyLease))
+	if w := send("crashed"); w.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("abandoned key: got %d, handler ran %d times, want 201", w.Code, calls)
+	}
+	if w := send("crashed"); w.Code != http.StatusCreated || w.Header().Get(ReplayedHeader) != "true" || calls != 1 {
+		t.Errorf("retry after reclaiming: got %d (replayed=%q), handler ran %d times", w.Code, w.Header().Get(ReplayedHeader), calls)
+	}
+}
+
+func TestIdempotencyStoresAbortedRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	fail := apierror.EntryNotFound
+	r := gin.New()
+	r.Use(Errors())
+	r.POST("/entries", Idempotency(db, time.Hour), func(c *gin.Context) {
+		calls++
+		apierror.Abort(c, fail)
+	})
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/entries", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("missing")
+	retry := send("missing")
+	if first.Code != http.StatusNotFound || retry.Code != http.StatusNotFound || calls != 1 {
+		t.Fatalf("4xx: codes %d/%d, handler ran %d times", first.Code, retry.Code, calls)
+	}
+	if retry.Body.String() != first.Body.String() || !strings.Contains(retry.Body.String(), `"code":"entry_not_found"`) {
+		t.Errorf("4xx replay got %q, want %q", retry.Body, first.Body)
+	}
+	if ct := retry.Header().Get("Content-Type"); ct != apierror.ContentType {
+		t.Errorf("4xx replay Content-Type = %q, want %q", ct, apierror.ContentType)
+	}
+
+	fail = apierror.DatabaseError
+	first = send("broken")
+	retry = send("broken")
+	if first.Code != http.StatusInternalServerError || retry.Code != http.StatusInternalServerError || calls != 3 {
+		t.Fatalf("5xx: codes %d/%d, handler ran %d times, want the key released", first.Code, retry.Code, calls-1)
+	}
+	if retry.Header().Get(ReplayedHeader) != "" || !strings.Contains(retry.Body.String(), `"code":"database_error"`) {
+		t.Errorf("5xx retry got %q (replayed=%q)", retry.Body, retry.Header().Get(ReplayedHeader))
+	}
+}
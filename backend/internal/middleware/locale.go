@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"Base/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale picks the response language from Accept-Language. For signed-in
+// users the auth middleware then overrides it with their stored preference.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setLang(c, i18n.Match(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
+
+func setLang(c *gin.Context, lang i18n.Lang) {
+	c.Set("lang", lang)
+	c.Header("Content-Language", string(lang))
+}
+
+// GetLang returns the language to answer the request in.
+func GetLang(c *gin.Context) i18n.Lang {
+	if lang, ok := c.Get("lang"); ok {
+		return lang.(i18n.Lang)
+	}
+	return i18n.Match(c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"Base/internal/apierror"
+	"Base/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger writes one record per request once it has been handled. The path
+// is logged as its route template, since some paths carry tokens. Register
+// it after RequestID so the record carries the request ID.
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// Recovery turns a panic into a 500 problem response and logs it with its
+// stack trace.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic while handling request",
+			"method", c.Request.Method, "route", c.FullPath(), "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+		p := apierror.New(apierror.InternalError).Problem(GetLang(c), c.Request.URL.Path, GetRequestID(c))
+		p.TraceID = tracing.TraceID(c.Request.Context())
+		c.Header("Content-Type", apierror.ContentType)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, p)
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Base/internal/config"
+	"Base/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggerTagsRequestAndUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&buf, config.Log{Level: slog.LevelInfo, Format: "json"}))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	r := gin.New()
+	r.Use(RequestID(), Logger(), Recovery())
+	r.GET("/exports/download/:token", func(c *gin.Context) {
+		logUser(c, 42)
+		panic("boom")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/exports/download/s3cret", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"internal_error"`) {
+		t.Errorf("got %d %s, want a 500 problem", w.Code, w.Body)
+	}
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("log contains the token from the path: %s", buf.String())
+	}
+	var last map[string]any
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last["msg"] != "request" || last["request_id"] != "req-1" || last["user_id"] != 42.0 ||
+		last["route"] != "/exports/download/:token" || last["status"] != 500.0 {
+		t.Errorf("access log = %v", last)
+	}
+}
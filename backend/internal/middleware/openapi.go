@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Base/internal/apierror"
+	"Base/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxValidatedBody is the largest JSON body checked against the spec;
+// larger ones pass unchecked.
+const maxValidatedBody = 1 << 20 // 1 MiB
+
+// OpenAPIValidation reports which of requests and responses to check
+// against the OpenAPI document for mode (OPENAPI_VALIDATION): off, requests,
+// responses or all. Empty means all in gin's debug and test modes and off
+// in release mode.
+func OpenAPIValidation(mode string) (requests, responses bool) {
+	if mode == "" {
+		mode = "all"
+		if gin.Mode() == gin.ReleaseMode {
+			mode = "off"
+		}
+	}
+	return mode == "all" || mode == "requests", mode == "all" || mode == "responses"
+}
+
+// OpenAPIRequests rejects requests whose parameters or JSON body don't
+// match the operation in the document with 400 validation_failed. Register
+// it after Errors. spec is called per request so the document can be built
+// once every route is registered.
+func OpenAPIRequests(spec func() *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		doc := spec()
+		if doc == nil {
+			c.Next()
+			return
+		}
+		op := doc.Operation(c.Request.Method, c.FullPath())
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		violations, err := requestViolations(c, doc, op)
+		if err != nil {
+			apierror.Abort(c, apierror.New(apierror.InvalidJSON).Wrap(err))
+			return
+		}
+		if len(violations) > 0 {
+			e := apierror.New(apierror.ValidationFailed).Wrap(violationError(violations))
+			for _, v := range violations {
+				field := v.Path
+				if field == "" {
+					field = "body"
+				}
+				e.Fields = append(e.Fields, apierror.FieldError{Field: field, Rule: v.Rule, Param: v.Param})
+			}
+			apierror.Abort(c, e)
+			return
+		}
+		c.Next()
+	}
+}
+
+func requestViolations(c *gin.Context, doc *openapi.Document, op *openapi.Operation) ([]openapi.Violation, error) {
+	var out []openapi.Violation
+	query := c.Request.URL.Query()
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			out = append(out, doc.ValidateValue(p.Schema, c.Param(p.Name), p.Name)...)
+		case "query":
+			values, ok := query[p.Name]
+			if !ok {
+				if p.Required {
+					out = append(out, openapi.Violation{Path: p.Name, Rule: "required"})
+				}
+				continue
+			}
+			for _, v := range values {
+				out = append(out, doc.ValidateValue(p.Schema, v, p.Name)...)
+			}
+		}
+	}
+
+	body := op.RequestBody
+	if body == nil {
+		return out, nil
+	}
+	if c.Request.ContentLength == 0 {
+		if body.Required {
+			out = append(out, openapi.Violation{Rule: "required"})
+		}
+		return out, nil
+	}
+	contentType, media, ok := mediaTypeFor(body.Content, c.GetHeader("Content-Type"))
+	if !ok {
+		return append(out, openapi.Violation{Path: "Content-Type", Rule: "oneof", Param: mediaTypes(body.Content)}), nil
+	}
+	if !isJSON(contentType) || c.Request.ContentLength > maxValidatedBody {
+		return out, nil
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBody+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), c.Request.Body))
+	if err != nil || len(raw) > maxValidatedBody {
+		return out, nil
+	}
+	value, err := openapi.DecodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, doc.Validate(media.Schema, value)...), nil
+}
+
+// OpenAPIResponses checks every response against the document and passes
+// mismatches to report; a nil report logs them. Responses are sent
+// unchanged. Register it before Errors so error responses are checked too.
+func OpenAPIResponses(spec func() *openapi.Document, report func(c *gin.Context, problems []string)) gin.HandlerFunc {
+	if report == nil {
+		report = func(c *gin.Context, problems []string) {
+			slog.WarnContext(c.Request.Context(), "response does not match the OpenAPI spec",
+				"method", c.Request.Method, "route", c.FullPath(), "status", c.Writer.Status(), "problems", strings.Join(problems, "; "))
+		}
+	}
+	return func(c *gin.Context) {
+		doc := spec()
+		if doc == nil {
+			c.Next()
+			return
+		}
+		op := doc.Operation(c.Request.Method, c.FullPath())
+		if op == nil {
+			c.Next()
+			return
+		}
+
+		w := &teeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		if problems := responseProblems(doc, op, c.Request.Method, w); len(problems) > 0 {
+			report(c, problems)
+		}
+	}
+}
+
+func responseProblems(doc *openapi.Document, op *openapi.Operation, method string, w *teeWriter) []string {
+	status := w.Status()
+	resp := op.Response(status)
+	if resp == nil {
+		return []string{"status " + strconv.Itoa(status) + " is not documented"}
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified || method == http.MethodHead || w.buf.Len() == 0 && !w.truncated {
+		return nil
+	}
+	header := w.Header().Get("Content-Type")
+	contentType, media, ok := mediaTypeFor(resp.Content, header)
+	if !ok {
+		return []string{"content type " + strconv.Quote(header) + " is not documented for status " + strconv.Itoa(status)}
+	}
+	if !isJSON(contentType) || w.truncated || media.Schema == nil {
+		return nil
+	}
+	value, err := openapi.DecodeJSON(w.buf.Bytes())
+	if err != nil {
+		return []string{"invalid JSON: " + err.Error()}
+	}
+	var problems []string
+	for _, v := range doc.Validate(media.Schema, value) {
+		problems = append(problems, v.String())
+	}
+	return problems
+}
+
+// mediaTypeFor finds the documented media type matching a Content-Type
+// header, ignoring parameters such as charset.
+func mediaTypeFor(content map[string]openapi.MediaType, header string) (string, openapi.MediaType, bool) {
+	got, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", openapi.MediaType{}, false
+	}
+	for ct, media := range content {
+		want, _, _ := mime.ParseMediaType(ct)
+		if want == got || want == "application/octet-stream" {
+			return got, media, true
+		}
+	}
+	return "", openapi.MediaType{}, false
+}
+
+func mediaTypes(content map[string]openapi.MediaType) string {
+	var types []string
+	for ct := range content {
+		types = append(types, ct)
+	}
+	return strings.Join(types, " ")
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+type violationError []openapi.Violation
+
+func (v violationError) Error() string {
+	parts := make([]string, len(v))
+	for i, x := range v {
+		parts[i] = x.String()
+	}
+	return "request does not match the OpenAPI spec: " + strings.Join(parts, "; ")
+}
+
+// teeWriter keeps a copy of the response body, up to maxValidatedBody.
+type teeWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (w *teeWriter) keep(n int, write func()) {
+	if w.truncated {
+		return
+	}
+	if w.buf.Len()+n > maxValidatedBody {
+		w.truncated = true
+		w.buf = bytes.Buffer{}
+		return
+	}
+	write()
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	w.keep(len(b), func() { w.buf.Write(b) })
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	w.keep(len(s), func() { w.buf.WriteString(s) })
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (w *teeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+type widget struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func TestOpenAPIValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	b := &openapi.Builder{
+		Operations: map[string]*openapi.Operation{
+			"POST /widgets/:id": {
+				OperationID: "createWidget",
+				RequestBody: openapi.Body(openapi.SchemaOf(widget{})),
+				Responses:   map[string]*openapi.Response{"201": openapi.Reply("Created", openapi.SchemaOf(widget{}))},
+			},
+		},
+		Default: &openapi.Response{Description: "Error", Content: map[string]openapi.MediaType{
+			"application/problem+json": {Schema: openapi.Object(map[string]*openapi.Schema{"code": openapi.String()}, "code")},
+		}},
+	}
+	doc, err := b.Build([]openapi.Route{{Method: "POST", Path: "/widgets/:id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := func() *openapi.Document { return doc }
+
+	var reported []string
+	r := gin.New()
+	r.Use(OpenAPIResponses(spec, func(c *gin.Context, problems []string) { reported = append(reported, problems...) }))
+	r.Use(Errors(), OpenAPIRequests(spec))
+	r.POST("/widgets/:id", func(c *gin.Context) {
+		var w widget
+		_ = c.ShouldBindJSON(&w)
+		if w.Name == "bad" {
+			c.JSON(http.StatusCreated, gin.H{"name": 42})
+			return
+		}
+		c.JSON(http.StatusCreated, w)
+	})
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("/widgets/1", `{"name":"ok"}`); w.Code != http.StatusCreated || len(reported) != 0 {
+		t.Fatalf("valid request: %d %s, reported %v", w.Code, w.Body, reported)
+	}
+
+	w := send("/widgets/x", `{}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid request: status %d, body %s", w.Code, w.Body)
+	}
+	var problem struct {
+		Code   string `json:"code"`
+		Errors []struct {
+			Field string `json:"field"`
+			Rule  string `json:"rule"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Code != "validation_failed" || len(problem.Errors) != 2 ||
+		problem.Errors[0].Field != "id" || problem.Errors[1].Field != "name" || problem.Errors[1].Rule != "required" {
+		t.Errorf("problem = %+v", problem)
+	}
+	if len(reported) != 0 {
+		t.Errorf("the problem response was reported as invalid: %v", reported)
+	}
+
+	if w := send("/widgets/2", `{"name":"bad"}`); w.Code != http.StatusCreated {
+		t.Fatalf("status %d", w.Code)
+	}
+	if len(reported) != 1 || reported[0] != "name: type=string" {
+		t.Errorf("reported = %v", reported)
+	}
+}
+
+func TestResponseWrappersUnwrap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/stream", func(c *gin.Context) {
+		c.Writer = &teeWriter{ResponseWriter: c.Writer}
+		c.Writer = &responseRecorder{ResponseWriter: c.Writer}
+		if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Errorf("SetWriteDeadline through the wrappers: %d %s", resp.StatusCode, body)
+	}
+}
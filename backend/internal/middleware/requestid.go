@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"Base/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID tags every request with an ID, reusing a well-formed
+// X-Request-ID sent by the client or a proxy. The ID is echoed in the
+// response header, included in error responses, and attached to every log
+// record made with the request's context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set("requestID", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "request_id", id))
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("requestID")
+}
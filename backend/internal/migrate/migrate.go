@@ -0,0 +1,232 @@
+// Package migrate applies versioned schema migrations and records them in
+// the schema_migrations table, so the schema can be moved forward and back
+// one step at a time and its state inspected.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one schema change. Versions are applied in ascending order
+// and must never be renumbered once released.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// record is a row of schema_migrations.
+type record struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (record) TableName() string { return "schema_migrations" }
+
+// Status is a migration and when it was applied, nil if pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// ErrNoDown is returned when rolling back a migration that can't be undone.
+var ErrNoDown = errors.New("migration cannot be rolled back")
+
+// lockKey is the Postgres advisory lock held while migrating, so replicas
+// starting together don't apply the same migration twice.
+const lockKey = 727274
+
+// Migrator applies a list of migrations to a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a migrator for db. The migrations are sorted by version;
+// duplicate versions are a programming error and panic.
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			panic(fmt.Sprintf("migrate: duplicate version %d", sorted[i].Version))
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Status lists every known migration with its state, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		out[i] = Status{Migration: mig}
+		if r, ok := applied[mig.Version]; ok {
+			at := r.AppliedAt
+			out[i].AppliedAt = &at
+		}
+	}
+	return out, nil
+}
+
+// Pending lists the migrations not applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies pending migrations up to and including target, or all of them
+// when target is 0, each in its own transaction. It returns those applied.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	var done []Migration
+	for _, mig := range m.migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		ran, err := m.step(ctx, mig, true)
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", mig.Version, mig.Name, err)
+		}
+		if ran {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// Down rolls back applied migrations newer than target, newest first, so
+// Down(ctx, 0) empties the schema. It returns those rolled back.
+func (m *Migrator) Down(ctx context.Context, target int) ([]Migration, error) {
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= target {
+			break
+		}
+		ran, err := m.step(ctx, mig, false)
+		if err != nil {
+			return done, fmt.Errorf("rolling back migration %d (%s): %w", mig.Version, mig.Name, err)
+		}
+		if ran {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// Current is the newest applied version, 0 for an empty schema.
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current, nil
+}
+
+// Unapplied counts the known migrations the database hasn't applied. Unlike
+// Pending it only reads schema_migrations and never creates it, so it is
+// safe for a readiness probe; a database without the table is an error.
+func (m *Migrator) Unapplied(ctx context.Context) (int, error) {
+	var versions []int
+	if err := m.db.WithContext(ctx).Model(&record{}).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	n := 0
+	for _, mig := range m.migrations {
+		if !applied[mig.Version] {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// step applies (up) or rolls back one migration unless it is already in
+// that state, reporting whether it ran.
+func (m *Migrator) step(ctx context.Context, mig Migration, up bool) (bool, error) {
+	if !up && mig.Down == nil {
+		if applied, err := m.isApplied(m.db.WithContext(ctx), mig.Version); err != nil || !applied {
+			return false, err
+		}
+		return false, ErrNoDown
+	}
+
+	ran := false
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+		}
+		applied, err := m.isApplied(tx, mig.Version)
+		if err != nil || applied == up {
+			return err
+		}
+		if up {
+			if err := mig.Up(tx); err != nil {
+				return err
+			}
+			ran = true
+			return tx.Create(&record{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()}).Error
+		}
+		if err := mig.Down(tx); err != nil {
+			return err
+		}
+		ran = true
+		return tx.Delete(&record{}, mig.Version).Error
+	})
+	return ran, err
+}
+
+func (m *Migrator) isApplied(db *gorm.DB, version int) (bool, error) {
+	if err := db.AutoMigrate(&record{}); err != nil {
+		return false, err
+	}
+	var n int64
+	err := db.Model(&record{}).Where("version = ?", version).Count(&n).Error
+	return n > 0, err
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int]record, error) {
+	if err := db.AutoMigrate(&record{}); err != nil {
+		return nil, err
+	}
+	var rows []record
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]record, len(rows))
+	for _, r := range rows {
+		out[r.Version] = r
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"Base/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestAllUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := New(db, All)
+
+	if _, err := m.Unapplied(ctx); err == nil || db.Migrator().HasTable("schema_migrations") {
+		t.Fatalf("Unapplied on an empty database: err = %v; it must fail without creating the table", err)
+	}
+
+	done, err := m.Up(ctx, 0)
+	if err != nil || len(done) != len(All) {
+		t.Fatalf("Up = %v, %v", done, err)
+	}
+	if !db.Migrator().HasTable(&models.Entry{}) {
+		t.Fatal("entries table missing after Up")
+	}
+	if done, err := m.Up(ctx, 0); err != nil || len(done) != 0 {
+		t.Fatalf("second Up = %v, %v; want nothing to do", done, err)
+	}
+	if v, _ := m.Current(ctx); v != Latest() {
+		t.Errorf("Current = %d, want %d", v, Latest())
+	}
+	if n, err := m.Unapplied(ctx); err != nil || n != 0 {
+		t.Errorf("Unapplied = %d, %v; want 0", n, err)
+	}
+
+	if _, err := m.Down(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable(&models.Entry{}) {
+		t.Error("entries table still there after Down")
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != len(All) {
+		t.Errorf("pending after Down = %d, want %d", len(pending), len(All))
+	}
+	if n, _ := m.Unapplied(ctx); n != len(All) {
+		t.Errorf("Unapplied after Down = %d, want %d", n, len(All))
+	}
+}
+
+func TestUpToTargetAndFailures(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	var ran []int
+	step := func(v int) func(*gorm.DB) error {
+		return func(*gorm.DB) error { ran = append(ran, v); return nil }
+	}
+	boom := errors.New("boom")
+	m := New(db, []Migration{
+		{Version: 3, Name: "three", Up: func(*gorm.DB) error { return boom }},
+		{Version: 1, Name: "one", Up: step(1), Down: step(-1)},
+		{Version: 2, Name: "two", Up: step(2)},
+	})
+
+	if _, err := m.Up(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 || ran[0] != 1 || ran[1] != 2 {
+		t.Fatalf("ran %v, want [1 2] in order", ran)
+	}
+	if _, err := m.Up(ctx, 0); !errors.Is(err, boom) {
+		t.Fatalf("failing migration: err = %v", err)
+	}
+	status, _ := m.Status(ctx)
+	if status[2].AppliedAt != nil {
+		t.Error("failed migration was recorded as applied")
+	}
+
+	// 2 has no Down, so rolling back past it stops there
+	if _, err := m.Down(ctx, 0); !errors.Is(err, ErrNoDown) {
+		t.Fatalf("Down past an irreversible migration: err = %v", err)
+	}
+	if v, _ := m.Current(ctx); v != 2 {
+		t.Errorf("Current = %d, want 2", v)
+	}
+}
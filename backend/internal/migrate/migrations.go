@@ -0,0 +1,102 @@
+package migrate
+
+import (
+	"Base/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// All is the schema history of the API, oldest first.
+//
+// Add a migration for every schema change instead of editing an old one.
+// Migration 1 builds tables from the current models, so on a fresh
+// database later additive migrations find their change already made:
+// write them with AutoMigrate on the changed model, which is idempotent, and
+// keep anything else (renames, data fixes) safe to run against either shape.
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		Up: func(tx *gorm.DB) error {
+			// Databases created before versioned migrations already have
+			// these tables; AutoMigrate leaves them as they are
+			return tx.AutoMigrate(&models.User{}, &models.Entry{}, &models.ExportJob{}, &models.Review{},
+				&models.Attachment{}, &models.Thumbnail{}, &models.IdempotencyKey{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.Thumbnail{}, &models.Attachment{}, &models.Review{},
+				&models.ExportJob{}, &models.Entry{}, &models.IdempotencyKey{}, &models.User{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "audit log",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&models.AuditEvent{}); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() != "postgres" {
+				return nil
+			}
+			// Refuse changes to written records, even from the application
+			return tx.Exec(`
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&models.AuditEvent{}); err != nil {
+				return err
+			}
+			if tx.Dialector.Name() != "postgres" {
+				return nil
+			}
+			return tx.Exec("DROP FUNCTION IF EXISTS audit_events_append_only()").Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "one active export per user",
+		Up: func(tx *gorm.DB) error {
+			// Keep the newest of any duplicates the old check let through
+			if err := tx.Exec(`UPDATE export_jobs SET status = 'failed', error = 'superseded by a newer export'
+WHERE status IN ('pending', 'running') AND id NOT IN (
+	SELECT MAX(id) FROM export_jobs WHERE status IN ('pending', 'running') GROUP BY user_id)`).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_active ON export_jobs (user_id)
+WHERE status IN ('pending', 'running')`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP INDEX IF EXISTS idx_export_jobs_active").Error
+		},
+	},
+	{
+		Version: 4,
+		Name:    "unique client IDs",
+		Up: func(tx *gorm.DB) error {
+			// Concurrent syncs may already have created an offline entry
+			// twice; the oldest copy keeps the client ID
+			if err := tx.Exec(`UPDATE entries SET client_id = ''
+WHERE client_id <> '' AND id NOT IN (
+	SELECT MIN(id) FROM entries WHERE client_id <> '' GROUP BY user_id, client_id)`).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_entries_user_client ON entries (user_id, client_id)
+WHERE client_id <> ''`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP INDEX IF EXISTS idx_entries_user_client").Error
+		},
+	},
+}
+
+// Latest is the version the code expects.
+func Latest() int {
+	return All[len(All)-1].Version
+}
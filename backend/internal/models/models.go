@@ -1,6 +1,9 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,6 +13,9 @@ type User struct {
 	Email    string `gorm:"unique;" json:"email"`
 	Password string `gorm:"not null" json:"password"`
 	Role     string `gorm:"not null;default:'user'" json:"role"`
+
+	CalendarToken *string `gorm:"uniqueIndex" json:"-"` // secret for the ICS feed URL
+	Language      string  `json:"language"`             // preferred UI/API language, e.g. "ru"; empty means Accept-Language
 }
 
 type Entry struct {
@@ -18,5 +24,136 @@ type Entry struct {
 	Text      string `json:"text"`
 	Icon      string `json:"icon"`
 	Colour    string `json:"colour"`
+	Tags      string `json:"tags"`
 	UserID    uint   `json:"user_id"`
+
+	RemindAt   *time.Time `gorm:"index" json:"remind_at"`
+	Recurrence string     `json:"recurrence"` // RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO
+
+	ClientID string `gorm:"index" json:"client_id,omitempty"` // device-side ID of entries created offline; unique per user
+}
+
+// TagList returns the entry's space-separated tags.
+func (e *Entry) TagList() []string {
+	return strings.Fields(e.Tags)
+}
+
+// JoinTags normalises tags into the stored form: space-separated, no
+// duplicates, with any whitespace inside a tag replaced by underscores.
+func JoinTags(tags []string) string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(t), "_")
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		out = append(out, t)
+	}
+	return strings.Join(out, " ")
+}
+
+// Review is one study session of an entry, e.g. carried over from Anki.
+type Review struct {
+	gorm.Model
+	EntryID    uint      `gorm:"index;not null" json:"entry_id"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+	Ease       int       `json:"ease"`     // 1 (again) to 4 (easy)
+	Interval   int       `json:"interval"` // days until the next review
+	Source     string    `json:"source"`
+}
+
+// Export job statuses
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+// ExportJob tracks an asynchronous personal data export for a user.
+type ExportJob struct {
+	gorm.Model
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	Status        string     `gorm:"not null;default:'pending'" json:"status"`
+	Error         string     `json:"error,omitempty"`
+	FilePath      string     `json:"-"`
+	Size          int64      `json:"size"`
+	DownloadToken string     `gorm:"index" json:"-"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// Attachment is a file (photo, voice memo, ...) attached to an entry. The
+// bytes live in the blob store under StorageKey.
+type Attachment struct {
+	gorm.Model
+	EntryID     uint   `gorm:"index;not null" json:"entry_id"`
+	UserID      uint   `gorm:"index;not null" json:"user_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	StorageKey  string `gorm:"not null" json:"-"`
+
+	// Filled in by background image processing
+	Status     string      `json:"status"`
+	Width      int         `json:"width,omitempty"`
+	Height     int         `json:"height,omitempty"`
+	CapturedAt *time.Time  `json:"captured_at,omitempty"`
+	Thumbnails []Thumbnail `json:"thumbnails,omitempty"`
+}
+
+// Attachment processing statuses
+const (
+	AttachmentReady      = "ready"
+	AttachmentProcessing = "processing"
+	AttachmentFailed     = "failed"
+)
+
+// Thumbnail is a downscaled, upright copy of an image attachment.
+type Thumbnail struct {
+	gorm.Model
+	AttachmentID uint   `gorm:"index;not null" json:"attachment_id"`
+	Size         int    `json:"size"` // longest side requested, in pixels
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	ContentType  string `json:"content_type"`
+	StorageKey   string `gorm:"not null" json:"-"`
+}
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so a retried request gets the same answer instead
+// of being applied twice. Status is 0 while the first request is in flight.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primarykey"`
+	Scope       string `gorm:"uniqueIndex:idx_idempotency_scope_key;not null"` // "user:<id>" or "anonymous"
+	Key         string `gorm:"uniqueIndex:idx_idempotency_scope_key;not null"`
+	RequestHash string `gorm:"not null"`
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
+
+// AuditEvent is one record of the append-only audit log of admin and
+// security actions. Hash covers the record and the previous record's hash,
+// so editing, removing or reordering records breaks the chain.
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement:false" json:"id"` // gapless, assigned on append
+	CreatedAt  time.Time `gorm:"index;not null" json:"created_at"`
+	ActorID    uint      `gorm:"index" json:"actor_id"`  // 0 without a signed-in user, e.g. a failed login
+	Source     string    `gorm:"not null" json:"source"` // "api" or "cli"
+	Action     string    `gorm:"index;not null" json:"action"`
+	TargetType string    `gorm:"index:idx_audit_target" json:"target_type"`
+	TargetID   uint      `gorm:"index:idx_audit_target" json:"target_id"`
+	Changes    string    `gorm:"type:text" json:"-"` // JSON {"field": {"before": ..., "after": ...}}
+	IP         string    `json:"ip"`
+	RequestID  string    `json:"request_id"`
+	PrevHash   string    `gorm:"size:64;not null" json:"prev_hash"`
+	Hash       string    `gorm:"size:64;uniqueIndex;not null" json:"hash"`
 }
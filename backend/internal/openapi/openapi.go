@@ -0,0 +1,320 @@
+// Package openapi builds the OpenAPI 3.1 description of the API and
+// validates requests and responses against it.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+// Document is an OpenAPI document. Only the parts this API uses are modelled.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+
+	// Security overrides the document's default; see Public.
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query or header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"` // http or apiKey
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the schemes that together authorise a request.
+type SecurityRequirement map[string][]string
+
+// Route is a registered route, as in gin.RouteInfo.
+type Route struct {
+	Method string
+	Path   string // gin syntax, e.g. /entries/:id
+}
+
+// Builder assembles a Document from the registered routes and a
+// description of each operation. Go types referenced with SchemaOf are
+// turned into components.
+type Builder struct {
+	Info            Info
+	Tags            []Tag
+	Security        []SecurityRequirement
+	SecuritySchemes map[string]SecurityScheme
+
+	// Operations describes each route, keyed by "METHOD /gin/path".
+	Operations map[string]*Operation
+
+	// Legacy maps a deprecated route key to the key of its successor, whose
+	// description is reused with deprecated set.
+	Legacy map[string]string
+
+	// Default is added to every operation's responses under "default",
+	// typically the error format.
+	Default *Response
+}
+
+// Build describes every route. It fails listing the routes that have no
+// description, so a test can make sure the document stays complete.
+func (b *Builder) Build(routes []Route) (*Document, error) {
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     b.Info,
+		Tags:     b.Tags,
+		Paths:    map[string]PathItem{},
+		Security: b.Security,
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: b.SecuritySchemes,
+		},
+	}
+	r := &reflector{schemas: doc.Components.Schemas, names: map[reflect.Type]string{}}
+
+	var missing []string
+	for _, rt := range routes {
+		key := rt.Method + " " + rt.Path
+		op, deprecated := b.Operations[key], false
+		if op == nil {
+			if successor, ok := b.Legacy[key]; ok {
+				op, deprecated = b.Operations[successor], true
+			}
+		}
+		if op == nil {
+			missing = append(missing, key)
+			continue
+		}
+
+		out := *op
+		if deprecated {
+			out.Deprecated = true
+			out.OperationID = "legacy" + strings.ToUpper(out.OperationID[:1]) + out.OperationID[1:]
+		}
+		out.Parameters = append(pathParameters(rt.Path, op.Parameters), op.Parameters...)
+		if out.RequestBody != nil {
+			body := *out.RequestBody
+			body.Content = r.content(body.Content)
+			out.RequestBody = &body
+		}
+		out.Responses = map[string]*Response{}
+		for status, resp := range op.Responses {
+			res := *resp
+			res.Content = r.content(resp.Content)
+			out.Responses[status] = &res
+		}
+		if _, ok := out.Responses["default"]; !ok && b.Default != nil {
+			res := *b.Default
+			res.Content = r.content(b.Default.Content)
+			out.Responses["default"] = &res
+		}
+		for i, p := range out.Parameters {
+			out.Parameters[i].Schema = r.resolve(p.Schema)
+		}
+
+		path := OpenAPIPath(rt.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(rt.Method)] = &out
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return doc, fmt.Errorf("openapi: undocumented routes: %s", strings.Join(missing, ", "))
+	}
+	return doc, nil
+}
+
+func (r *reflector) content(in map[string]MediaType) map[string]MediaType {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]MediaType, len(in))
+	for ct, mt := range in {
+		out[ct] = MediaType{Schema: r.resolve(mt.Schema)}
+	}
+	return out
+}
+
+// pathParameters describes the ":name" segments of a gin path that aren't
+// already described by the operation. They are strings unless the name
+// is id.
+func pathParameters(path string, described []Parameter) []Parameter {
+	var params []Parameter
+	for _, seg := range strings.Split(path, "/") {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+		name := seg[1:]
+		if hasParameter(described, name, "path") {
+			continue
+		}
+		schema := String()
+		if name == "id" {
+			schema = Integer()
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+func hasParameter(params []Parameter, name, in string) bool {
+	for _, p := range params {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAPIPath converts a gin path such as /entries/:id to /entries/{id}.
+func OpenAPIPath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// Operation returns the operation for a request matched to the gin route
+// path, or nil.
+func (d *Document) Operation(method, ginPath string) *Operation {
+	item := d.Paths[OpenAPIPath(ginPath)]
+	if item == nil {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// Response returns the documented response for status, falling back to the
+// NXX range and then default.
+func (o *Operation) Response(status int) *Response {
+	if r, ok := o.Responses[fmt.Sprint(status)]; ok {
+		return r
+	}
+	if r, ok := o.Responses[fmt.Sprintf("%dXX", status/100)]; ok {
+		return r
+	}
+	return o.Responses["default"]
+}
+
+// MarshalIndent renders the document the way it is committed to the repo.
+func (d *Document) MarshalIndent() ([]byte, error) {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// JSON is a media type map for a single application/json schema.
+func JSON(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+// Body is a required JSON request body.
+func Body(s *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: JSON(s)}
+}
+
+// Multipart is a required multipart/form-data request body.
+func Multipart(s *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: s}}}
+}
+
+// Reply is a response with a JSON body; a nil schema means no body.
+func Reply(description string, s *Schema) *Response {
+	r := &Response{Description: description}
+	if s != nil {
+		r.Content = JSON(s)
+	}
+	return r
+}
+
+// Binary is a response whose body is a file of the given media types.
+func Binary(description string, contentTypes ...string) *Response {
+	r := &Response{Description: description, Content: map[string]MediaType{}}
+	for _, ct := range contentTypes {
+		r.Content[ct] = MediaType{Schema: &Schema{Type: Types{"string"}, ContentMediaType: ct}}
+	}
+	return r
+}
+
+// Query is an optional query parameter.
+func Query(name, description string, s *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: s}
+}
+
+// Public is the security of an operation that needs no credentials.
+func Public() *[]SecurityRequirement {
+	return &[]SecurityRequirement{}
+}
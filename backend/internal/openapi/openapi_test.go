@@ -0,0 +1,112 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type note struct {
+	Title   string     `json:"title" binding:"required,max=5"`
+	Kind    string     `json:"kind" binding:"omitempty,oneof=a b"`
+	Email   string     `json:"email" binding:"omitempty,email"`
+	Due     *time.Time `json:"due"`
+	Tags    []string   `json:"tags,omitempty"`
+	Count   uint       `json:"count"`
+	Private string     `json:"-"`
+}
+
+func buildTestDoc(t *testing.T) *Document {
+	t.Helper()
+	b := &Builder{
+		Info: Info{Title: "test", Version: "1"},
+		Operations: map[string]*Operation{
+			"POST /notes/:id": {OperationID: "createNote", RequestBody: Body(SchemaOf(note{})),
+				Responses: map[string]*Response{"201": Reply("Created", SchemaOf(note{}))}},
+		},
+		Legacy:  map[string]string{"POST /old/notes/:id": "POST /notes/:id"},
+		Default: Reply("Error", Object(map[string]*Schema{"code": String()}, "code")),
+	}
+	doc, err := b.Build([]Route{{"POST", "/notes/:id"}, {"POST", "/old/notes/:id"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestBuildDescribesRoutesAndComponents(t *testing.T) {
+	doc := buildTestDoc(t)
+
+	op := doc.Operation("POST", "/notes/:id")
+	if op == nil || op.Deprecated {
+		t.Fatalf("operation = %+v", op)
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+		t.Errorf("parameters = %+v", op.Parameters)
+	}
+	if op.Response(500) == nil {
+		t.Error("default response missing")
+	}
+
+	legacy := doc.Operation("POST", "/old/notes/:id")
+	if legacy == nil || !legacy.Deprecated || legacy.OperationID != "legacyCreateNote" {
+		t.Errorf("legacy operation = %+v", legacy)
+	}
+
+	s := doc.Components.Schemas["Note"]
+	if s == nil {
+		t.Fatal("Note component missing")
+	}
+	if len(s.Required) != 1 || s.Required[0] != "title" {
+		t.Errorf("required = %v", s.Required)
+	}
+	if _, ok := s.Properties["Private"]; ok {
+		t.Error(`json:"-" field was described`)
+	}
+	if got := s.Properties["kind"].Enum; len(got) != 3 || got[0] != "" {
+		t.Errorf("kind enum = %v, want empty string allowed by omitempty", got)
+	}
+}
+
+func TestBuildReportsUndocumentedRoutes(t *testing.T) {
+	b := &Builder{Operations: map[string]*Operation{}}
+	_, err := b.Build([]Route{{"GET", "/missing"}})
+	if err == nil || !strings.Contains(err.Error(), "GET /missing") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := buildTestDoc(t)
+	schema := doc.Operation("POST", "/notes/:id").RequestBody.Content["application/json"].Schema
+
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{`{"title":"hi","due":null,"tags":["x"],"count":2}`, nil},
+		{`{"due":"2026-01-02T03:04:05Z"}`, []string{"title: required"}},
+		{`{"title":"too long"}`, []string{"title: max=5"}},
+		{`{"title":"ok","kind":"c"}`, []string{"kind: oneof= a b"}},
+		{`{"title":"ok","due":"tomorrow","email":"nope"}`, []string{"due: format=date-time", "email: format=email"}},
+		{`{"title":"ok","tags":[1],"count":-1}`, []string{"count: gte=0", "tags.0: type=string"}},
+		{`[]`, []string{"(body): type=object"}},
+	}
+	for _, tt := range tests {
+		value, err := DecodeJSON([]byte(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range doc.Validate(schema, value) {
+			got = append(got, v.String())
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Validate(%s) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+
+	if v := doc.ValidateValue(Integer(), "abc", "id"); len(v) != 1 || v[0].Rule != "type" {
+		t.Errorf("ValidateValue(abc) = %v", v)
+	}
+}
@@ -0,0 +1,308 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Schema is a JSON Schema (draft 2020-12, as used by OpenAPI 3.1).
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ContentMediaType     string             `json:"contentMediaType,omitempty"`
+
+	// goType is resolved into a component reference when the document is built.
+	goType reflect.Type
+}
+
+// Types is the JSON Schema "type" keyword: one type, or several when the
+// value may also be null.
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t Types) has(name string) bool {
+	for _, s := range t {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func String() *Schema  { return &Schema{Type: Types{"string"}} }
+func Integer() *Schema { return &Schema{Type: Types{"integer"}} }
+func Boolean() *Schema { return &Schema{Type: Types{"boolean"}} }
+func Any() *Schema     { return &Schema{} }
+
+// DateTime is an RFC 3339 timestamp.
+func DateTime() *Schema { return &Schema{Type: Types{"string"}, Format: "date-time"} }
+
+// Enum is a string restricted to values.
+func Enum(values ...string) *Schema {
+	s := String()
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+func Array(items *Schema) *Schema { return &Schema{Type: Types{"array"}, Items: items} }
+
+// Object is an object with the given properties, of which required must
+// be present.
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: Types{"object"}, Properties: properties, Required: required}
+}
+
+// MapOf is an object whose values all match values.
+func MapOf(values *Schema) *Schema {
+	return &Schema{Type: Types{"object"}, AdditionalProperties: values}
+}
+
+// Nullable allows null in addition to s.
+func Nullable(s *Schema) *Schema {
+	if s.Ref != "" || s.goType != nil {
+		return &Schema{OneOf: []*Schema{s, {Type: Types{"null"}}}}
+	}
+	out := *s
+	if !out.Type.has("null") {
+		out.Type = append(append(Types{}, out.Type...), "null")
+	}
+	return &out
+}
+
+// Describe returns a copy of s with a description.
+func Describe(s *Schema, description string) *Schema {
+	out := *s
+	out.Description = description
+	return &out
+}
+
+// SchemaOf refers to the schema of v's Go type, derived from its json and
+// binding tags. Named struct types become components.
+func SchemaOf(v interface{}) *Schema {
+	return &Schema{goType: reflect.TypeOf(v)}
+}
+
+// Ref refers to a component that is added by hand.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+type reflector struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+)
+
+// resolve replaces every SchemaOf placeholder in s with a real schema.
+func (r *reflector) resolve(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.goType != nil {
+		return r.schemaFor(s.goType)
+	}
+	out := *s
+	if s.Properties != nil {
+		out.Properties = make(map[string]*Schema, len(s.Properties))
+		for k, p := range s.Properties {
+			out.Properties[k] = r.resolve(p)
+		}
+	}
+	out.Items = r.resolve(s.Items)
+	out.AdditionalProperties = r.resolve(s.AdditionalProperties)
+	if s.OneOf != nil {
+		out.OneOf = make([]*Schema, len(s.OneOf))
+		for i, o := range s.OneOf {
+			out.OneOf[i] = r.resolve(o)
+		}
+	}
+	return &out
+}
+
+func (r *reflector) schemaFor(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return DateTime()
+	case deletedAtType:
+		return Nullable(DateTime())
+	case rawType:
+		return Any()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return Nullable(r.schemaFor(t.Elem()))
+	case reflect.String:
+		return String()
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Integer()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s := Integer()
+		zero := 0.0
+		s.Minimum = &zero
+		return s
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Types{"string"}, ContentMediaType: "application/octet-stream"}
+		}
+		// A nil slice is encoded as null.
+		return Nullable(Array(r.schemaFor(t.Elem())))
+	case reflect.Map:
+		return Nullable(MapOf(r.schemaFor(t.Elem())))
+	case reflect.Interface:
+		return Any()
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name, ok := r.names[t]
+		if !ok {
+			name = componentName(t)
+			if _, taken := r.schemas[name]; taken {
+				name = upperFirst(path.Base(t.PkgPath())) + name
+			}
+			r.names[t] = name
+			r.schemas[name] = &Schema{} // placeholder for recursive types
+			*r.schemas[name] = *r.structSchema(t)
+		}
+		return Ref(name)
+	}
+	return Any()
+}
+
+// componentName is the exported form of a type's name, e.g. batchRequest
+// becomes BatchRequest. Clashing names are prefixed with the package name.
+func componentName(t reflect.Type) string {
+	return upperFirst(t.Name())
+}
+
+func upperFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func (r *reflector) structSchema(t reflect.Type) *Schema {
+	s := Object(map[string]*Schema{})
+	r.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (r *reflector) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := r.schemaFor(f.Type)
+		if opts == "string" {
+			fs = String()
+		}
+		if applyBinding(fs, f.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// applyBinding translates the validator rules of a binding tag into schema
+// constraints and reports whether the field is required.
+func applyBinding(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+	omitempty := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			omitempty = true
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case s.Type.has("string") && name == "min":
+				s.MinLength = &n
+			case s.Type.has("string"):
+				s.MaxLength = &n
+			case s.Type.has("array") && name == "min":
+				s.MinItems = &n
+			case s.Type.has("array"):
+				s.MaxItems = &n
+			}
+		}
+	}
+	if omitempty && len(s.Enum) > 0 {
+		s.Enum = append([]interface{}{""}, s.Enum...)
+	}
+	return required
+}
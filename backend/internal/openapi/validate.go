@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is one way a value doesn't match its schema. Rule and Param
+// follow the binding rule names used in error responses (required, type,
+// oneof, min, max, gte, lte, format).
+type Violation struct {
+	Path  string // dotted path of the offending value, e.g. operations.0.op
+	Rule  string
+	Param string
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(body)"
+	}
+	if v.Param == "" {
+		return path + ": " + v.Rule
+	}
+	return path + ": " + v.Rule + "=" + v.Param
+}
+
+// DecodeJSON decodes a body for validation, keeping numbers exact.
+func DecodeJSON(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Validate checks a decoded JSON value against s, resolving component
+// references in d.
+func (d *Document) Validate(s *Schema, value interface{}) []Violation {
+	var out []Violation
+	d.validate(s, value, "", &out)
+	return out
+}
+
+func (d *Document) component(ref string) *Schema {
+	return d.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+}
+
+func (d *Document) validate(s *Schema, v interface{}, path string, out *[]Violation) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		d.validate(d.component(s.Ref), v, path, out)
+		return
+	}
+	if len(s.OneOf) > 0 {
+		var best []Violation
+		for i, alt := range s.OneOf {
+			var errs []Violation
+			d.validate(alt, v, path, &errs)
+			if len(errs) == 0 {
+				return
+			}
+			if i == 0 || len(errs) < len(best) {
+				best = errs
+			}
+		}
+		*out = append(*out, best...)
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.has(jsonType(v)) && !(jsonType(v) == "integer" && s.Type.has("number")) {
+		*out = append(*out, Violation{Path: path, Rule: "type", Param: strings.Join(s.Type, "|")})
+		return
+	}
+	if len(s.Enum) > 0 && v != nil && !inEnum(s.Enum, v) {
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = fmt.Sprint(e)
+		}
+		*out = append(*out, Violation{Path: path, Rule: "oneof", Param: strings.Join(values, " ")})
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			*out = append(*out, Violation{Path: path, Rule: "min", Param: strconv.Itoa(*s.MinLength)})
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			*out = append(*out, Violation{Path: path, Rule: "max", Param: strconv.Itoa(*s.MaxLength)})
+		}
+		if !validFormat(s.Format, v) {
+			*out = append(*out, Violation{Path: path, Rule: "format", Param: s.Format})
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err == nil && s.Minimum != nil && f < *s.Minimum {
+			*out = append(*out, Violation{Path: path, Rule: "gte", Param: strconv.FormatFloat(*s.Minimum, 'f', -1, 64)})
+		}
+		if err == nil && s.Maximum != nil && f > *s.Maximum {
+			*out = append(*out, Violation{Path: path, Rule: "lte", Param: strconv.FormatFloat(*s.Maximum, 'f', -1, 64)})
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			*out = append(*out, Violation{Path: path, Rule: "min", Param: strconv.Itoa(*s.MinItems)})
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			*out = append(*out, Violation{Path: path, Rule: "max", Param: strconv.Itoa(*s.MaxItems)})
+		}
+		for i, item := range v {
+			d.validate(s.Items, item, join(path, strconv.Itoa(i)), out)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*out = append(*out, Violation{Path: join(path, name), Rule: "required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				d.validate(p, v[k], join(path, k), out)
+			} else if s.AdditionalProperties != nil {
+				d.validate(s.AdditionalProperties, v[k], join(path, k), out)
+			}
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func validFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, v)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	}
+	return true
+}
+
+// ValidateValue checks a path or query parameter, which arrives as a string.
+func (d *Document) ValidateValue(s *Schema, raw, path string) []Violation {
+	var v interface{} = raw
+	if s != nil && (s.Type.has("integer") || s.Type.has("number")) {
+		v = json.Number(raw)
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return []Violation{{Path: path, Rule: "type", Param: strings.Join(s.Type, "|")}}
+		}
+	} else if s != nil && s.Type.has("boolean") {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []Violation{{Path: path, Rule: "type", Param: "boolean"}}
+		}
+		v = b
+	}
+	var out []Violation
+	d.validate(s, v, path, &out)
+	return out
+}
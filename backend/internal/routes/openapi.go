@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"strings"
+
+	handlers "Base/internal/handlers"
+	"Base/internal/middleware"
+	"Base/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI describes every route registered on r except the Swagger UI. It
+// returns an error naming any route without a description in
+// handlers.Operations.
+func OpenAPI(r *gin.Engine) (*openapi.Document, error) {
+	ops := handlers.Operations()
+	ops["GET /openapi.json"] = &openapi.Operation{
+		Tags: []string{"meta"}, OperationID: "getOpenAPI", Summary: "This document",
+		Security:  openapi.Public(),
+		Responses: map[string]*openapi.Response{"200": openapi.Reply("OpenAPI 3.1 document", openapi.MapOf(openapi.Any()))},
+	}
+
+	legacy := map[string]string{}
+	for _, u := range middleware.LegacyUsage() {
+		legacy[u.Method+" "+u.Route] = u.Method + " " + u.Successor
+	}
+
+	var routes []openapi.Route
+	for _, ri := range r.Routes() {
+		if strings.HasPrefix(ri.Path, "/swagger/") {
+			continue
+		}
+		routes = append(routes, openapi.Route{Method: ri.Method, Path: ri.Path})
+	}
+
+	b := &openapi.Builder{
+		Info: openapi.Info{
+			Title:       "Reminder-Card API",
+			Version:     "1.0",
+			Description: "Errors are RFC 7807 problem details (application/problem+json) with a stable code. Routes outside /api/v1 are deprecated.",
+		},
+		Tags: []openapi.Tag{
+			{Name: "auth", Description: "Registration and sessions"},
+			{Name: "account", Description: "The current user"},
+			{Name: "entries", Description: "Reminder entries"},
+			{Name: "transfer", Description: "Import and export of entries"},
+			{Name: "attachments", Description: "Files attached to entries"},
+			{Name: "events", Description: "Live updates"},
+			{Name: "calendar", Description: "iCalendar feed and import"},
+			{Name: "exports", Description: "Personal data exports"},
+			{Name: "admin", Description: "Administration, admin role only"},
+			{Name: "meta", Description: "API description"},
+			{Name: "health", Description: "Liveness and readiness probes"},
+		},
+		Security: []openapi.SecurityRequirement{{"bearerAuth": {}}, {"cookieAuth": {}}},
+		SecuritySchemes: map[string]openapi.SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token", Description: "The JWT set by login"},
+		},
+		Operations: ops,
+		Legacy:     legacy,
+		Default:    handlers.ProblemResponse,
+	}
+	return b.Build(routes)
+}
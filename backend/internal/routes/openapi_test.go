@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"Base/internal/config"
+	"Base/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+var update = flag.Bool("update", false, "rewrite docs/openapi.json")
+
+const specFile = "../../docs/openapi.json"
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	SetupProbes(r, health.New(time.Second))
+	SetupRoutes(r, config.Defaults())
+
+	doc, err := OpenAPI(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := doc.MarshalIndent()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile(specFile, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run go test ./internal/routes -update", specFile)
+	}
+}
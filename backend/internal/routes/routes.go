@@ -1,9 +1,15 @@
 package routes
 
 import (
+	"Base/internal/config"
 	handlers "Base/internal/handlers"
+	"Base/internal/health"
 
 	"Base/internal/middleware"
+	"Base/internal/openapi"
+	"log/slog"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -13,51 +19,191 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(r *gin.Engine) {
+// SetupProbes registers /ping and the health probes. Call it before
+// SetupRoutes so that probes skip the API middleware.
+func SetupProbes(r *gin.Engine, probes *health.Probes) {
+	r.GET("/ping", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "pong",
+		})
+	})
+
+	r.GET("/health", probes.Health)
+	r.GET("/livez", probes.Live)
+	r.GET("/readyz", probes.Ready)
+}
+
+// SetupRoutes registers every route on r, configured by cfg.
+func SetupRoutes(r *gin.Engine, cfg *config.Config) {
+	tokens := middleware.NewTokens(cfg.Auth.JWTSecret)
+	handlers.SetTokens(tokens)
+	handlers.SetAdminPassword(cfg.Auth.AdminPassword)
+	handlers.SetPublicURL(cfg.Server.BaseURL())
+	handlers.SetLegacySunset(cfg.API.LegacySunset)
+	handlers.SetAttachments(cfg.Attachments)
+	handlers.SetExports(cfg.Exports)
+
 	r.Use(cors.New(cors.Config{
 		AllowOriginFunc: func(origin string) bool {
 			// Allow all localhost origins and any origin ending in .onrender.com
 			return true // For development, let's just allow anything that contacts us if they have the right headers
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "X-Requested-With", middleware.IdempotencyHeader, middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Language", middleware.ReplayedHeader, middleware.RequestIDHeader, middleware.DeprecationHeader, middleware.SunsetHeader, middleware.LinkHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// 2. PUBLIC USER ROUTES (No Token Needed)
+	// In dev and test, requests and responses are checked against the
+	// OpenAPI document, which is built once every route is registered
+	var spec *openapi.Document
+	getSpec := func() *openapi.Document { return spec }
+	validateRequests, validateResponses := middleware.OpenAPIValidation(cfg.API.OpenAPIValidation)
+
+	// Every error is rendered as application/problem+json with the request ID,
+	// in the user's language
+	r.Use(middleware.RequestID(), middleware.Logger(), middleware.Locale())
+	if validateResponses {
+		r.Use(middleware.OpenAPIResponses(getSpec, nil))
+	}
+	r.Use(middleware.Errors())
+	if validateRequests {
+		r.Use(middleware.OpenAPIRequests(getSpec))
+	}
+	r.NoRoute(middleware.NotFound)
+
+	// Retried POSTs with an Idempotency-Key are answered from the stored response
+	idempotent := middleware.Idempotency(handlers.DB, cfg.API.IdempotencyTTL)
+
+	// Versioned REST API
+	v1 := r.Group("/api/v1")
+	{
+		v1.POST("/session", handlers.Login)
+		v1.POST("/users", idempotent, handlers.CreateUser)
+		v1.GET("/exports/:id/download", handlers.DownloadExport) // Authorised by the link token
+	}
+
+	authed := v1.Group("")
+	authed.Use(tokens.AuthMiddleware())
+	{
+		authed.POST("/session/refresh", handlers.RefreshSession)
+		authed.DELETE("/session", handlers.Logout)
+		authed.GET("/me", handlers.GetMe)
+		authed.GET("/me/preferences", handlers.GetPreferences)
+		authed.PUT("/me/preferences", handlers.UpdatePreferences)
+		authed.GET("/entries", handlers.GetEntries)
+		authed.POST("/entries", idempotent, handlers.CreateEntry)
+		authed.GET("/entries/:id", handlers.GetEntry)
+		authed.PUT("/entries/:id", handlers.UpdateEntry)
+		authed.DELETE("/entries/:id", handlers.DeleteEntry)
+		authed.GET("/entries/export", handlers.ExportEntries)
+		authed.POST("/entries/import", handlers.ImportEntries)
+		authed.GET("/entries/export/anki", handlers.ExportAnki)
+		authed.POST("/entries/import/anki", handlers.ImportAnki)
+		authed.POST("/entries/sync", handlers.SyncEntries)
+		authed.POST("/entries/batch", idempotent, handlers.BatchEntries)
+		authed.GET("/entries/:id/attachments", handlers.GetAttachments)
+		authed.POST("/entries/:id/attachments", handlers.UploadAttachment)
+		authed.GET("/entries/:id/suggested-date", handlers.SuggestEntryDate)
+		authed.GET("/attachments/:id", handlers.DownloadAttachment)
+		authed.GET("/attachments/:id/thumbnails/:size", handlers.DownloadThumbnail)
+		authed.DELETE("/attachments/:id", handlers.DeleteAttachment)
+		authed.GET("/events", handlers.StreamEvents) // Server-Sent Events
+		authed.POST("/calendar/token", handlers.RotateCalendarToken)
+		authed.DELETE("/calendar/token", handlers.RevokeCalendarToken)
+		authed.POST("/calendar/import", handlers.ImportCalendar)
+		authed.POST("/exports", handlers.RequestExport)
+		authed.GET("/exports/:id", handlers.GetExport)
+	}
+
+	adminV1 := v1.Group("/admin")
+	adminV1.Use(tokens.AuthAdminMiddleware(), idempotent)
+	{
+		adminV1.GET("/users", handlers.GetAllUsers)
+		adminV1.PUT("/users/:id", handlers.UpdateUser)
+		adminV1.DELETE("/users/:id", handlers.DeleteUser)
+		adminV1.GET("/entries", handlers.GetAllEntries)
+		adminV1.PUT("/entries/:id", handlers.UpdateAnyEntry)
+		adminV1.DELETE("/entries/:id", handlers.DeleteAnyEntry)
+		adminV1.POST("/entries/batch", handlers.AdminBatchEntries)
+		adminV1.GET("/deprecations", handlers.GetDeprecatedRouteUsage)
+		adminV1.GET("/audit", handlers.GetAuditEvents)
+		adminV1.GET("/audit/export", handlers.ExportAuditEvents)
+		adminV1.GET("/audit/verify", handlers.VerifyAuditLog)
+	}
+
+	// Legacy unversioned routes, kept until the sunset date. Each answers with
+	// Deprecation, Sunset and Link headers pointing at its /api/v1 successor.
+	legacy := func(g *gin.RouterGroup, method, path, successor string, h ...gin.HandlerFunc) {
+		route := strings.TrimSuffix(g.BasePath(), "/") + path
+		g.Handle(method, path, append([]gin.HandlerFunc{middleware.Deprecated(method, route, successor, cfg.API.LegacySunset)}, h...)...)
+	}
+
 	public := r.Group("/user")
 	{
-		public.POST("/login", handlers.Login)
-		public.POST("/create", handlers.CreateUser) // Registration
+		legacy(public, "POST", "/login", "/api/v1/session", handlers.Login)
+		legacy(public, "POST", "/create", "/api/v1/users", idempotent, handlers.CreateUser)
+		legacy(public, "GET", "/exports/:id/download", "/api/v1/exports/:id/download", handlers.DownloadExport)
 	}
 
-	// 3. PROTECTED USER ROUTES (Token Needed!)
-	// This is why regular users weren't getting data properly
 	protectedUser := r.Group("/user")
-	protectedUser.Use(middleware.AuthMiddleware())
+	protectedUser.Use(tokens.AuthMiddleware())
 	{
-		protectedUser.POST("/logout", handlers.Logout)
-		protectedUser.POST("/getusername", handlers.GetUsername)
-		protectedUser.GET("/entries", handlers.GetEntries) // Now works because middleware sets UserID
-		protectedUser.POST("/entries", handlers.CreateEntry)
-		protectedUser.PUT("/entries/:id", handlers.UpdateEntry)
-		protectedUser.DELETE("/entries/:id", handlers.DeleteEntry)
+		legacy(protectedUser, "POST", "/logout", "/api/v1/session", handlers.Logout)
+		legacy(protectedUser, "POST", "/getusername", "/api/v1/me", handlers.GetUsername)
+		legacy(protectedUser, "GET", "/entries", "/api/v1/entries", handlers.GetEntries)
+		legacy(protectedUser, "POST", "/entries", "/api/v1/entries", idempotent, handlers.CreateEntry)
+		legacy(protectedUser, "PUT", "/entries/:id", "/api/v1/entries/:id", handlers.UpdateEntry)
+		legacy(protectedUser, "DELETE", "/entries/:id", "/api/v1/entries/:id", handlers.DeleteEntry)
+		legacy(protectedUser, "GET", "/entries/export", "/api/v1/entries/export", handlers.ExportEntries)
+		legacy(protectedUser, "POST", "/entries/import", "/api/v1/entries/import", handlers.ImportEntries)
+		legacy(protectedUser, "GET", "/entries/export/anki", "/api/v1/entries/export/anki", handlers.ExportAnki)
+		legacy(protectedUser, "POST", "/entries/import/anki", "/api/v1/entries/import/anki", handlers.ImportAnki)
+		legacy(protectedUser, "POST", "/entries/sync", "/api/v1/entries/sync", handlers.SyncEntries)
+		legacy(protectedUser, "POST", "/entries/batch", "/api/v1/entries/batch", idempotent, handlers.BatchEntries)
+		legacy(protectedUser, "GET", "/entries/:id/attachments", "/api/v1/entries/:id/attachments", handlers.GetAttachments)
+		legacy(protectedUser, "POST", "/entries/:id/attachments", "/api/v1/entries/:id/attachments", handlers.UploadAttachment)
+		legacy(protectedUser, "GET", "/entries/:id/suggested-date", "/api/v1/entries/:id/suggested-date", handlers.SuggestEntryDate)
+		legacy(protectedUser, "GET", "/attachments/:id", "/api/v1/attachments/:id", handlers.DownloadAttachment)
+		legacy(protectedUser, "GET", "/attachments/:id/thumbnails/:size", "/api/v1/attachments/:id/thumbnails/:size", handlers.DownloadThumbnail)
+		legacy(protectedUser, "DELETE", "/attachments/:id", "/api/v1/attachments/:id", handlers.DeleteAttachment)
+		legacy(protectedUser, "GET", "/events", "/api/v1/events", handlers.StreamEvents)
+		legacy(protectedUser, "POST", "/calendar/token", "/api/v1/calendar/token", handlers.RotateCalendarToken)
+		legacy(protectedUser, "DELETE", "/calendar/token", "/api/v1/calendar/token", handlers.RevokeCalendarToken)
+		legacy(protectedUser, "POST", "/calendar/import", "/api/v1/calendar/import", handlers.ImportCalendar)
+		legacy(protectedUser, "POST", "/exports", "/api/v1/exports", handlers.RequestExport)
+		legacy(protectedUser, "GET", "/exports/:id", "/api/v1/exports/:id", handlers.GetExport)
 	}
 
-	// 4. ADMIN ROUTES
 	admin := r.Group("/admin")
-	admin.Use(middleware.AuthAdminMiddleware())
+	admin.Use(tokens.AuthAdminMiddleware(), idempotent)
 	{
-		admin.GET("/entries", handlers.GetAllEntries)
-		admin.GET("/users", handlers.GetAllUsers)
-		admin.PUT("/entries/:id", handlers.UpdateAnyEntry)
-		admin.DELETE("/entries/:id", handlers.DeleteAnyEntry)
-		admin.PUT("/users/:id", handlers.UpdateUser)
-		admin.DELETE("/users/:id", handlers.DeleteUser)
+		legacy(admin, "GET", "/entries", "/api/v1/admin/entries", handlers.GetAllEntries)
+		legacy(admin, "GET", "/users", "/api/v1/admin/users", handlers.GetAllUsers)
+		legacy(admin, "PUT", "/entries/:id", "/api/v1/admin/entries/:id", handlers.UpdateAnyEntry)
+		legacy(admin, "DELETE", "/entries/:id", "/api/v1/admin/entries/:id", handlers.DeleteAnyEntry)
+		legacy(admin, "POST", "/entries/batch", "/api/v1/admin/entries/batch", handlers.AdminBatchEntries)
+		legacy(admin, "PUT", "/users/:id", "/api/v1/admin/users/:id", handlers.UpdateUser)
+		legacy(admin, "DELETE", "/users/:id", "/api/v1/admin/users/:id", handlers.DeleteUser)
 	}
 
-	// Swagger and Dash
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Private ICS feed, authorised by the secret token in the URL. It stays
+	// unversioned: calendar apps keep subscribed URLs forever.
+	r.GET("/calendar/:token", handlers.CalendarFeed)
+
+	// OpenAPI document and Swagger UI
+	var specJSON []byte
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", specJSON)
+	})
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+	var err error
+	if spec, err = OpenAPI(r); err != nil {
+		slog.Warn("OpenAPI document is incomplete", "error", err)
+	}
+	if specJSON, err = spec.MarshalIndent(); err != nil {
+		slog.Error("failed to render the OpenAPI document", "error", err)
+	}
 }
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local stores blobs as files under a root directory.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Ping creates and removes a file, which catches a missing or read-only root.
+func (l *Local) Ping(_ context.Context) error {
+	f, err := os.CreateTemp(l.root, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("blob dir is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+func (l *Local) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so readers never see a partial blob.
+func (l *Local) Put(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("short write: got %d of %d bytes", n, size)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Open(_ context.Context, key string) (Blob, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) // #nosec G304 -- key is validated and joined to our root
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &localBlob{File: f, info: info}, nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type localBlob struct {
+	*os.File
+	info fs.FileInfo
+}
+
+func (b *localBlob) Size() int64        { return b.info.Size() }
+func (b *localBlob) ModTime() time.Time { return b.info.ModTime() }
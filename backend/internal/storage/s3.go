@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config points at an S3-compatible service (AWS, MinIO, R2, ...).
+// Objects are addressed path-style: {Endpoint}/{Bucket}/{key}.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// S3 is a minimal S3 client signing requests with AWS Signature Version 4.
+type S3 struct {
+	endpoint *url.URL
+	cfg      S3Config
+	client   *http.Client
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3 storage needs S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+	u, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &S3{endpoint: u, cfg: cfg, client: client}, nil
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.cfg.Bucket + "/" + key
+	u.RawPath = ""
+	return &u
+}
+
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	h := http.Header{}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, h)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError("put", key, resp)
+	}
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (Blob, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError("head", key, resp)
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &s3Blob{ctx: ctx, store: s, key: key, size: resp.ContentLength, modTime: modTime}, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return responseError("delete", key, resp)
+	}
+	return nil
+}
+
+// pingKey is looked up by Ping; it need not exist.
+const pingKey = "healthz"
+
+// Ping looks up an object, which checks the endpoint, bucket and
+// credentials. A missing object is fine.
+func (s *S3) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, pingKey, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return responseError("head", pingKey, resp)
+	}
+	return nil
+}
+
+func responseError(op, key string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// s3Blob reads an object lazily with ranged GETs, reopening the stream
+// whenever the caller seeks.
+type s3Blob struct {
+	ctx     context.Context
+	store   *S3
+	key     string
+	size    int64
+	modTime time.Time
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (b *s3Blob) Size() int64        { return b.size }
+func (b *s3Blob) ModTime() time.Time { return b.modTime }
+
+func (b *s3Blob) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+	if b.body == nil {
+		h := http.Header{}
+		h.Set("Range", "bytes="+strconv.FormatInt(b.offset, 10)+"-")
+		resp, err := b.store.do(b.ctx, http.MethodGet, b.key, nil, 0, h)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			return 0, responseError("get", b.key, resp)
+		}
+		if resp.StatusCode == http.StatusOK && b.offset > 0 {
+			// Server ignored the range; skip ahead ourselves.
+			if _, err := io.CopyN(io.Discard, resp.Body, b.offset); err != nil {
+				resp.Body.Close()
+				return 0, err
+			}
+		}
+		b.body = resp.Body
+	}
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+	return n, err
+}
+
+func (b *s3Blob) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = b.offset + offset
+	case io.SeekEnd:
+		abs = b.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	if abs != b.offset && b.body != nil {
+		b.body.Close()
+		b.body = nil
+	}
+	b.offset = abs
+	return abs, nil
+}
+
+func (b *s3Blob) Close() error {
+	if b.body != nil {
+		return b.body.Close()
+	}
+	return nil
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sign adds an AWS SigV4 Authorization header. The payload is left unsigned
+// so uploads can be streamed; TLS protects it in transit.
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	req.Header.Set("Host", req.URL.Host)
+
+	var names []string
+	canonHeaders := map[string]string{}
+	for k, v := range req.Header {
+		name := strings.ToLower(k)
+		if name == "host" || name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			names = append(names, name)
+			canonHeaders[name] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	sort.Strings(names)
+	var hb strings.Builder
+	for _, n := range names {
+		hb.WriteString(n + ":" + canonHeaders[n] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		uriEncodePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		hb.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+func uriEncodePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = uriEncode(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := q[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
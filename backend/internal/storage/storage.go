@@ -0,0 +1,72 @@
+// Package storage keeps attachment bytes out of the database behind a small
+// BlobStore interface, with a local-filesystem and an S3-compatible backend.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"Base/internal/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Blob is an open stored object. It supports seeking so it can be served
+// with http.ServeContent (Range requests).
+type Blob interface {
+	io.ReadSeekCloser
+	Size() int64
+	ModTime() time.Time
+}
+
+// BlobStore stores opaque objects by key. Keys are slash-separated paths
+// made of letters, digits, '-', '_' and '.'.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (Blob, error)
+	Delete(ctx context.Context, key string) error
+	// Ping checks that the store can be reached and written to
+	Ping(ctx context.Context) error
+}
+
+// New builds the store selected by cfg.Backend: "local" or "s3".
+func New(cfg config.Storage) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		if cfg.Dir == "" {
+			return nil, errors.New("BLOB_DIR is not set")
+		}
+		return NewLocal(cfg.Dir)
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+		for _, r := range part {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+				return fmt.Errorf("invalid blob key %q", key)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO-style stand-in: an in-memory, path-style S3 endpoint
+// that checks request signatures and honours Range requests.
+type fakeS3 struct {
+	t       *testing.T
+	signer  *S3
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.validSignature(r) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodHead, http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Length", strconv.Itoa(len(obj)-start))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(obj[start:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj)))
+		if r.Method == http.MethodGet {
+			w.Write(obj)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// validSignature re-signs the request as received and compares signatures.
+func (f *fakeS3) validSignature(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	at, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
+		return false
+	}
+	clone, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for _, h := range []string{"Content-Type", "Range"} {
+		if v := r.Header.Get(h); v != "" {
+			clone.Header.Set(h, v)
+		}
+	}
+	f.signer.sign(clone, at)
+	return clone.Header.Get("Authorization") == auth
+}
+
+func newTestS3(t *testing.T) *S3 {
+	cfg := S3Config{Bucket: "attachments", AccessKey: "minio", SecretKey: "minio-secret"}
+	fake := &fakeS3{t: t, objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	cfg.Endpoint = srv.URL
+	store, err := NewS3(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.signer = store
+	return store
+}
+
+func TestBlobStores(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]BlobStore{"local": local, "s3": newTestS3(t)}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := store.Ping(ctx); err != nil {
+				t.Fatalf("ping: %v", err)
+			}
+			data := []byte("0123456789abcdefghij")
+			key := "attachments/1/2/voice-memo.m4a"
+
+			if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "audio/x-m4a"); err != nil {
+				t.Fatalf("put: %v", err)
+			}
+
+			blob, err := store.Open(ctx, key)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if blob.Size() != int64(len(data)) {
+				t.Errorf("size = %d", blob.Size())
+			}
+			if _, err := blob.Seek(10, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			rest, err := io.ReadAll(blob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(rest) != "abcdefghij" {
+				t.Errorf("read after seek = %q", rest)
+			}
+			blob.Close()
+
+			if err := store.Delete(ctx, key); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("open after delete: %v", err)
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				t.Errorf("deleting a missing blob should succeed: %v", err)
+			}
+		})
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, key := range []string{"", "/abs", "a/../b", "a//b", "dir/", "spa ce"} {
+		if validKey(key) == nil {
+			t.Errorf("key %q should be rejected", key)
+		}
+	}
+}
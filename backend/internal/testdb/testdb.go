@@ -0,0 +1,34 @@
+// Package testdb gives tests a throwaway SQLite database with the schema
+// built by the real migrations.
+package testdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"Base/internal/migrate"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open returns a database file in t's temporary directory migrated to the
+// latest version. It is closed when the test ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "api.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if _, err := migrate.New(db, migrate.All).Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
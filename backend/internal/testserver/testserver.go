@@ -0,0 +1,32 @@
+// Package testserver runs the real API router over a test database, for
+// tests of the Go client and the command-line client.
+package testserver
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"Base/internal/config"
+	"Base/internal/handlers"
+	"Base/internal/routes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Start serves every route on db until the test ends and returns the
+// server's URL.
+func Start(t testing.TB, db *gorm.DB) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handlers.SetDB(db)
+	t.Cleanup(func() { handlers.SetDB(nil) })
+
+	r := gin.New()
+	cfg := config.Defaults()
+	cfg.Auth.JWTSecret = "test-secret"
+	routes.SetupRoutes(r, cfg)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the trace
+// of an incoming traceparent header. Handlers reach the span through
+// c.Request.Context(). Requests to the skip routes are not traced.
+func Middleware(skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if slices.Contains(skip, c.FullPath()) {
+			c.Next()
+			return
+		}
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method
+		}
+		ctx, span := tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+	}
+}
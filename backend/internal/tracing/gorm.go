@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// query is what the after callback needs: the span to end and the context
+// to put back, since a transaction reuses one statement for many queries.
+type query struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// Plugin records a client span for every GORM query. Queries only join a
+// request's trace when run with db.WithContext. Install it with
+// db.Use(tracing.Plugin{}).
+type Plugin struct{}
+
+func (Plugin) Name() string { return "tracing" }
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, p := range []struct {
+		op     string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	} {
+		if err := p.before("tracing:before_"+p.op, startQuery(p.op)); err != nil {
+			return err
+		}
+		if err := p.after("tracing:after_"+p.op, endQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startQuery(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := "gorm." + op
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		parent := db.Statement.Context
+		ctx, span := tracer().Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", db.Dialector.Name()),
+				attribute.String("db.operation.name", op),
+				attribute.String("db.collection.name", db.Statement.Table),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, query{span, parent})
+	}
+}
+
+func endQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	q := v.(query)
+	db.Statement.Context = q.parent
+	span := q.span
+	defer span.End()
+	// The SQL only has placeholders; the values stay out of the trace
+	span.SetAttributes(
+		attribute.String("db.query.text", db.Statement.SQL.String()),
+		attribute.Int64("db.response.returned_rows", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
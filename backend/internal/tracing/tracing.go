@@ -0,0 +1,101 @@
+// Package tracing sets up OpenTelemetry and instruments the router and GORM.
+// Everything else starts spans through otel.Tracer, which is a no-op until
+// Setup installs a provider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"Base/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "Base/internal/tracing"
+
+func init() {
+	// W3C traceparent is honoured even with tracing off, so trace IDs from
+	// an upstream proxy still reach the error envelope and the logs.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+}
+
+// Setup installs the global tracer provider described by cfg. The returned
+// function flushes buffered spans and must be called on shutdown.
+func Setup(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			endpoint, err := tracesURL(cfg.Endpoint)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// tracesURL adds the OTLP/HTTP traces path to a bare collector address, as
+// the OTEL_EXPORTER_OTLP_ENDPOINT convention has it.
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("trace endpoint %q is not a URL like http://collector:4318", endpoint)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+// TraceID is the hex trace ID of the span in ctx, or "" if there is none.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
@@ -0,0 +1,130 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Base/internal/apierror"
+	"Base/internal/config"
+	"Base/internal/middleware"
+	"Base/internal/models"
+	"Base/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+// record installs a provider that keeps every span in memory.
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func TestRequestSpanContinuesTraceAndQueriesJoinIt(t *testing.T) {
+	rec := record(t)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tracing.Plugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Entry{}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(tracing.Middleware("/livez"), middleware.Errors())
+	r.GET("/entries/:id", func(c *gin.Context) {
+		var entry models.Entry
+		err := db.WithContext(c.Request.Context()).First(&entry, c.Param("id")).Error
+		apierror.Abort(c, apierror.New(apierror.DatabaseError).Wrap(err))
+	})
+	r.GET("/livez", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/entries/7", nil)
+	req.Header.Set("traceparent", traceparent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	var problem map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem["trace_id"] != traceID {
+		t.Errorf("trace_id = %v, want %s", problem["trace_id"], traceID)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+	}
+	server, ok := spans["GET /entries/:id"]
+	if !ok {
+		t.Fatalf("no request span among %v", spans)
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("request span trace = %s, want %s", got, traceID)
+	}
+	if server.Status().Code.String() != "Error" {
+		t.Errorf("request span status = %v, want Error for a 500", server.Status())
+	}
+	query, ok := spans["gorm.query entries"]
+	if !ok {
+		t.Fatalf("no query span among %v", spans)
+	}
+	if query.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("query span is not a child of the request span")
+	}
+	if _, ok := spans["GET /livez"]; ok {
+		t.Error("skipped route was traced")
+	}
+}
+
+func TestTraceIDWithoutSpan(t *testing.T) {
+	if id := tracing.TraceID(context.Background()); id != "" {
+		t.Errorf("TraceID = %q, want empty", id)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}})
+	if id := tracing.TraceID(trace.ContextWithSpanContext(context.Background(), sc)); id != "01000000000000000000000000000000" {
+		t.Errorf("TraceID = %q", id)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	for _, cfg := range []config.Tracing{
+		{Exporter: "otlp", Endpoint: "collector:4318"},
+		{Exporter: "zipkin"},
+	} {
+		if _, err := tracing.Setup(context.Background(), cfg); err == nil {
+			t.Errorf("Setup(%+v) succeeded, want an error", cfg)
+		}
+	}
+	shutdown, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
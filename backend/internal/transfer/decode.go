@@ -0,0 +1,238 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"Base/internal/apierror"
+)
+
+// Decode reads every row from an import file. Problems with individual rows
+// are reported on the row; an error is returned only when the file as a
+// whole can't be read.
+func Decode(r io.Reader, f Format) ([]Row, error) {
+	switch f {
+	case JSON:
+		return decodeJSON(r)
+	case CSV:
+		return decodeCSV(r)
+	case Markdown:
+		return decodeMarkdown(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", f)
+}
+
+type jsonRecord struct {
+	Situation  string `json:"situation"`
+	Text       string `json:"text"`
+	Icon       string `json:"icon"`
+	Colour     string `json:"colour"`
+	Color      string `json:"color"`
+	Tags       string `json:"tags"`
+	RemindAt   string `json:"remind_at"`
+	Recurrence string `json:"recurrence"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func decodeJSON(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []jsonRecord
+	if err := json.Unmarshal(data, &items); err != nil {
+		// Also accept {"entries": [...]} as produced by some exporters.
+		var wrapped struct {
+			Entries []jsonRecord `json:"entries"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil || wrapped.Entries == nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		items = wrapped.Entries
+	}
+
+	rows := make([]Row, len(items))
+	for i, item := range items {
+		colour := item.Colour
+		if colour == "" {
+			colour = item.Color
+		}
+		rows[i] = newRow(i+1, Record{
+			Situation:  item.Situation,
+			Text:       item.Text,
+			Icon:       item.Icon,
+			Colour:     colour,
+			Tags:       item.Tags,
+			Recurrence: item.Recurrence,
+		}, item.RemindAt, item.CreatedAt)
+	}
+	return rows, nil
+}
+
+func decodeCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "color" {
+			name = "colour"
+		}
+		cols[name] = i
+	}
+	if _, ok := cols["situation"]; !ok {
+		return nil, errors.New(`CSV header must contain a "situation" column`)
+	}
+	if _, ok := cols["text"]; !ok {
+		return nil, errors.New(`CSV header must contain a "text" column`)
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	var rows []Row
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				rows = append(rows, Row{Line: perr.Line, Errors: []error{apierror.New(apierror.InvalidFile).Detailf("%v", perr.Err)}})
+				continue
+			}
+			return nil, err
+		}
+		rows = append(rows, newRow(line, Record{
+			Situation:  field(rec, "situation"),
+			Text:       field(rec, "text"),
+			Icon:       field(rec, "icon"),
+			Colour:     field(rec, "colour"),
+			Tags:       field(rec, "tags"),
+			Recurrence: field(rec, "recurrence"),
+		}, field(rec, "remind_at"), field(rec, "created_at")))
+	}
+	return rows, nil
+}
+
+// decodeMarkdown reads the layout written by encodeMarkdown: a "## " heading
+// per entry, an optional "- key: value" metadata list, then the text.
+func decodeMarkdown(r io.Reader) ([]Row, error) {
+	var (
+		rows    []Row
+		current *mdSection
+	)
+	flush := func() {
+		if current != nil {
+			rows = append(rows, current.row())
+		}
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		if strings.HasPrefix(text, "## ") {
+			flush()
+			current = &mdSection{line: line, situation: strings.TrimSpace(text[3:]), meta: map[string]string{}, inMeta: true}
+			continue
+		}
+		if current == nil {
+			continue // document title and preamble
+		}
+		if current.inMeta {
+			trimmed := strings.TrimSpace(text)
+			if trimmed == "" && len(current.meta) == 0 {
+				continue
+			}
+			if key, value, ok := metaLine(trimmed); ok {
+				current.meta[key] = value
+				continue
+			}
+			current.inMeta = false
+			if trimmed == "" {
+				continue
+			}
+		}
+		if strings.HasPrefix(text, `\`) {
+			text = text[1:]
+		}
+		current.text = append(current.text, text)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return rows, nil
+}
+
+type mdSection struct {
+	line      int
+	situation string
+	meta      map[string]string
+	text      []string
+	inMeta    bool
+}
+
+func (s *mdSection) row() Row {
+	colour := s.meta["colour"]
+	if colour == "" {
+		colour = s.meta["color"]
+	}
+	return newRow(s.line, Record{
+		Situation:  s.situation,
+		Text:       strings.TrimRight(strings.Join(s.text, "\n"), "\n"),
+		Icon:       s.meta["icon"],
+		Colour:     colour,
+		Tags:       s.meta["tags"],
+		Recurrence: s.meta["recurrence"],
+	}, s.meta["remind_at"], s.meta["created_at"])
+}
+
+func metaLine(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "- ") {
+		return "", "", false
+	}
+	key, value, ok := strings.Cut(s[2:], ":")
+	if !ok {
+		return "", "", false
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	switch key {
+	case "icon", "colour", "color", "tags", "remind_at", "recurrence", "created_at":
+		return key, strings.TrimSpace(value), true
+	}
+	return "", "", false
+}
+
+// newRow builds a row from a record whose timestamps are still raw strings.
+func newRow(line int, rec Record, remindAt, createdAt string) Row {
+	row := Row{Line: line, Record: rec}
+	var err error
+	if row.Record.RemindAt, err = parseTime("remind_at", remindAt); err != nil {
+		row.Errors = append(row.Errors, err)
+	}
+	if row.Record.CreatedAt, err = parseTime("created_at", createdAt); err != nil {
+		row.Errors = append(row.Errors, err)
+	}
+	return row
+}
@@ -0,0 +1,85 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var csvHeader = []string{"situation", "text", "icon", "colour", "tags", "remind_at", "recurrence", "created_at"}
+
+// Encode writes records in the given format.
+func Encode(w io.Writer, f Format, records []Record) error {
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case CSV:
+		return encodeCSV(w, records)
+	case Markdown:
+		return encodeMarkdown(w, records)
+	}
+	return fmt.Errorf("unsupported format %q", f)
+}
+
+func encodeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{
+			r.Situation, r.Text, r.Icon, r.Colour, r.Tags, formatTime(r.RemindAt), r.Recurrence, formatTime(r.CreatedAt),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// encodeMarkdown writes one "## situation" section per record with the
+// metadata as a bullet list, followed by the text. Text lines that would be
+// mistaken for a heading are escaped with a backslash.
+func encodeMarkdown(w io.Writer, records []Record) error {
+	var b strings.Builder
+	b.WriteString("# Entries\n")
+	for _, r := range records {
+		fmt.Fprintf(&b, "\n## %s\n\n", strings.Join(strings.Fields(r.Situation), " "))
+		fmt.Fprintf(&b, "- icon: %s\n", r.Icon)
+		fmt.Fprintf(&b, "- colour: %s\n", r.Colour)
+		if r.Tags != "" {
+			fmt.Fprintf(&b, "- tags: %s\n", r.Tags)
+		}
+		if r.RemindAt != nil {
+			fmt.Fprintf(&b, "- remind_at: %s\n", formatTime(r.RemindAt))
+		}
+		if r.Recurrence != "" {
+			fmt.Fprintf(&b, "- recurrence: %s\n", r.Recurrence)
+		}
+		if r.CreatedAt != nil {
+			fmt.Fprintf(&b, "- created_at: %s\n", formatTime(r.CreatedAt))
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(r.Text, "\n") {
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, `\`) {
+				line = `\` + line
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
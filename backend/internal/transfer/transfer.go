@@ -0,0 +1,145 @@
+package transfer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"Base/internal/apierror"
+	"Base/internal/catalog"
+	"Base/internal/ical"
+	"Base/internal/models"
+)
+
+// Format is a supported import/export file format.
+type Format string
+
+const (
+	JSON     Format = "json"
+	CSV      Format = "csv"
+	Markdown Format = "md"
+)
+
+// Record is the portable shape of an entry used by every format.
+type Record struct {
+	Situation  string     `json:"situation"`
+	Text       string     `json:"text"`
+	Icon       string     `json:"icon"`
+	Colour     string     `json:"colour"`
+	Tags       string     `json:"tags,omitempty"`
+	RemindAt   *time.Time `json:"remind_at,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+}
+
+// Row is a record read from an import file, with its position in the file
+// and anything wrong with it. Errors are *apierror.Error values.
+type Row struct {
+	Line   int     `json:"line"`
+	Record Record  `json:"record"`
+	Errors []error `json:"-"`
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		return JSON, nil
+	case "csv":
+		return CSV, nil
+	case "md", "markdown":
+		return Markdown, nil
+	}
+	return "", fmt.Errorf("unsupported format %q (use json, csv or md)", s)
+}
+
+// FormatFromFilename guesses the format from a file extension.
+func FormatFromFilename(name string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	if ext == "" {
+		return "", fmt.Errorf("cannot detect format of %q", name)
+	}
+	return ParseFormat(ext)
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case Markdown:
+		return "text/markdown; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+func FromEntries(entries []models.Entry) []Record {
+	records := make([]Record, len(entries))
+	for i, e := range entries {
+		created := e.CreatedAt
+		records[i] = Record{
+			Situation:  e.Situation,
+			Text:       e.Text,
+			Icon:       e.Icon,
+			Colour:     e.Colour,
+			Tags:       e.Tags,
+			RemindAt:   e.RemindAt,
+			Recurrence: e.Recurrence,
+			CreatedAt:  &created,
+		}
+	}
+	return records
+}
+
+// Normalize trims the record, maps icon and colour onto the catalog and
+// records a validation error for every problem found.
+func (r *Row) Normalize() {
+	r.Record.Situation = strings.TrimSpace(r.Record.Situation)
+	r.Record.Text = strings.TrimSpace(r.Record.Text)
+	r.Record.Tags = models.JoinTags(strings.FieldsFunc(r.Record.Tags, func(c rune) bool {
+		return c == ',' || unicode.IsSpace(c)
+	}))
+
+	if r.Record.Situation == "" {
+		r.Errors = append(r.Errors, apierror.New(apierror.MissingFields).Localized("detail.field_required", "field", "situation"))
+	}
+	if r.Record.Text == "" {
+		r.Errors = append(r.Errors, apierror.New(apierror.MissingFields).Localized("detail.field_required", "field", "text"))
+	}
+	if icon, ok := catalog.NormalizeIcon(r.Record.Icon); ok {
+		r.Record.Icon = icon
+	} else {
+		r.Errors = append(r.Errors, apierror.New(apierror.ValidationFailed).Localized("detail.unknown_icon", "icon", r.Record.Icon))
+	}
+	if colour, ok := catalog.NormalizeColour(r.Record.Colour); ok {
+		r.Record.Colour = colour
+	} else {
+		r.Errors = append(r.Errors, apierror.New(apierror.ValidationFailed).Localized("detail.unknown_colour", "colour", r.Record.Colour))
+	}
+	r.Record.Recurrence = strings.TrimPrefix(strings.TrimSpace(r.Record.Recurrence), "RRULE:")
+	if err := ical.ValidateRRule(r.Record.Recurrence); err != nil {
+		r.Errors = append(r.Errors, apierror.New(apierror.InvalidReminder).Detailf("%v", err))
+	} else if r.Record.Recurrence != "" && r.Record.RemindAt == nil {
+		r.Errors = append(r.Errors, apierror.New(apierror.InvalidReminder).Localized("detail.recurrence_requires_remind_at"))
+	}
+}
+
+// Valid reports whether the row has no errors.
+func (r *Row) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func parseTime(field, s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, apierror.New(apierror.ValidationFailed).Localized("detail.invalid_time", "field", field, "value", s)
+}
@@ -0,0 +1,75 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"Base/internal/apierror"
+)
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2021, 3, 14, 9, 26, 0, 0, time.UTC)
+	records := []Record{
+		{Situation: "Job interview", Text: "Breathe.\n## not a heading\nSmile.", Icon: "briefcase", Colour: "blue", Tags: "work nerves",
+			RemindAt: &created, Recurrence: "FREQ=YEARLY", CreatedAt: &created},
+		{Situation: "Rainy day", Text: "Tea, book, \"blanket\", done", Icon: "coffee", Colour: "green", CreatedAt: &created},
+	}
+
+	for _, f := range []Format{JSON, CSV, Markdown} {
+		t.Run(string(f), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, f, records); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			rows, err := Decode(&buf, f)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(rows) != len(records) {
+				t.Fatalf("expected %d rows, got %d", len(records), len(rows))
+			}
+			for i, row := range rows {
+				if !row.Valid() {
+					t.Fatalf("row %d has errors: %v", i, row.Errors)
+				}
+				got, want := row.Record, records[i]
+				if got.Situation != want.Situation || got.Text != want.Text || got.Icon != want.Icon || got.Colour != want.Colour ||
+					got.Tags != want.Tags || got.Recurrence != want.Recurrence || (got.RemindAt == nil) != (want.RemindAt == nil) {
+					t.Errorf("row %d: got %+v, want %+v", i, got, want)
+				}
+				if got.CreatedAt == nil || !got.CreatedAt.Equal(created) {
+					t.Errorf("row %d: created_at %v, want %v", i, got.CreatedAt, created)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	input := "situation,text,icon,color\nA,one,Lightbulb,violet\nB,,rocket,black\n"
+	rows, err := Decode(strings.NewReader(input), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows[0].Normalize()
+	if !rows[0].Valid() || rows[0].Record.Icon != "idea" || rows[0].Record.Colour != "purple" {
+		t.Errorf("expected aliases to map onto the catalog, got %+v %v", rows[0].Record, rows[0].Errors)
+	}
+
+	rows[1].Normalize()
+	if len(rows[1].Errors) != 3 {
+		t.Fatalf("expected 3 errors for row on line %d, got %v", rows[1].Line, rows[1].Errors)
+	}
+	for i, want := range []apierror.Code{apierror.MissingFields, apierror.ValidationFailed, apierror.ValidationFailed} {
+		if !errors.Is(rows[1].Errors[i], want) {
+			t.Errorf("error %d = %v, want code %s", i, rows[1].Errors[i], want)
+		}
+	}
+	if rows[1].Line != 3 {
+		t.Errorf("expected line 3, got %d", rows[1].Line)
+	}
+}
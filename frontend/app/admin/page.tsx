@@ -42,7 +42,7 @@ export default function AdminPanel() {
     message: "",
     type: "success" as ToastType,
   });
-  const [confirm, setConfirm] = useState({ show: false, id: 0 });
+  const [confirm, setConfirm] = useState({ show: false, id: 0, ids: [] as number[] });
   const [isDeleting, setIsDeleting] = useState(false);
   
   // Edit Modal State
@@ -69,8 +69,8 @@ export default function AdminPanel() {
     setLoading(true);
     try {
       const [usersRes, entriesRes] = await Promise.all([
-        api.get("/admin/users"),
-        api.get("/admin/entries"),
+        api.get("/api/v1/admin/users"),
+        api.get("/api/v1/admin/entries"),
       ]);
       setData({
         users: usersRes.data ?? [],
@@ -89,15 +89,33 @@ export default function AdminPanel() {
   }, [isAuthorized, activeTab, loadData]);
 
   // --- Action: Delete Logic ---
-  const triggerDelete = (id: number) => setConfirm({ show: true, id });
+  const triggerDelete = (id: number) => setConfirm({ show: true, id, ids: [] });
+  const triggerBulkDelete = (ids: number[]) => setConfirm({ show: true, id: 0, ids });
 
   const executeDelete = async () => {
     setIsDeleting(true);
     try {
+      if (confirm.ids.length > 0) {
+        // One request for the whole selection; failures don't block the rest
+        const res = await api.post("/api/v1/admin/entries/batch", {
+          mode: "per_item",
+          operations: confirm.ids.map((id) => ({ op: "delete", id })),
+        });
+        const { succeeded, failed } = res.data;
+        showToast(
+          failed > 0
+            ? `Deleted ${succeeded} entries, ${failed} failed`
+            : `Deleted ${succeeded} entries`,
+          failed > 0 ? "error" : "success",
+        );
+        loadData();
+        return;
+      }
+
       const endpoint =
         activeTab === "users"
-          ? `/admin/users/${confirm.id}`
-          : `/admin/entries/${confirm.id}`;
+          ? `/api/v1/admin/users/${confirm.id}`
+          : `/api/v1/admin/entries/${confirm.id}`;
       await api.delete(endpoint);
       showToast(
         `${activeTab === "users" ? "User" : "Entry"} deleted successfully`,
@@ -108,7 +126,7 @@ export default function AdminPanel() {
       showToast("Deletion failed. Please try again.", "error");
     } finally {
       setIsDeleting(false);
-      setConfirm({ show: false, id: 0 });
+      setConfirm({ show: false, id: 0, ids: [] });
     }
   };
 
@@ -128,7 +146,7 @@ export default function AdminPanel() {
     setIsUpdating(true);
     try {
       // Backend now expects PUT /admin/users/:id
-      await api.put(`/admin/users/${editModal.user.ID}`, {
+      await api.put(`/api/v1/admin/users/${editModal.user.ID}`, {
         name: editModal.user.name,
         // Only send password if it's not empty, otherwise backend might hash empty string if logic isn't careful.
         // My backend logic checks: if user.Password != "" { hash it }
@@ -259,6 +277,7 @@ export default function AdminPanel() {
           type={activeTab}
           data={currentList}
           onDelete={triggerDelete}
+          onDeleteSelected={activeTab === 'entries' ? triggerBulkDelete : undefined}
           onEdit={activeTab === 'users' ? openEditModal : undefined}
           onViewEntries={activeTab === 'users' ? viewUserEntries : undefined}
         />
@@ -275,10 +294,14 @@ export default function AdminPanel() {
       <ConfirmModal
         isOpen={confirm.show}
         title="Confirm Deletion"
-        message={`Are you sure you want to remove this ${activeTab === "users" ? "user" : "entry"}? This action is permanent and cannot be reversed.`}
+        message={
+          confirm.ids.length > 0
+            ? `Are you sure you want to remove ${confirm.ids.length} entries? This action is permanent and cannot be reversed.`
+            : `Are you sure you want to remove this ${activeTab === "users" ? "user" : "entry"}? This action is permanent and cannot be reversed.`
+        }
         isLoading={isDeleting}
         onConfirm={executeDelete}
-        onCancel={() => setConfirm({ show: false, id: 0 })}
+        onCancel={() => setConfirm({ show: false, id: 0, ids: [] })}
       />
 
        {/* Edit User Modal */}
@@ -22,7 +22,7 @@ export default function LoginPage() {
     setLoading(true);
 
     try {
-      const res = await api.post("/user/login", form);
+      const res = await api.post("/api/v1/session", form);
 
       // Debug: log full response so we can inspect role/token shape
       if (typeof window !== 'undefined') console.debug('Login response:', res.data);
@@ -28,7 +28,7 @@ export default function RegisterPage() {
     setLoading(true);
 
     try {
-      await api.post('/user/create', form);
+      await api.post('/api/v1/users', form);
       toast.success('Account created successfully!');
       router.push('/login');
     } catch (err) {
@@ -56,7 +56,7 @@ export default function CreateEntryForm({ onEntryCreated }: Props) {
           <div className="text-sm text-red-700">
             <p className="font-bold">Entry Refused</p>
             {/* eslint-disable-next-line @typescript-eslint/no-explicit-any */}
-            <p>{(createMutation.error as any)?.response?.data?.error || 'Failed to create entry'}</p>
+            <p>{(createMutation.error as Error)?.message || 'Failed to create entry'}</p>
           </div>
         </div>
       )}
@@ -19,6 +19,7 @@ interface DataTableProps {
   type: "users" | "entries";
   data: TableItem[];
   onDelete: (id: number) => void;
+  onDeleteSelected?: (ids: number[]) => void;
   onEdit?: (user: User) => void;
   onViewEntries?: (userId: number) => void;
 }
@@ -37,8 +38,9 @@ const colorMap: Record<string, string> = {
   default: 'bg-slate-400'
 };
 
-export const DataTable = ({ type, data, onDelete, onEdit, onViewEntries }: DataTableProps) => {
+export const DataTable = ({ type, data, onDelete, onDeleteSelected, onEdit, onViewEntries }: DataTableProps) => {
   const [toast, setToast] = useState({ show: false, message: '', type: 'success' as ToastType });
+  const [selected, setSelected] = useState<Set<number>>(new Set());
 
   const validData = data.filter((item): item is TableItem => {
     if (type === "users") return !!(item as User).email;
@@ -55,6 +57,23 @@ export const DataTable = ({ type, data, onDelete, onEdit, onViewEntries }: DataT
     }
   };
 
+  const selectable = !!onDeleteSelected;
+  const visibleSelected = validData.filter((item) => selected.has(item.ID)).map((item) => item.ID);
+  const allSelected = validData.length > 0 && visibleSelected.length === validData.length;
+
+  const toggleSelected = (id: number) => {
+    setSelected((prev) => {
+      const next = new Set(prev);
+      if (next.has(id)) next.delete(id);
+      else next.add(id);
+      return next;
+    });
+  };
+
+  const toggleAll = () => {
+    setSelected(allSelected ? new Set() : new Set(validData.map((item) => item.ID)));
+  };
+
   const getInitials = (name: string) => {
     return name ? name.substring(0, 2).toUpperCase() : "??";
   };
@@ -78,12 +97,29 @@ export const DataTable = ({ type, data, onDelete, onEdit, onViewEntries }: DataT
             Total: {validData.length} items
           </p>
         </div>
+        {onDeleteSelected && visibleSelected.length > 0 && (
+          <button
+            onClick={() => {
+              onDeleteSelected(visibleSelected);
+              setSelected(new Set());
+            }}
+            className="flex items-center gap-2 px-4 py-2 rounded-xl text-sm font-bold text-red-500 bg-red-50 dark:bg-red-900/20 hover:bg-red-100 dark:hover:bg-red-900/40 transition-all active:scale-95"
+          >
+            <Trash2 size={16} strokeWidth={2.5} />
+            Delete selected ({visibleSelected.length})
+          </button>
+        )}
       </div>
 
       <div className="overflow-x-auto">
         <table className="w-full text-left border-collapse">
           <thead>
             <tr className="border-b border-slate-100 dark:border-slate-800 text-xs font-bold text-slate-400 dark:text-slate-500 uppercase tracking-wider">
+              {selectable && (
+                <th className="p-5 pl-8 w-10">
+                  <input type="checkbox" checked={allSelected} onChange={toggleAll} aria-label="Select all" />
+                </th>
+              )}
               <th className="p-5 pl-8 w-16">ID</th>
               <th className="p-5">{type === "users" ? "User Profile" : "Memory Detail"}</th>
               <th className="p-5 hidden md:table-cell">Date Created</th>
@@ -96,7 +132,7 @@ export const DataTable = ({ type, data, onDelete, onEdit, onViewEntries }: DataT
             <AnimatePresence mode="popLayout">
               {validData.length === 0 ? (
                 <motion.tr initial={{ opacity: 0 }} animate={{ opacity: 1 }} exit={{ opacity: 0 }}>
-                  <td colSpan={5} className="p-12 text-center">
+                  <td colSpan={selectable ? 6 : 5} className="p-12 text-center">
                     <div className="flex flex-col items-center justify-center text-slate-400 dark:text-slate-500">
                       <Search size={48} className="mb-4 opacity-20" />
                       <p className="font-medium">No records found</p>
@@ -114,6 +150,16 @@ export const DataTable = ({ type, data, onDelete, onEdit, onViewEntries }: DataT
                     transition={{ duration: 0.2, delay: index * 0.05 }}
                     className="group hover:bg-slate-50/80 dark:hover:bg-slate-800/50 transition-colors border-b border-slate-50 dark:border-slate-800 last:border-0"
                   >
+                    {selectable && (
+                      <td className="p-5 pl-8">
+                        <input
+                          type="checkbox"
+                          checked={selected.has(item.ID)}
+                          onChange={() => toggleSelected(item.ID)}
+                          aria-label={`Select #${item.ID}`}
+                        />
+                      </td>
+                    )}
                     <td className="p-5 pl-8 text-slate-400 dark:text-slate-500 font-mono text-xs">#{item.ID}</td>
 
                     <td className="p-5">
@@ -16,7 +16,7 @@ export default function Header() {
       try {
         setIsLoading(true);
         setError(null);
-        const res = await api.post('/user/getusername');
+        const res = await api.get('/api/v1/me');
         // Проверяем разные варианты ответа от бэкенда
         const name = res.data.username || res.data.name || res.data.user?.name || 'User';
         setUsername(name);
@@ -35,7 +35,7 @@ export default function Header() {
   const handleSignOut = async () => {
     try {
       // Call backend logout endpoint
-      await api.post('/user/logout');
+      await api.delete('/api/v1/session');
     } catch (err) {   
       console.error('Logout endpoint error:', err);
     } finally {
@@ -29,7 +29,7 @@ export const Sidebar = ({ activeTab, setActiveTab }: SidebarProps) => {
     setIsLoggingOut(true);
     try {
       // 1. Call backend logout endpoint
-      await api.post('/user/logout');
+      await api.delete('/api/v1/session');
     } catch (err) {
       console.error('Logout endpoint error:', err);
     } finally {
@@ -1,3 +1,4 @@
+import { useEffect } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import api from '../lib/axios';
 import { Entry } from '../types';
@@ -5,7 +6,7 @@ import { toast } from 'sonner';
 
 // Backend often returns data wrappers, we parse them here
 const fetchEntries = async (): Promise<Entry[]> => {
-    const res = await api.get('/user/entries');
+    const res = await api.get('/api/v1/entries');
     const rawData = Array.isArray(res.data) ? res.data : (res.data.data || res.data.entries || []);
 
     // Normalize data to handle ID/id and case mismatch
@@ -22,6 +23,22 @@ const fetchEntries = async (): Promise<Entry[]> => {
 };
 
 export const useEntries = () => {
+    const queryClient = useQueryClient();
+
+    // Live updates: the backend pushes entry changes made on other devices
+    // (or by an admin) over Server-Sent Events. The auth cookie is sent with
+    // the stream, and EventSource reconnects with Last-Event-ID by itself.
+    useEffect(() => {
+        if (typeof window === 'undefined' || typeof EventSource === 'undefined') return;
+
+        const source = new EventSource(`${api.defaults.baseURL ?? ''}/api/v1/events`, { withCredentials: true });
+        const refresh = () => queryClient.invalidateQueries({ queryKey: ['entries'] });
+        const types = ['entry.created', 'entry.updated', 'entry.deleted', 'entries.changed', 'reset'];
+        types.forEach((type) => source.addEventListener(type, refresh));
+
+        return () => source.close();
+    }, [queryClient]);
+
     return useQuery({
         queryKey: ['entries'],
         queryFn: fetchEntries,
@@ -41,7 +58,7 @@ export const useCreateEntry = () => {
                 colour: newEntry.colour,
                 icon: newEntry.icon,
             };
-            const res = await api.post('/user/entries', payload);
+            const res = await api.post('/api/v1/entries', payload);
             return res.data;
         },
         onSuccess: () => {
@@ -66,7 +83,7 @@ export const useUpdateEntry = () => {
                 colour: entry.colour,
                 icon: entry.icon,
             };
-            const res = await api.put(`/user/entries/${entry.ID}`, payload);
+            const res = await api.put(`/api/v1/entries/${entry.ID}`, payload);
             return res.data;
         },
         onSuccess: () => {
@@ -84,7 +101,7 @@ export const useDeleteEntry = () => {
 
     return useMutation({
         mutationFn: async (id: number) => {
-            await api.delete(`/user/entries/${id}`);
+            await api.delete(`/api/v1/entries/${id}`);
         },
         onSuccess: () => {
             queryClient.invalidateQueries({ queryKey: ['entries'] });
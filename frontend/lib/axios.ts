@@ -73,6 +73,16 @@ api.interceptors.response.use(
       return Promise.reject(new Error('Server connection failed. Check if backend is running.'));
     }
 
+    // Ошибки API приходят в формате RFC 7807 (application/problem+json):
+    // показываем пользователю detail или title вместо "Request failed with status code ..."
+    const problem = error.response.data;
+    if (problem && typeof problem === 'object' && problem.code) {
+      const fieldErrors = Array.isArray(problem.errors)
+        ? problem.errors.map((e: { message: string }) => e.message).join(', ')
+        : '';
+      error.message = fieldErrors || problem.detail || problem.title || error.message;
+    }
+
     // Если сервер вернул 401 (Не авторизован)
     if (error.response.status === 401) {
       if (typeof window !== 'undefined') {